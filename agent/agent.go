@@ -45,6 +45,16 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
+	if a.Config.Agent.APIListen != "" {
+		api, err := newAPI(a)
+		if err != nil {
+			return fmt.Errorf("could not start API: %v", err)
+		}
+		log.Printf("I! [agent] Listening for API requests on %s", api.Addr())
+		go api.serve()
+		defer api.shutdown()
+	}
+
 	log.Printf("D! [agent] Connecting outputs")
 	err = a.connectOutputs(ctx)
 	if err != nil {
@@ -267,6 +277,11 @@ func (a *Agent) runInputs(
 		go func(input *models.RunningInput) {
 			defer wg.Done()
 
+			if input.Config.Schedule != nil {
+				a.gatherOnSchedule(ctx, acc, input, interval)
+				return
+			}
+
 			if a.Config.Agent.RoundInterval {
 				err := internal.SleepContext(
 					ctx, internal.AlignDuration(startTime, interval))
@@ -297,26 +312,60 @@ func (a *Agent) gatherOnInterval(
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	requested := false
 	for {
-		err := internal.SleepContext(ctx, internal.RandomDuration(jitter))
-		if err != nil {
-			return
+		// Requested gathers are not subject to the collection jitter.
+		if !requested {
+			err := internal.SleepContext(ctx, internal.RandomDuration(jitter))
+			if err != nil {
+				return
+			}
 		}
 
-		err = a.gatherOnce(acc, input, interval)
+		err := a.gatherOnce(acc, input, interval)
 		if err != nil {
 			acc.AddError(err)
 		}
 
 		select {
 		case <-ticker.C:
-			continue
+			requested = false
+		case <-input.GatherRequested:
+			requested = true
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// gatherOnSchedule runs an input's gather function at the times selected by
+// its schedule until the context is done.
+func (a *Agent) gatherOnSchedule(
+	ctx context.Context,
+	acc telegraf.Accumulator,
+	input *models.RunningInput,
+	timeout time.Duration,
+) {
+	defer panicRecover(input)
+
+	ticker := NewScheduleTicker(input.Config.Schedule)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-input.GatherRequested:
+		case <-ctx.Done():
+			return
+		}
+
+		err := a.gatherOnce(acc, input, timeout)
+		if err != nil {
+			acc.AddError(err)
+		}
+	}
+}
+
 // gatherOnce runs the input's Gather function once, logging a warning each
 // interval it fails to complete before.
 func (a *Agent) gatherOnce(
@@ -573,6 +622,8 @@ func (a *Agent) flush(
 		select {
 		case <-ticker.C:
 			logError(a.flushOnce(output, interval, output.Write))
+		case <-output.FlushRequested:
+			logError(a.flushOnce(output, interval, output.Write))
 		case <-output.BatchReady:
 			// Favor the ticker over batch ready
 			select {
@@ -0,0 +1,316 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+)
+
+// api serves the status of the running agent and allows gathers and flushes
+// to be requested over HTTP.
+type api struct {
+	agent     *Agent
+	token     string
+	startTime time.Time
+
+	server   *http.Server
+	listener net.Listener
+}
+
+type pluginInfo struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+}
+
+type pluginsResponse struct {
+	Inputs      []pluginInfo `json:"inputs"`
+	Processors  []pluginInfo `json:"processors"`
+	Aggregators []pluginInfo `json:"aggregators"`
+	Outputs     []pluginInfo `json:"outputs"`
+}
+
+type statusResponse struct {
+	Version  string `json:"version"`
+	Hostname string `json:"hostname"`
+	Uptime   string `json:"uptime"`
+	Interval string `json:"interval"`
+	Flush    string `json:"flush_interval"`
+	Inputs   int    `json:"inputs"`
+	Outputs  int    `json:"outputs"`
+}
+
+type inputStatus struct {
+	pluginInfo
+	Interval           string     `json:"interval"`
+	Schedule           string     `json:"schedule,omitempty"`
+	LastGather         *time.Time `json:"last_gather,omitempty"`
+	LastGatherDuration int64      `json:"last_gather_duration_ns"`
+	MetricsGathered    int64      `json:"metrics_gathered"`
+	Errors             int64      `json:"errors"`
+	LastError          string     `json:"last_error,omitempty"`
+	LastErrorTime      *time.Time `json:"last_error_time,omitempty"`
+}
+
+type outputStatus struct {
+	pluginInfo
+	BufferSize        int        `json:"buffer_size"`
+	BufferLimit       int        `json:"buffer_limit"`
+	MetricsWritten    int64      `json:"metrics_written"`
+	MetricsDropped    int64      `json:"metrics_dropped"`
+	LastWrite         *time.Time `json:"last_write,omitempty"`
+	LastWriteDuration int64      `json:"last_write_duration_ns"`
+	Errors            int64      `json:"errors"`
+	LastError         string     `json:"last_error,omitempty"`
+	LastErrorTime     *time.Time `json:"last_error_time,omitempty"`
+}
+
+type triggerResponse struct {
+	Triggered []pluginInfo `json:"triggered"`
+}
+
+func newAPI(agent *Agent) (*api, error) {
+	cfg := agent.Config.Agent
+
+	tlsConfig, err := (&tls.ServerConfig{
+		TLSCert:           cfg.APITLSCert,
+		TLSKey:            cfg.APITLSKey,
+		TLSAllowedCACerts: cfg.APITLSAllowedCACerts,
+	}).TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &api{
+		agent:     agent,
+		token:     cfg.APIToken,
+		startTime: time.Now(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", a.get(a.serveStatus))
+	mux.HandleFunc("/plugins", a.get(a.servePlugins))
+	mux.HandleFunc("/inputs", a.get(a.serveInputs))
+	mux.HandleFunc("/outputs", a.get(a.serveOutputs))
+	mux.HandleFunc("/inputs/gather", a.post(a.serveGather))
+	mux.HandleFunc("/outputs/flush", a.post(a.serveFlush))
+
+	a.server = &http.Server{
+		Handler:   internal.TokenAuthHandler(a.token, a.authFailed)(mux),
+		TLSConfig: tlsConfig,
+	}
+
+	a.listener, err = net.Listen("tcp", cfg.APIListen)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Addr returns the address the API is listening on.
+func (a *api) Addr() net.Addr {
+	return a.listener.Addr()
+}
+
+func (a *api) serve() {
+	var err error
+	if a.server.TLSConfig != nil {
+		err = a.server.ServeTLS(a.listener, "", "")
+	} else {
+		err = a.server.Serve(a.listener)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		log.Printf("E! [agent] Error serving API: %v", err)
+	}
+}
+
+func (a *api) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	a.server.Shutdown(ctx)
+}
+
+func (a *api) authFailed(w http.ResponseWriter, code int) {
+	http.Error(w, http.StatusText(code), code)
+}
+
+func (a *api) get(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (a *api) post(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (a *api) serveStatus(w http.ResponseWriter, r *http.Request) {
+	cfg := a.agent.Config.Agent
+	writeJSON(w, http.StatusOK, statusResponse{
+		Version:  internal.Version(),
+		Hostname: cfg.Hostname,
+		Uptime:   time.Since(a.startTime).Round(time.Second).String(),
+		Interval: cfg.Interval.Duration.String(),
+		Flush:    cfg.FlushInterval.Duration.String(),
+		Inputs:   len(a.agent.Config.Inputs),
+		Outputs:  len(a.agent.Config.Outputs),
+	})
+}
+
+func (a *api) servePlugins(w http.ResponseWriter, r *http.Request) {
+	c := a.agent.Config
+	resp := pluginsResponse{
+		Inputs:      []pluginInfo{},
+		Processors:  []pluginInfo{},
+		Aggregators: []pluginInfo{},
+		Outputs:     []pluginInfo{},
+	}
+	for _, input := range c.Inputs {
+		resp.Inputs = append(resp.Inputs, pluginInfo{input.Config.Name, input.Config.Alias})
+	}
+	for _, processor := range c.Processors {
+		resp.Processors = append(resp.Processors, pluginInfo{processor.Config.Name, processor.Config.Alias})
+	}
+	for _, aggregator := range c.Aggregators {
+		resp.Aggregators = append(resp.Aggregators, pluginInfo{aggregator.Config.Name, aggregator.Config.Alias})
+	}
+	for _, output := range c.Outputs {
+		resp.Outputs = append(resp.Outputs, pluginInfo{output.Config.Name, output.Config.Alias})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (a *api) serveInputs(w http.ResponseWriter, r *http.Request) {
+	resp := []inputStatus{}
+	for _, input := range a.agent.Config.Inputs {
+		info := pluginInfo{input.Config.Name, input.Config.Alias}
+		if !matches(r, info) {
+			continue
+		}
+
+		interval := a.agent.Config.Agent.Interval.Duration
+		if input.Config.Interval != 0 {
+			interval = input.Config.Interval
+		}
+
+		var schedule string
+		if input.Config.Schedule != nil {
+			schedule = input.Config.Schedule.String()
+		}
+
+		status := input.Status()
+		resp = append(resp, inputStatus{
+			pluginInfo:         info,
+			Interval:           interval.String(),
+			Schedule:           schedule,
+			LastGather:         timePtr(status.LastGather),
+			LastGatherDuration: status.LastGatherDuration.Nanoseconds(),
+			MetricsGathered:    input.MetricsGathered.Get(),
+			Errors:             status.Errors,
+			LastError:          status.LastError,
+			LastErrorTime:      timePtr(status.LastErrorTime),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (a *api) serveOutputs(w http.ResponseWriter, r *http.Request) {
+	resp := []outputStatus{}
+	for _, output := range a.agent.Config.Outputs {
+		info := pluginInfo{output.Config.Name, output.Config.Alias}
+		if !matches(r, info) {
+			continue
+		}
+
+		status := output.Status()
+		resp = append(resp, outputStatus{
+			pluginInfo:        info,
+			BufferSize:        status.BufferSize,
+			BufferLimit:       status.BufferLimit,
+			MetricsWritten:    status.MetricsWritten,
+			MetricsDropped:    status.MetricsDropped,
+			LastWrite:         timePtr(status.LastWrite),
+			LastWriteDuration: status.LastWriteDuration.Nanoseconds(),
+			Errors:            status.Errors,
+			LastError:         status.LastError,
+			LastErrorTime:     timePtr(status.LastErrorTime),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (a *api) serveGather(w http.ResponseWriter, r *http.Request) {
+	resp := triggerResponse{Triggered: []pluginInfo{}}
+	for _, input := range a.agent.Config.Inputs {
+		info := pluginInfo{input.Config.Name, input.Config.Alias}
+		if matches(r, info) {
+			input.RequestGather()
+			resp.Triggered = append(resp.Triggered, info)
+		}
+	}
+	triggered(w, resp)
+}
+
+func (a *api) serveFlush(w http.ResponseWriter, r *http.Request) {
+	resp := triggerResponse{Triggered: []pluginInfo{}}
+	for _, output := range a.agent.Config.Outputs {
+		info := pluginInfo{output.Config.Name, output.Config.Alias}
+		if matches(r, info) {
+			output.RequestFlush()
+			resp.Triggered = append(resp.Triggered, info)
+		}
+	}
+	triggered(w, resp)
+}
+
+func triggered(w http.ResponseWriter, resp triggerResponse) {
+	if len(resp.Triggered) == 0 {
+		http.Error(w, "no matching plugins", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// matches returns true if the plugin matches the optional name and alias
+// query parameters of the request.
+func matches(r *http.Request, info pluginInfo) bool {
+	query := r.URL.Query()
+	if name := query.Get("name"); name != "" && name != info.Name {
+		return false
+	}
+	if alias := query.Get("alias"); alias != "" && alias != info.Alias {
+		return false
+	}
+	return true
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("E! [agent] Error writing API response: %v", err)
+	}
+}
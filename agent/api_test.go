@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type apiTestInput struct{}
+
+func (i *apiTestInput) SampleConfig() string { return "" }
+func (i *apiTestInput) Description() string  { return "" }
+func (i *apiTestInput) Gather(acc telegraf.Accumulator) error {
+	return errors.New("gather failed")
+}
+
+type apiTestOutput struct{}
+
+func (o *apiTestOutput) SampleConfig() string                  { return "" }
+func (o *apiTestOutput) Description() string                   { return "" }
+func (o *apiTestOutput) Connect() error                        { return nil }
+func (o *apiTestOutput) Close() error                          { return nil }
+func (o *apiTestOutput) Write(metrics []telegraf.Metric) error { return nil }
+
+func newTestAPI(t *testing.T, token string) (*api, *models.RunningInput, *models.RunningOutput) {
+	c := config.NewConfig()
+	c.Agent.APIListen = "localhost:0"
+	c.Agent.APIToken = token
+
+	input := models.NewRunningInput(&apiTestInput{}, &models.InputConfig{
+		Name:  "test",
+		Alias: "first",
+	})
+	c.Inputs = append(c.Inputs, input)
+
+	output := models.NewRunningOutput("test", &apiTestOutput{}, &models.OutputConfig{
+		Name: "test",
+	}, 1000, 100)
+	c.Outputs = append(c.Outputs, output)
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	server, err := newAPI(a)
+	require.NoError(t, err)
+	go server.serve()
+	return server, input, output
+}
+
+func request(t *testing.T, server *api, method, path, token string, v interface{}) int {
+	url := fmt.Sprintf("http://%s%s", server.Addr(), path)
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if v != nil && resp.StatusCode < 300 {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+	return resp.StatusCode
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	server, _, _ := newTestAPI(t, "secret")
+	defer server.shutdown()
+
+	require.Equal(t, http.StatusUnauthorized, request(t, server, "GET", "/status", "", nil))
+	require.Equal(t, http.StatusUnauthorized, request(t, server, "GET", "/status", "wrong", nil))
+
+	var status statusResponse
+	require.Equal(t, http.StatusOK, request(t, server, "GET", "/status", "secret", &status))
+	require.Equal(t, 1, status.Inputs)
+	require.Equal(t, 1, status.Outputs)
+}
+
+func TestAPIPlugins(t *testing.T) {
+	server, _, _ := newTestAPI(t, "")
+	defer server.shutdown()
+
+	var plugins pluginsResponse
+	require.Equal(t, http.StatusOK, request(t, server, "GET", "/plugins", "", &plugins))
+	require.Equal(t, []pluginInfo{{Name: "test", Alias: "first"}}, plugins.Inputs)
+	require.Equal(t, []pluginInfo{{Name: "test"}}, plugins.Outputs)
+	require.Empty(t, plugins.Processors)
+	require.Empty(t, plugins.Aggregators)
+}
+
+func TestAPIInputStatus(t *testing.T) {
+	server, input, _ := newTestAPI(t, "")
+	defer server.shutdown()
+
+	var inputs []inputStatus
+	require.Equal(t, http.StatusOK, request(t, server, "GET", "/inputs", "", &inputs))
+	require.Len(t, inputs, 1)
+	require.Nil(t, inputs[0].LastGather)
+	require.Equal(t, "10s", inputs[0].Interval)
+
+	acc := NewAccumulator(input, make(chan telegraf.Metric, 10))
+	acc.AddError(input.Gather(acc))
+
+	inputs = nil
+	require.Equal(t, http.StatusOK, request(t, server, "GET", "/inputs?name=test&alias=first", "", &inputs))
+	require.Len(t, inputs, 1)
+	require.NotNil(t, inputs[0].LastGather)
+	require.Equal(t, int64(1), inputs[0].Errors)
+	require.Equal(t, "gather failed", inputs[0].LastError)
+
+	inputs = nil
+	require.Equal(t, http.StatusOK, request(t, server, "GET", "/inputs?alias=second", "", &inputs))
+	require.Empty(t, inputs)
+}
+
+func TestAPIOutputStatus(t *testing.T) {
+	server, _, output := newTestAPI(t, "")
+	defer server.shutdown()
+
+	output.AddMetric(testutil.TestMetric(42))
+	output.AddMetric(testutil.TestMetric(43))
+
+	var outputs []outputStatus
+	require.Equal(t, http.StatusOK, request(t, server, "GET", "/outputs", "", &outputs))
+	require.Len(t, outputs, 1)
+	require.Equal(t, 2, outputs[0].BufferSize)
+	require.Equal(t, 100, outputs[0].BufferLimit)
+	require.Nil(t, outputs[0].LastWrite)
+
+	require.NoError(t, output.Write())
+
+	outputs = nil
+	require.Equal(t, http.StatusOK, request(t, server, "GET", "/outputs", "", &outputs))
+	require.Equal(t, 0, outputs[0].BufferSize)
+	require.NotNil(t, outputs[0].LastWrite)
+	require.Equal(t, int64(0), outputs[0].Errors)
+}
+
+func TestAPITriggers(t *testing.T) {
+	server, input, output := newTestAPI(t, "")
+	defer server.shutdown()
+
+	require.Equal(t, http.StatusMethodNotAllowed, request(t, server, "GET", "/inputs/gather", "", nil))
+	require.Equal(t, http.StatusNotFound, request(t, server, "POST", "/inputs/gather?name=cpu", "", nil))
+
+	var resp triggerResponse
+	require.Equal(t, http.StatusAccepted, request(t, server, "POST", "/inputs/gather?name=test", "", &resp))
+	require.Equal(t, []pluginInfo{{Name: "test", Alias: "first"}}, resp.Triggered)
+	select {
+	case <-input.GatherRequested:
+	case <-time.After(time.Second):
+		t.Fatal("gather was not requested")
+	}
+
+	require.Equal(t, http.StatusAccepted, request(t, server, "POST", "/outputs/flush", "", &resp))
+	select {
+	case <-output.FlushRequested:
+	case <-time.After(time.Second):
+		t.Fatal("flush was not requested")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cron"
 )
 
 type Ticker struct {
@@ -35,6 +36,22 @@ func NewTicker(
 	return t
 }
 
+// NewScheduleTicker returns a Ticker that ticks at the times selected by the
+// cron schedule.
+func NewScheduleTicker(schedule *cron.Schedule) *Ticker {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &Ticker{
+		C:          make(chan time.Time, 1),
+		cancelFunc: cancel,
+	}
+
+	t.wg.Add(1)
+	go t.relaySchedule(ctx, schedule)
+
+	return t
+}
+
 func (t *Ticker) Stop() {
 	t.cancelFunc()
 	t.wg.Wait()
@@ -55,3 +72,25 @@ func (t *Ticker) relayTime(ctx context.Context) {
 		}
 	}
 }
+
+func (t *Ticker) relaySchedule(ctx context.Context, schedule *cron.Schedule) {
+	defer t.wg.Done()
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case tm := <-timer.C:
+			select {
+			case t.C <- tm:
+			default:
+			}
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
@@ -20,7 +20,9 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cron"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -152,6 +154,10 @@ type AgentConfig struct {
 	// is determined by the "logfile" setting.
 	LogTarget string `toml:"logtarget"`
 
+	// Log format controls the format of log lines written to "stderr" or
+	// "file", and can be one of "text" or "json".
+	LogFormat string `toml:"logformat"`
+
 	// Name of the file to be logged to when using the "file" logtarget.  If set to
 	// the empty string then logs are written to stderr.
 	Logfile string `toml:"logfile"`
@@ -170,6 +176,19 @@ type AgentConfig struct {
 
 	Hostname     string
 	OmitHostname bool
+
+	// APIListen is the address of the HTTP status and control API.  When
+	// empty the API is disabled.
+	APIListen string `toml:"api_listen"`
+
+	// APIToken is the token required in the Authorization header of API
+	// requests.  When empty no authentication is performed.
+	APIToken string `toml:"api_token"`
+
+	// TLS server configuration of the API.
+	APITLSCert           string   `toml:"api_tls_cert"`
+	APITLSKey            string   `toml:"api_tls_key"`
+	APITLSAllowedCACerts []string `toml:"api_tls_allowed_cacerts"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -299,6 +318,10 @@ var agentConfig = `
   ## is determined by the "logfile" setting.
   # logtarget = "file"
 
+  ## Log format controls the format of log lines written to "stderr" or "file",
+  ## and can be one of "text" or "json".
+  # logformat = "text"
+
   ## Name of the file to be logged to when using the "file" logtarget.  If set to
   ## the empty string then logs are written to stderr.
   # logfile = ""
@@ -321,6 +344,20 @@ var agentConfig = `
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Address of the HTTP API reporting the status of the agent and allowing
+  ## gathers and flushes to be triggered.  When empty the API is disabled.
+  # api_listen = "localhost:8087"
+
+  ## Token required in the Authorization header of API requests, for example
+  ## "Authorization: Token <api_token>".
+  # api_token = ""
+
+  ## TLS server certificate and key for the API; when set the API is served
+  ## using HTTPS.  Set api_tls_allowed_cacerts to require client certificates.
+  # api_tls_cert = "/etc/telegraf/cert.pem"
+  # api_tls_key = "/etc/telegraf/key.pem"
+  # api_tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
+
 `
 
 var outputHeader = `
@@ -958,9 +995,15 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
+	var serializerConfig *serializers.Config
 	switch t := output.(type) {
 	case serializers.SerializerOutput:
-		serializer, err := buildSerializer(name, table)
+		var err error
+		serializerConfig, err = getSerializerConfig(name, table)
+		if err != nil {
+			return err
+		}
+		serializer, err := serializers.NewSerializer(serializerConfig)
 		if err != nil {
 			return err
 		}
@@ -971,6 +1014,14 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	if err != nil {
 		return err
 	}
+	if serializerConfig != nil && outputConfig.MaxBatchBytes > 0 {
+		// The batches are sized with a serializer of their own, as the
+		// serializers may keep state between calls.
+		outputConfig.Serializer, err = serializers.NewSerializer(serializerConfig)
+		if err != nil {
+			return err
+		}
+	}
 
 	if err := toml.UnmarshalTable(table, output); err != nil {
 		return err
@@ -1127,6 +1178,18 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		}
 	}
 
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				level, err := logger.ParseLevel(str.Value)
+				if err != nil {
+					return nil, err
+				}
+				conf.LogLevel = level
+			}
+		}
+	}
+
 	conf.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
 		if subtbl, ok := node.(*ast.Table); ok {
@@ -1144,6 +1207,7 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "tags")
 	var err error
 	conf.Filter, err = buildFilter(tbl)
@@ -1179,7 +1243,20 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 		}
 	}
 
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				level, err := logger.ParseLevel(str.Value)
+				if err != nil {
+					return nil, err
+				}
+				conf.LogLevel = level
+			}
+		}
+	}
+
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "order")
 	var err error
 	conf.Filter, err = buildFilter(tbl)
@@ -1344,6 +1421,31 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	location := time.Local
+	if node, ok := tbl.Fields["schedule_timezone"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				var err error
+				location, err = time.LoadLocation(str.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["schedule"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				schedule, err := cron.Parse(str.Value, location)
+				if err != nil {
+					return nil, err
+				}
+				cp.Schedule = schedule
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["name_prefix"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1376,6 +1478,18 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				level, err := logger.ParseLevel(str.Value)
+				if err != nil {
+					return nil, err
+				}
+				cp.LogLevel = level
+			}
+		}
+	}
+
 	cp.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
 		if subtbl, ok := node.(*ast.Table); ok {
@@ -1389,7 +1503,10 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "interval")
+	delete(tbl.Fields, "schedule")
+	delete(tbl.Fields, "schedule_timezone")
 	delete(tbl.Fields, "tags")
 	var err error
 	cp.Filter, err = buildFilter(tbl)
@@ -1842,10 +1959,9 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	return c, nil
 }
 
-// buildSerializer grabs the necessary entries from the ast.Table for creating
-// a serializers.Serializer object, and creates it, which can then be added onto
-// an Output object.
-func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error) {
+// getSerializerConfig grabs the necessary entries from the ast.Table for
+// creating the serializers.Serializer objects of an Output.
+func getSerializerConfig(name string, tbl *ast.Table) (*serializers.Config, error) {
 	c := &serializers.Config{TimestampUnits: time.Duration(1 * time.Second)}
 
 	if node, ok := tbl.Fields["data_format"]; ok {
@@ -1988,6 +2104,54 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["batch_template"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.BatchTemplate = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_header"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.CSVHeader, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_separator"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVSeparator = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_columns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVColumns = append(c.CSVColumns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "influx_max_line_bytes")
 	delete(tbl.Fields, "influx_sort_fields")
 	delete(tbl.Fields, "influx_uint_support")
@@ -2000,7 +2164,12 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 	delete(tbl.Fields, "splunkmetric_multimetric")
 	delete(tbl.Fields, "wavefront_source_override")
 	delete(tbl.Fields, "wavefront_use_strict")
-	return serializers.NewSerializer(c)
+	delete(tbl.Fields, "batch_template")
+	delete(tbl.Fields, "csv_header")
+	delete(tbl.Fields, "csv_separator")
+	delete(tbl.Fields, "csv_timestamp_format")
+	delete(tbl.Fields, "csv_columns")
+	return c, nil
 }
 
 // buildOutput parses output specific items from the ast.Table,
@@ -2076,6 +2245,35 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["max_requests_per_second"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			switch v := kv.Value.(type) {
+			case *ast.Integer:
+				n, err := v.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.MaxRequestsPerSecond = float64(n)
+			case *ast.Float:
+				f, err := v.Float()
+				if err != nil {
+					return nil, err
+				}
+				oc.MaxRequestsPerSecond = f
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["max_batch_bytes"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var size internal.Size
+			if err := size.UnmarshalTOML([]byte(kv.Value.Source())); err != nil {
+				return nil, fmt.Errorf("invalid max_batch_bytes: %v", err)
+			}
+			oc.MaxBatchBytes = size.Size
+		}
+	}
+
 	if node, ok := tbl.Fields["alias"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -2084,11 +2282,26 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				level, err := logger.ParseLevel(str.Value)
+				if err != nil {
+					return nil, err
+				}
+				oc.LogLevel = level
+			}
+		}
+	}
+
 	delete(tbl.Fields, "flush_interval")
 	delete(tbl.Fields, "flush_jitter")
 	delete(tbl.Fields, "metric_buffer_limit")
 	delete(tbl.Fields, "metric_batch_size")
+	delete(tbl.Fields, "max_requests_per_second")
+	delete(tbl.Fields, "max_batch_bytes")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 
 	return oc, nil
 }
@@ -5,15 +5,20 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/exec"
 	"github.com/influxdata/telegraf/plugins/inputs/http_listener_v2"
 	"github.com/influxdata/telegraf/plugins/inputs/memcached"
 	"github.com/influxdata/telegraf/plugins/inputs/procstat"
+	"github.com/influxdata/telegraf/plugins/outputs"
 	httpOut "github.com/influxdata/telegraf/plugins/outputs/http"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -202,6 +207,31 @@ func TestConfig_LoadSpecialTypes(t *testing.T) {
 	assert.Equal(t, "/path/to/my/cert\n", inputHTTPListener.TLSCert)
 }
 
+func TestConfig_Schedule(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/schedule.toml")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(c.Inputs))
+
+	config := c.Inputs[0].Config
+	require.NotNil(t, config.Schedule)
+	require.Equal(t, "5-59/15 * * * *", config.Schedule.String())
+	require.Equal(t, time.UTC, config.Schedule.Location())
+	require.Equal(t, logger.LevelDebug, config.LogLevel)
+}
+
+func TestConfig_OutputLimits(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/output_limits.toml")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(c.Outputs))
+
+	config := c.Outputs[0].Config
+	require.Equal(t, 2.5, config.MaxRequestsPerSecond)
+	require.Equal(t, int64(1024*1024), config.MaxBatchBytes)
+	require.NotNil(t, config.Serializer)
+}
+
 func TestConfig_FieldNotDefined(t *testing.T) {
 	c := NewConfig()
 	err := c.LoadConfig("./testdata/invalid_field.toml")
@@ -256,3 +286,34 @@ func TestConfig_BadOrdering(t *testing.T) {
 	require.Error(t, err, "bad ordering")
 	assert.Equal(t, "Error parsing ./testdata/non_slice_slice.toml, line 4: cannot unmarshal TOML array into string (need slice)", err.Error())
 }
+
+type serializerOutput struct {
+	Serializer serializers.Serializer
+}
+
+func (o *serializerOutput) SetSerializer(s serializers.Serializer) { o.Serializer = s }
+func (o *serializerOutput) Connect() error                         { return nil }
+func (o *serializerOutput) Close() error                           { return nil }
+func (o *serializerOutput) Description() string                    { return "" }
+func (o *serializerOutput) SampleConfig() string                   { return "" }
+func (o *serializerOutput) Write([]telegraf.Metric) error          { return nil }
+
+func TestConfig_OutputBatchSerializer(t *testing.T) {
+	outputs.Add("serializer_test", func() telegraf.Output { return &serializerOutput{} })
+	defer delete(outputs.Outputs, "serializer_test")
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/output_serializer.toml")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(c.Outputs))
+
+	// Sizing the batches does not change the state of the output's
+	// serializer, which still writes the csv header.
+	m := testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+	_, err = c.Outputs[0].Config.Serializer.Serialize(m)
+	require.NoError(t, err)
+
+	out, err := c.Outputs[0].Output.(*serializerOutput).Serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "timestamp,measurement,value\n0,cpu,42\n", string(out))
+}
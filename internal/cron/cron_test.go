@@ -0,0 +1,71 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		spec     string
+		from     string
+		expected string
+	}{
+		{"* * * * *", "2020-01-10T10:00:30Z", "2020-01-10T10:01:00Z"},
+		{"0 2 * * *", "2020-01-10T10:00:00Z", "2020-01-11T02:00:00Z"},
+		{"0 2 * * *", "2020-01-10T01:59:59Z", "2020-01-10T02:00:00Z"},
+		{"5-59/15 * * * *", "2020-01-10T10:06:00Z", "2020-01-10T10:20:00Z"},
+		{"5/15 * * * *", "2020-01-10T10:51:00Z", "2020-01-10T11:05:00Z"},
+		{"0,30 9-17 * * mon-fri", "2020-01-10T17:30:00Z", "2020-01-13T09:00:00Z"},
+		{"0 0 29 feb *", "2020-03-01T00:00:00Z", "2024-02-29T00:00:00Z"},
+		{"0 0 31 * *", "2020-04-01T00:00:00Z", "2020-05-31T00:00:00Z"},
+		{"0 0 1 * 7", "2020-01-02T00:00:00Z", "2020-01-05T00:00:00Z"},
+		{"0 0 13 * 5", "2020-03-07T00:00:00Z", "2020-03-13T00:00:00Z"},
+		{"@hourly", "2020-12-31T23:30:00Z", "2021-01-01T00:00:00Z"},
+		{"@yearly", "2020-06-01T00:00:00Z", "2021-01-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec, time.UTC)
+			require.NoError(t, err)
+
+			from, err := time.Parse(time.RFC3339, tt.from)
+			require.NoError(t, err)
+			expected, err := time.Parse(time.RFC3339, tt.expected)
+			require.NoError(t, err)
+
+			require.Equal(t, expected, s.Next(from))
+		})
+	}
+}
+
+func TestNextLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	s, err := Parse("0 2 * * *", loc)
+	require.NoError(t, err)
+
+	next := s.Next(time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2020, 1, 11, 7, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+	} {
+		_, err := Parse(spec, time.UTC)
+		require.Error(t, err, spec)
+	}
+}
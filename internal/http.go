@@ -45,6 +45,37 @@ func (h *basicAuthHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request)
 	h.next.ServeHTTP(rw, req)
 }
 
+// TokenAuthHandler returns a http handler that requires the Authorization
+// header to contain the given token, as in "Token <token>".
+func TokenAuthHandler(token string, onError ErrorFunc) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return &tokenAuthHandler{
+			token:   token,
+			onError: onError,
+			next:    h,
+		}
+	}
+}
+
+type tokenAuthHandler struct {
+	token   string
+	onError ErrorFunc
+	next    http.Handler
+}
+
+func (h *tokenAuthHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if h.token != "" {
+		expected := []byte("Token " + h.token)
+		actual := []byte(req.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(expected, actual) != 1 {
+			h.onError(rw, http.StatusUnauthorized)
+			return
+		}
+	}
+
+	h.next.ServeHTTP(rw, req)
+}
+
 // IPRangeHandler returns a http handler that requires the remote address to be
 // in the specified network.
 func IPRangeHandler(network []*net.IPNet, onError ErrorFunc) func(h http.Handler) http.Handler {
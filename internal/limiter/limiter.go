@@ -1,6 +1,7 @@
 package limiter
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -57,3 +58,70 @@ func (r *rateLimiter) limiter() {
 		}
 	}
 }
+
+// TokenBucket limits the rate of an operation to rate per second, allowing
+// bursts of up to burst operations.  Operations exceeding the rate are
+// delayed rather than dropped.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	now func() time.Time
+}
+
+// NewTokenBucket returns a full TokenBucket refilling at rate tokens per
+// second.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Reserve takes a token from the bucket and returns how long the caller must
+// wait before performing the operation.
+func (b *TokenBucket) Reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Wait blocks until the operation is allowed and returns the time waited.  It
+// returns the context's error if the context is done first.
+func (b *TokenBucket) Wait(ctx context.Context) (time.Duration, error) {
+	delay := b.Reserve()
+	if delay <= 0 {
+		return 0, nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return delay, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
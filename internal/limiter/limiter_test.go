@@ -0,0 +1,46 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewTokenBucket(2, 2)
+	b.now = func() time.Time { return now }
+
+	// The burst is allowed without delay.
+	require.Equal(t, time.Duration(0), b.Reserve())
+	require.Equal(t, time.Duration(0), b.Reserve())
+
+	// Further operations are delayed according to the rate.
+	require.Equal(t, 500*time.Millisecond, b.Reserve())
+	require.Equal(t, time.Second, b.Reserve())
+
+	// The bucket refills over time.
+	now = now.Add(2 * time.Second)
+	require.Equal(t, time.Duration(0), b.Reserve())
+
+	now = now.Add(time.Hour)
+	require.Equal(t, time.Duration(0), b.Reserve())
+	require.Equal(t, time.Duration(0), b.Reserve())
+	require.Equal(t, 500*time.Millisecond, b.Reserve())
+}
+
+func TestTokenBucketWaitCanceled(t *testing.T) {
+	b := NewTokenBucket(0.001, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	delay, err := b.Wait(ctx)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), delay)
+
+	// The next operation is allowed in 1000s, the wait stops when canceled.
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err = b.Wait(ctx)
+	require.Equal(t, context.Canceled, err)
+}
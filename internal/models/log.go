@@ -1,10 +1,11 @@
 package models
 
 import (
-	"log"
+	"fmt"
 	"reflect"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -12,48 +13,83 @@ import (
 type Logger struct {
 	Errs selfstat.Stat
 	Name string // Name is the plugin name, will be printed in the `[]`.
+
+	level logger.Level
+}
+
+// SetLevel overrides the agent log level for messages logged by the plugin,
+// the zero Level uses the agent log level.
+func (l *Logger) SetLevel(level logger.Level) {
+	l.level = level
+}
+
+// enabled returns true if a message at level is written with the log level
+// of the plugin.
+func (l *Logger) enabled(level logger.Level) bool {
+	if l.level == 0 {
+		return logger.Enabled(level)
+	}
+	return level >= l.level
 }
 
 // Errorf logs an error message, patterned after log.Printf.
 func (l *Logger) Errorf(format string, args ...interface{}) {
 	l.Errs.Incr(1)
-	log.Printf("E! ["+l.Name+"] "+format, args...)
+	logger.Print(fmt.Sprintf("E! ["+l.Name+"] "+format, args...))
 }
 
 // Error logs an error message, patterned after log.Print.
 func (l *Logger) Error(args ...interface{}) {
 	l.Errs.Incr(1)
-	log.Print(append([]interface{}{"E! [" + l.Name + "] "}, args...)...)
+	logger.Print(fmt.Sprint(append([]interface{}{"E! [" + l.Name + "] "}, args...)...))
 }
 
 // Debugf logs a debug message, patterned after log.Printf.
 func (l *Logger) Debugf(format string, args ...interface{}) {
-	log.Printf("D! ["+l.Name+"] "+format, args...)
+	if !l.enabled(logger.LevelDebug) {
+		return
+	}
+	logger.Print(fmt.Sprintf("D! ["+l.Name+"] "+format, args...))
 }
 
 // Debug logs a debug message, patterned after log.Print.
 func (l *Logger) Debug(args ...interface{}) {
-	log.Print(append([]interface{}{"D! [" + l.Name + "] "}, args...)...)
+	if !l.enabled(logger.LevelDebug) {
+		return
+	}
+	logger.Print(fmt.Sprint(append([]interface{}{"D! [" + l.Name + "] "}, args...)...))
 }
 
 // Warnf logs a warning message, patterned after log.Printf.
 func (l *Logger) Warnf(format string, args ...interface{}) {
-	log.Printf("W! ["+l.Name+"] "+format, args...)
+	if !l.enabled(logger.LevelWarn) {
+		return
+	}
+	logger.Print(fmt.Sprintf("W! ["+l.Name+"] "+format, args...))
 }
 
 // Warn logs a warning message, patterned after log.Print.
 func (l *Logger) Warn(args ...interface{}) {
-	log.Print(append([]interface{}{"W! [" + l.Name + "] "}, args...)...)
+	if !l.enabled(logger.LevelWarn) {
+		return
+	}
+	logger.Print(fmt.Sprint(append([]interface{}{"W! [" + l.Name + "] "}, args...)...))
 }
 
 // Infof logs an information message, patterned after log.Printf.
 func (l *Logger) Infof(format string, args ...interface{}) {
-	log.Printf("I! ["+l.Name+"] "+format, args...)
+	if !l.enabled(logger.LevelInfo) {
+		return
+	}
+	logger.Print(fmt.Sprintf("I! ["+l.Name+"] "+format, args...))
 }
 
 // Info logs an information message, patterned after log.Print.
 func (l *Logger) Info(args ...interface{}) {
-	log.Print(append([]interface{}{"I! [" + l.Name + "] "}, args...)...)
+	if !l.enabled(logger.LevelInfo) {
+		return
+	}
+	logger.Print(fmt.Sprint(append([]interface{}{"I! [" + l.Name + "] "}, args...)...))
 }
 
 // logName returns the log-friendly name/type.
@@ -3,6 +3,7 @@ package models
 import (
 	"testing"
 
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/selfstat"
 	"github.com/stretchr/testify/require"
 )
@@ -68,3 +69,19 @@ func TestLogging(t *testing.T) {
 	log.Error("something happened")
 	require.Equal(t, int64(2), log.Errs.Get())
 }
+
+func TestLoggerLevel(t *testing.T) {
+	quiet := Logger{Name: "inputs.level"}
+	quiet.SetLevel(logger.LevelWarn)
+	verbose := Logger{Name: "inputs.level"}
+	verbose.SetLevel(logger.LevelDebug)
+
+	// The instances of a plugin share the name but not the level.
+	require.False(t, quiet.enabled(logger.LevelInfo))
+	require.True(t, quiet.enabled(logger.LevelWarn))
+	require.True(t, verbose.enabled(logger.LevelDebug))
+
+	verbose.SetLevel(0)
+	require.False(t, quiet.enabled(logger.LevelInfo))
+	require.Equal(t, logger.Enabled(logger.LevelDebug), verbose.enabled(logger.LevelDebug))
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 )
@@ -33,6 +34,7 @@ func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConf
 		Name: logName("aggregators", config.Name, config.Alias),
 		Errs: selfstat.Register("aggregate", "errors", tags),
 	}
+	logger.SetLevel(config.LogLevel)
 
 	setLogIfExist(aggregator, logger)
 
@@ -67,6 +69,7 @@ func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConf
 type AggregatorConfig struct {
 	Name         string
 	Alias        string
+	LogLevel     logger.Level
 	DropOriginal bool
 	Period       time.Duration
 	Delay        time.Duration
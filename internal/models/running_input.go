@@ -1,9 +1,12 @@
 package models
 
 import (
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/cron"
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -18,6 +21,21 @@ type RunningInput struct {
 
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
+
+	// GatherRequested receives a value when an immediate gather is requested.
+	GatherRequested chan time.Time
+
+	statusMu sync.Mutex
+	status   InputStatus
+}
+
+// InputStatus is a snapshot of the recent activity of an input.
+type InputStatus struct {
+	LastGather         time.Time
+	LastGatherDuration time.Duration
+	LastError          string
+	LastErrorTime      time.Time
+	Errors             int64
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
@@ -30,11 +48,13 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 		Name: logName("inputs", config.Name, config.Alias),
 		Errs: selfstat.Register("gather", "errors", tags),
 	}
+	logger.SetLevel(config.LogLevel)
 	setLogIfExist(input, logger)
 
 	return &RunningInput{
-		Input:  input,
-		Config: config,
+		Input:           input,
+		Config:          config,
+		GatherRequested: make(chan time.Time, 1),
 		MetricsGathered: selfstat.Register(
 			"gather",
 			"metrics_gathered",
@@ -53,7 +73,9 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 type InputConfig struct {
 	Name     string
 	Alias    string
+	LogLevel logger.Level
 	Interval time.Duration
+	Schedule *cron.Schedule
 
 	NameOverride      string
 	MeasurementPrefix string
@@ -110,9 +132,38 @@ func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
 	err := r.Input.Gather(acc)
 	elapsed := time.Since(start)
 	r.GatherTime.Incr(elapsed.Nanoseconds())
+
+	r.statusMu.Lock()
+	r.status.LastGather = start
+	r.status.LastGatherDuration = elapsed
+	r.statusMu.Unlock()
 	return err
 }
 
+// RecordError stores the error as the most recent error of the input.
+func (r *RunningInput) RecordError(err error) {
+	r.statusMu.Lock()
+	r.status.LastError = err.Error()
+	r.status.LastErrorTime = time.Now()
+	r.status.Errors++
+	r.statusMu.Unlock()
+}
+
+// Status returns the recent activity of the input.
+func (r *RunningInput) Status() InputStatus {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	return r.status
+}
+
+// RequestGather asks for the input to be gathered as soon as possible.
+func (r *RunningInput) RequestGather() {
+	select {
+	case r.GatherRequested <- time.Now():
+	default:
+	}
+}
+
 func (r *RunningInput) SetDefaultTags(tags map[string]string) {
 	r.defaultTags = tags
 }
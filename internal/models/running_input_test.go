@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -256,6 +257,24 @@ func TestMakeMetricNameSuffix(t *testing.T) {
 	require.Equal(t, expected, m)
 }
 
+func TestRunningInputStatus(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name: "TestRunningInput",
+	})
+
+	status := ri.Status()
+	require.True(t, status.LastGather.IsZero())
+	require.Equal(t, int64(0), status.Errors)
+
+	require.NoError(t, ri.Gather(&testutil.Accumulator{}))
+	ri.RecordError(errors.New("failed"))
+
+	status = ri.Status()
+	require.False(t, status.LastGather.IsZero())
+	require.Equal(t, int64(1), status.Errors)
+	require.Equal(t, "failed", status.LastError)
+}
+
 type testInput struct{}
 
 func (t *testInput) Description() string                   { return "" }
@@ -1,11 +1,18 @@
 package models
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/limiter"
+	"github.com/influxdata/telegraf/logger"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -19,14 +26,23 @@ const (
 
 // OutputConfig containing name and filter
 type OutputConfig struct {
-	Name   string
-	Alias  string
-	Filter Filter
+	Name     string
+	Alias    string
+	LogLevel logger.Level
+	Filter   Filter
 
 	FlushInterval     time.Duration
 	FlushJitter       *time.Duration
 	MetricBufferLimit int
 	MetricBatchSize   int
+
+	// MaxRequestsPerSecond limits the rate of writes, writes exceeding it
+	// are delayed.
+	MaxRequestsPerSecond float64
+	// MaxBatchBytes splits the batches so that each write is at most this
+	// size once serialized by Serializer.
+	MaxBatchBytes int64
+	Serializer    serializers.Serializer
 }
 
 // RunningOutput contains the output configuration
@@ -40,15 +56,46 @@ type RunningOutput struct {
 	MetricBufferLimit int
 	MetricBatchSize   int
 
-	MetricsFiltered selfstat.Stat
-	WriteTime       selfstat.Stat
+	MetricsFiltered   selfstat.Stat
+	WriteTime         selfstat.Stat
+	WritesThrottled   selfstat.Stat
+	ThrottleTime      selfstat.Stat
+	BatchesSplit      selfstat.Stat
+	MetricsOversized  selfstat.Stat
+	requestsPerSecond *limiter.TokenBucket
+
+	// ctx is canceled on Close, ending the writes waiting for the rate
+	// limit.
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	BatchReady chan time.Time
 
+	// FlushRequested receives a value when an immediate flush is requested.
+	FlushRequested chan time.Time
+
 	buffer *Buffer
 	log    telegraf.Logger
 
 	aggMutex sync.Mutex
+
+	statusMu sync.Mutex
+	status   OutputStatus
+}
+
+// OutputStatus is a snapshot of the recent activity and buffer usage of an
+// output.
+type OutputStatus struct {
+	LastWrite         time.Time
+	LastWriteDuration time.Duration
+	LastError         string
+	LastErrorTime     time.Time
+	Errors            int64
+
+	BufferSize     int
+	BufferLimit    int
+	MetricsWritten int64
+	MetricsDropped int64
 }
 
 func NewRunningOutput(
@@ -67,6 +114,7 @@ func NewRunningOutput(
 		Name: logName("outputs", config.Name, config.Alias),
 		Errs: selfstat.Register("write", "errors", tags),
 	}
+	logger.SetLevel(config.LogLevel)
 	setLogIfExist(output, logger)
 
 	if config.MetricBufferLimit > 0 {
@@ -85,6 +133,7 @@ func NewRunningOutput(
 	ro := &RunningOutput{
 		buffer:            NewBuffer(config.Name, config.Alias, bufferLimit),
 		BatchReady:        make(chan time.Time, 1),
+		FlushRequested:    make(chan time.Time, 1),
 		Output:            output,
 		Config:            config,
 		MetricBufferLimit: bufferLimit,
@@ -99,9 +148,40 @@ func NewRunningOutput(
 			"write_time_ns",
 			tags,
 		),
+		WritesThrottled: selfstat.Register(
+			"write",
+			"writes_throttled",
+			tags,
+		),
+		ThrottleTime: selfstat.Register(
+			"write",
+			"throttle_time_ns",
+			tags,
+		),
+		BatchesSplit: selfstat.Register(
+			"write",
+			"batches_split",
+			tags,
+		),
+		MetricsOversized: selfstat.Register(
+			"write",
+			"metrics_oversized",
+			tags,
+		),
 		log: logger,
 	}
 
+	ro.ctx, ro.cancel = context.WithCancel(context.Background())
+
+	if config.MaxRequestsPerSecond > 0 {
+		burst := int(math.Ceil(config.MaxRequestsPerSecond))
+		ro.requestsPerSecond = limiter.NewTokenBucket(config.MaxRequestsPerSecond, burst)
+	}
+
+	if config.MaxBatchBytes > 0 && config.Serializer == nil {
+		config.Serializer = influx.NewSerializer()
+	}
+
 	return ro
 }
 
@@ -183,12 +263,10 @@ func (ro *RunningOutput) Write() error {
 			break
 		}
 
-		err := ro.write(batch)
+		err := ro.writeBatch(batch)
 		if err != nil {
-			ro.buffer.Reject(batch)
 			return err
 		}
-		ro.buffer.Accept(batch)
 	}
 	return nil
 }
@@ -200,17 +278,61 @@ func (ro *RunningOutput) WriteBatch() error {
 		return nil
 	}
 
-	err := ro.write(batch)
-	if err != nil {
-		ro.buffer.Reject(batch)
-		return err
+	return ro.writeBatch(batch)
+}
+
+// writeBatch writes a batch acquired from the buffer, split into several
+// writes if it exceeds the maximum batch size in bytes.  The metrics that
+// could not be written are returned to the buffer.
+func (ro *RunningOutput) writeBatch(batch []telegraf.Metric) error {
+	var written int
+	for written < len(batch) {
+		n := ro.splitBatch(batch[written:])
+		if written == 0 && n < len(batch) {
+			ro.BatchesSplit.Incr(1)
+		}
+
+		err := ro.write(batch[written : written+n])
+		if err != nil {
+			ro.buffer.Reject(batch[written:])
+			ro.buffer.Accept(batch[:written])
+			return err
+		}
+		written += n
 	}
 	ro.buffer.Accept(batch)
-
 	return nil
 }
 
+// splitBatch returns the number of metrics at the start of the batch that
+// fit into the maximum batch size in bytes, a metric larger than the maximum
+// is written on its own.
+func (ro *RunningOutput) splitBatch(batch []telegraf.Metric) int {
+	if ro.Config.MaxBatchBytes <= 0 {
+		return len(batch)
+	}
+
+	var size int64
+	for i, m := range batch {
+		b, err := ro.Config.Serializer.Serialize(m)
+		if err != nil {
+			continue
+		}
+		size += int64(len(b))
+		if size > ro.Config.MaxBatchBytes {
+			if i == 0 {
+				ro.MetricsOversized.Incr(1)
+				ro.log.Warnf("Metric of %d bytes exceeds max_batch_bytes", len(b))
+				return 1
+			}
+			return i
+		}
+	}
+	return len(batch)
+}
+
 func (r *RunningOutput) Close() {
+	r.cancel()
 	err := r.Output.Close()
 	if err != nil {
 		r.log.Errorf("Error closing output: %v", err)
@@ -224,17 +346,59 @@ func (r *RunningOutput) write(metrics []telegraf.Metric) error {
 		atomic.StoreInt64(&r.droppedMetrics, 0)
 	}
 
+	if r.requestsPerSecond != nil {
+		delay, err := r.requestsPerSecond.Wait(r.ctx)
+		if err != nil {
+			return fmt.Errorf("write canceled: %v", err)
+		}
+		if delay > 0 {
+			r.WritesThrottled.Incr(1)
+			r.ThrottleTime.Incr(delay.Nanoseconds())
+		}
+	}
+
 	start := time.Now()
 	err := r.Output.Write(metrics)
 	elapsed := time.Since(start)
 	r.WriteTime.Incr(elapsed.Nanoseconds())
 
+	r.statusMu.Lock()
+	r.status.LastWrite = start
+	r.status.LastWriteDuration = elapsed
+	if err != nil {
+		r.status.LastError = err.Error()
+		r.status.LastErrorTime = start
+		r.status.Errors++
+	}
+	r.statusMu.Unlock()
+
 	if err == nil {
 		r.log.Debugf("Wrote batch of %d metrics in %s", len(metrics), elapsed)
 	}
 	return err
 }
 
+// Status returns the recent activity and buffer usage of the output.
+func (r *RunningOutput) Status() OutputStatus {
+	r.statusMu.Lock()
+	status := r.status
+	r.statusMu.Unlock()
+
+	status.BufferSize = r.buffer.Len()
+	status.BufferLimit = r.MetricBufferLimit
+	status.MetricsWritten = r.buffer.MetricsWritten.Get()
+	status.MetricsDropped = r.buffer.MetricsDropped.Get()
+	return status
+}
+
+// RequestFlush asks for the output to be flushed as soon as possible.
+func (r *RunningOutput) RequestFlush() {
+	select {
+	case r.FlushRequested <- time.Now():
+	default:
+	}
+}
+
 func (r *RunningOutput) LogBufferStatus() {
 	nBuffer := r.buffer.Len()
 	r.log.Debugf("Buffer fullness: %d / %d metrics", nBuffer, r.MetricBufferLimit)
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -412,10 +414,136 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+func TestRunningOutputStatus(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 100)
+
+	ro.AddMetric(testutil.TestMetric(101, "metric1"))
+	status := ro.Status()
+	require.Equal(t, 1, status.BufferSize)
+	require.Equal(t, 100, status.BufferLimit)
+	require.True(t, status.LastWrite.IsZero())
+
+	m.failWrite = true
+	require.Error(t, ro.Write())
+	status = ro.Status()
+	require.Equal(t, 1, status.BufferSize)
+	require.Equal(t, int64(1), status.Errors)
+	require.Equal(t, "Failed Write!", status.LastError)
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	status = ro.Status()
+	require.Equal(t, 0, status.BufferSize)
+	require.False(t, status.LastWrite.IsZero())
+	require.Equal(t, int64(1), status.Errors)
+}
+
+func TestRunningOutputMaxBatchBytes(t *testing.T) {
+	serializer := influx.NewSerializer()
+	octets, err := serializer.Serialize(first5[0])
+	require.NoError(t, err)
+
+	conf := &OutputConfig{
+		Filter:        Filter{},
+		MaxBatchBytes: int64(2 * len(octets)),
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	require.Equal(t, reverse(first5), m.Metrics())
+	require.Equal(t, []int{2, 2, 1}, m.writes)
+	require.Equal(t, int64(1), ro.BatchesSplit.Get())
+
+	// A metric larger than the maximum is written on its own.
+	conf.MaxBatchBytes = 1
+	for _, metric := range next5[:2] {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	require.Equal(t, []int{2, 2, 1, 1, 1}, m.writes)
+	require.Equal(t, int64(2), ro.MetricsOversized.Get())
+}
+
+func TestRunningOutputMaxBatchBytesWriteFail(t *testing.T) {
+	serializer := influx.NewSerializer()
+	octets, err := serializer.Serialize(first5[0])
+	require.NoError(t, err)
+
+	conf := &OutputConfig{
+		Filter:        Filter{},
+		MaxBatchBytes: int64(2 * len(octets)),
+	}
+
+	m := &mockOutput{failAfter: 1}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	require.Equal(t, 3, ro.buffer.Len())
+
+	// Only the metrics that were not written are sent again.
+	m.failAfter = 0
+	require.NoError(t, ro.Write())
+	require.Equal(t, reverse(first5), m.Metrics())
+}
+
+func TestRunningOutputMaxRequestsPerSecond(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:               Filter{},
+		MaxRequestsPerSecond: 100,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	require.Equal(t, reverse(first5), m.Metrics())
+	require.NotNil(t, ro.requestsPerSecond)
+}
+
+func TestRunningOutputMaxRequestsPerSecondClose(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:               Filter{},
+		MaxRequestsPerSecond: 0.001,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// The second write waits for the rate limit until the output is closed.
+	time.AfterFunc(10*time.Millisecond, ro.Close)
+	require.Error(t, ro.Write())
+	require.Len(t, m.Metrics(), 1)
+	require.Equal(t, 4, ro.buffer.Len())
+}
+
 type mockOutput struct {
 	sync.Mutex
 
 	metrics []telegraf.Metric
+	writes  []int
+
+	// if greater than zero, mock a write failure after this many writes
+	failAfter int
 
 	// if true, mock a write failure
 	failWrite bool
@@ -440,9 +568,10 @@ func (m *mockOutput) SampleConfig() string {
 func (m *mockOutput) Write(metrics []telegraf.Metric) error {
 	m.Lock()
 	defer m.Unlock()
-	if m.failWrite {
+	if m.failWrite || (m.failAfter > 0 && len(m.writes) >= m.failAfter) {
 		return fmt.Errorf("Failed Write!")
 	}
+	m.writes = append(m.writes, len(metrics))
 
 	if m.metrics == nil {
 		m.metrics = []telegraf.Metric{}
@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -22,10 +23,11 @@ func (rp RunningProcessors) Less(i, j int) bool { return rp[i].Config.Order < rp
 
 // FilterConfig containing a name and filter
 type ProcessorConfig struct {
-	Name   string
-	Alias  string
-	Order  int64
-	Filter Filter
+	Name     string
+	Alias    string
+	LogLevel logger.Level
+	Order    int64
+	Filter   Filter
 }
 
 func NewRunningProcessor(processor telegraf.Processor, config *ProcessorConfig) *RunningProcessor {
@@ -38,6 +40,7 @@ func NewRunningProcessor(processor telegraf.Processor, config *ProcessorConfig)
 		Name: logName("processors", config.Name, config.Alias),
 		Errs: selfstat.Register("process", "errors", tags),
 	}
+	logger.SetLevel(config.LogLevel)
 	setLogIfExist(processor, logger)
 
 	return &RunningProcessor{
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a log message.
+type Level int
+
+// The zero Level is unset and defers to the agent log level.
+const (
+	LevelDebug Level = iota + 1
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var (
+	levelMu     sync.RWMutex
+	globalLevel = LevelInfo
+)
+
+// ParseLevel parses one of "debug", "info", "warn" or "error".
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q", s)
+}
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return ""
+}
+
+// prefixLevel returns the level of a "D!", "I!", "W!" or "E!" prefix.
+func prefixLevel(c byte) Level {
+	switch c {
+	case 'D':
+		return LevelDebug
+	case 'W':
+		return LevelWarn
+	case 'E':
+		return LevelError
+	}
+	return LevelInfo
+}
+
+func setGlobalLevel(level Level) {
+	levelMu.Lock()
+	globalLevel = level
+	levelMu.Unlock()
+}
+
+// Enabled returns true if a message at level will be written with the agent
+// log level.
+func Enabled(level Level) bool {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	return level >= globalLevel
+}
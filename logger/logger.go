@@ -1,11 +1,15 @@
 package logger
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"io"
 	"log"
 	"os"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
@@ -15,11 +19,20 @@ import (
 
 var prefixRegex = regexp.MustCompile("^[DIWE]!")
 
+// lineRegex matches the level and optional plugin name of a log line, such as
+// "E! [inputs.cpu] ".
+var lineRegex = regexp.MustCompile(`^([DIWE])! (?:\[([^\]]*)\] )?`)
+
 const (
 	LogTargetFile   = "file"
 	LogTargetStderr = "stderr"
 )
 
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
 // LogConfig contains the log configuration settings
 type LogConfig struct {
 	// will set the log level to DEBUG
@@ -28,6 +41,8 @@ type LogConfig struct {
 	Quiet bool
 	//stderr, stdout, file or eventlog (Windows only)
 	LogTarget string
+	// text or json, only used by the stderr and file targets
+	LogFormat string
 	// will direct the logging output to a file. Empty string is
 	// interpreted as stderr. If there is an error opening the file the
 	// logger will fallback to stderr
@@ -54,11 +69,53 @@ func registerLogger(name string, loggerCreator LoggerCreator) {
 }
 
 type telegrafLog struct {
+	mu             sync.Mutex
 	writer         io.Writer
 	internalWriter io.Writer
+	format         string
+}
+
+// jsonRecord is a log line written with the json log format.
+type jsonRecord struct {
+	Time       string `json:"time"`
+	Level      string `json:"level"`
+	PluginType string `json:"plugin_type,omitempty"`
+	Plugin     string `json:"plugin,omitempty"`
+	Alias      string `json:"alias,omitempty"`
+	Message    string `json:"msg"`
+	Error      string `json:"error,omitempty"`
 }
 
 func (t *telegrafLog) Write(b []byte) (n int, err error) {
+	level := LevelInfo
+	if loc := lineRegex.FindSubmatchIndex(b); loc != nil {
+		level = prefixLevel(b[loc[2]])
+	}
+	if !Enabled(level) {
+		return len(b), nil
+	}
+	return t.write(b)
+}
+
+// write writes a log line without checking its level.
+func (t *telegrafLog) write(b []byte) (n int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.format == LogFormatJSON {
+		level := LevelInfo
+		var name string
+		msg := b
+		if loc := lineRegex.FindSubmatchIndex(b); loc != nil {
+			level = prefixLevel(b[loc[2]])
+			if loc[4] >= 0 {
+				name = string(b[loc[4]:loc[5]])
+			}
+			msg = b[loc[1]:]
+		}
+		return len(b), t.writeJSON(level, name, string(bytes.TrimRight(msg, "\r\n")))
+	}
+
 	var line []byte
 	if !prefixRegex.Match(b) {
 		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" I! "), b...)
@@ -68,6 +125,41 @@ func (t *telegrafLog) Write(b []byte) (n int, err error) {
 	return t.writer.Write(line)
 }
 
+// writeJSON writes the message as a json object.  The name of plugins, such as
+// "inputs.cpu::alias", is split into its type, name and alias.  Error
+// messages following the "message: error" convention are split into the
+// message and error.
+func (t *telegrafLog) writeJSON(level Level, name string, msg string) error {
+	record := jsonRecord{
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		Level:   level.String(),
+		Plugin:  name,
+		Message: msg,
+	}
+
+	if i := strings.Index(record.Plugin, "::"); i >= 0 {
+		record.Alias = record.Plugin[i+2:]
+		record.Plugin = record.Plugin[:i]
+	}
+	if i := strings.Index(record.Plugin, "."); i >= 0 {
+		record.PluginType = record.Plugin[:i]
+		record.Plugin = record.Plugin[i+1:]
+	}
+	if level == LevelError {
+		if i := strings.Index(msg, ": "); i >= 0 {
+			record.Message = msg[:i]
+			record.Error = msg[i+2:]
+		}
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = t.writer.Write(append(line, '\n'))
+	return err
+}
+
 func (t *telegrafLog) Close() error {
 	var stdErrWriter io.Writer
 	stdErrWriter = os.Stderr
@@ -83,10 +175,11 @@ func (t *telegrafLog) Close() error {
 }
 
 // newTelegrafWriter returns a logging-wrapped writer.
-func newTelegrafWriter(w io.Writer) io.Writer {
+func newTelegrafWriter(w io.Writer, format string) io.Writer {
 	return &telegrafLog{
-		writer:         wlog.NewWriter(w),
+		writer:         w,
 		internalWriter: w,
+		format:         format,
 	}
 }
 
@@ -120,7 +213,17 @@ func (t *telegrafLogCreator) CreateLogger(config LogConfig) (io.Writer, error) {
 		writer = defaultWriter
 	}
 
-	return newTelegrafWriter(writer), nil
+	format := config.LogFormat
+	switch format {
+	case LogFormatText, LogFormatJSON:
+	case "":
+		format = LogFormatText
+	default:
+		log.Printf("E! Unsupported logformat: %s, using text", config.LogFormat)
+		format = LogFormatText
+	}
+
+	return newTelegrafWriter(writer, format), nil
 }
 
 // Keep track what is actually set as a log output, because log package doesn't provide a getter.
@@ -131,12 +234,15 @@ func newLogWriter(config LogConfig) io.Writer {
 	log.SetFlags(0)
 	if config.Debug {
 		wlog.SetLevel(wlog.DEBUG)
+		setGlobalLevel(LevelDebug)
 	}
 	if config.Quiet {
 		wlog.SetLevel(wlog.ERROR)
+		setGlobalLevel(LevelError)
 	}
 	if !config.Debug && !config.Quiet {
 		wlog.SetLevel(wlog.INFO)
+		setGlobalLevel(LevelInfo)
 	}
 	var logWriter io.Writer
 	if logCreator, ok := loggerRegistry[config.LogTarget]; ok {
@@ -155,6 +261,20 @@ func newLogWriter(config LogConfig) io.Writer {
 	return logWriter
 }
 
+// Print writes a log line, such as "D! [inputs.cpu] message", whatever the
+// agent log level.  The plugin loggers use it to write the messages enabled
+// by their own log level.
+func Print(line string) {
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	if t, ok := actualLogger.(*telegrafLog); ok {
+		t.write([]byte(line))
+		return
+	}
+	log.Print(line)
+}
+
 func init() {
 	tlc := &telegrafLogCreator{}
 	registerLogger("", tlc)
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"log"
@@ -134,10 +135,80 @@ func TestLogTargetSettings(t *testing.T) {
 	assert.Equal(t, logger.internalWriter, os.Stderr)
 }
 
+func TestWriteJSONLogToFile(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	config := createBasicLogConfig(tmpfile.Name())
+	config.LogFormat = LogFormatJSON
+	SetupLogging(config)
+	log.Printf("E! [inputs.cpu::total] Error in plugin: could not read /proc/stat")
+	log.Printf("D! [agent] TEST") // <- should be ignored
+	log.Printf("TEST")
+
+	f, err := ioutil.ReadFile(tmpfile.Name())
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(f), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var record jsonRecord
+	require.NoError(t, json.Unmarshal(lines[0], &record))
+	require.NotEmpty(t, record.Time)
+	record.Time = ""
+	require.Equal(t, jsonRecord{
+		Level:      "error",
+		PluginType: "inputs",
+		Plugin:     "cpu",
+		Alias:      "total",
+		Message:    "Error in plugin",
+		Error:      "could not read /proc/stat",
+	}, record)
+
+	record = jsonRecord{}
+	require.NoError(t, json.Unmarshal(lines[1], &record))
+	record.Time = ""
+	require.Equal(t, jsonRecord{Level: "info", Message: "TEST"}, record)
+}
+
+func TestLogLevel(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	config := createBasicLogConfig(tmpfile.Name())
+	config.Quiet = true
+	SetupLogging(config)
+
+	require.True(t, Enabled(LevelError))
+	require.False(t, Enabled(LevelWarn))
+
+	log.Printf("W! [inputs.quiet] TEST") // <- should be ignored
+	log.Printf("E! [inputs.quiet] TEST")
+	// The plugin loggers check their own level before printing.
+	Print("D! [inputs.debug] TEST")
+
+	f, err := ioutil.ReadFile(tmpfile.Name())
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(f), []byte("\n"))
+	require.Len(t, lines, 2)
+	require.Equal(t, []byte("Z E! [inputs.quiet] TEST"), lines[0][19:])
+	require.Equal(t, []byte("Z D! [inputs.debug] TEST"), lines[1][19:])
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("DEBUG")
+	require.NoError(t, err)
+	require.Equal(t, LevelDebug, level)
+
+	_, err = ParseLevel("verbose")
+	require.Error(t, err)
+}
+
 func BenchmarkTelegrafLogWrite(b *testing.B) {
 	var msg = []byte("test")
 	var buf bytes.Buffer
-	w := newTelegrafWriter(&buf)
+	w := newTelegrafWriter(&buf, LogFormatText)
 	for i := 0; i < b.N; i++ {
 		buf.Reset()
 		w.Write(msg)
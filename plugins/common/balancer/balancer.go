@@ -0,0 +1,315 @@
+package balancer
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	// StrategyRandom writes each batch to a random endpoint.
+	StrategyRandom = "random"
+	// StrategyFailover writes each batch to the first healthy endpoint.
+	StrategyFailover = "failover"
+	// StrategyRoundRobin writes each batch to the next healthy endpoint.
+	StrategyRoundRobin = "round_robin"
+	// StrategyConsistentHash splits each batch between the endpoints by
+	// hashing the series of the metrics.
+	StrategyConsistentHash = "consistent_hash"
+)
+
+const (
+	defaultRetryInterval = 30 * time.Second
+
+	// replicas is the number of points on the hash ring for each endpoint.
+	replicas = 128
+)
+
+// ErrNoEndpoint is returned when the metrics could not be written to any of
+// the endpoints.
+var ErrNoEndpoint = errors.New("could not write to any endpoint")
+
+// Config selects how an output spreads its writes across several endpoints,
+// it is meant to be embedded into the configuration of the output.
+type Config struct {
+	BalanceStrategy      string            `toml:"balance_strategy"`
+	BalanceHashTags      []string          `toml:"balance_hash_tags"`
+	BalanceRetryInterval internal.Duration `toml:"balance_retry_interval"`
+}
+
+// WriteFunc writes the metrics to the endpoint with the given index.
+type WriteFunc func(endpoint int, metrics []telegraf.Metric) error
+
+// Balancer chooses the endpoints each batch of metrics is written to and
+// tracks which of them are healthy.  An endpoint failing a write is avoided
+// for the retry interval, unless no healthy endpoint is left.
+type Balancer struct {
+	strategy      string
+	hashTags      []string
+	retryInterval time.Duration
+
+	endpoints []*endpoint
+	ring      []ringPoint
+	next      int
+
+	// written holds the metrics written since the last successful
+	// consistent_hash write, they are skipped when the output retries the
+	// batch after a failure.
+	written map[telegraf.Metric]bool
+
+	now func() time.Time
+}
+
+type endpoint struct {
+	name      string
+	downUntil time.Time
+
+	writes         selfstat.Stat
+	writeErrors    selfstat.Stat
+	metricsWritten selfstat.Stat
+	healthy        selfstat.Stat
+}
+
+type ringPoint struct {
+	hash     uint64
+	endpoint int
+}
+
+// NewBalancer returns a Balancer for the endpoints of the output.  The
+// endpoint names are used to place them on the hash ring and to tag their
+// internal statistics, so they should be stable between restarts.
+func (c *Config) NewBalancer(output string, endpoints []string) (*Balancer, error) {
+	b := &Balancer{
+		strategy:      c.BalanceStrategy,
+		hashTags:      c.BalanceHashTags,
+		retryInterval: c.BalanceRetryInterval.Duration,
+		now:           time.Now,
+	}
+
+	switch b.strategy {
+	case "":
+		b.strategy = StrategyRandom
+	case StrategyRandom, StrategyFailover, StrategyRoundRobin, StrategyConsistentHash:
+	default:
+		return nil, fmt.Errorf("unknown balance_strategy %q", b.strategy)
+	}
+
+	if b.retryInterval <= 0 {
+		b.retryInterval = defaultRetryInterval
+	}
+
+	for i, name := range endpoints {
+		tags := map[string]string{"output": output, "endpoint": name}
+		e := &endpoint{
+			name:           name,
+			writes:         selfstat.Register("endpoint", "writes", tags),
+			writeErrors:    selfstat.Register("endpoint", "write_errors", tags),
+			metricsWritten: selfstat.Register("endpoint", "metrics_written", tags),
+			healthy:        selfstat.Register("endpoint", "healthy", tags),
+		}
+		e.healthy.Set(1)
+		b.endpoints = append(b.endpoints, e)
+
+		for r := 0; r < replicas; r++ {
+			b.ring = append(b.ring, ringPoint{
+				hash:     hash(name + "-" + strconv.Itoa(r)),
+				endpoint: i,
+			})
+		}
+	}
+	sort.Slice(b.ring, func(i, j int) bool {
+		return b.ring[i].hash < b.ring[j].hash
+	})
+
+	return b, nil
+}
+
+// Write writes the metrics using the strategy of the balancer.  Unless the
+// consistent_hash strategy is used, the whole batch is written to a single
+// endpoint, trying the others in turn if the write fails.  With the
+// consistent_hash strategy each endpoint receives the metrics of its own
+// series; the series of a failing endpoint are moved to the next endpoint on
+// the ring.  If consistent_hash writes fail after some endpoints were written
+// to, the metrics they received are not written again when the same batch is
+// retried, until a write succeeds.
+func (b *Balancer) Write(metrics []telegraf.Metric, write WriteFunc) error {
+	if len(b.endpoints) == 0 {
+		return ErrNoEndpoint
+	}
+
+	if b.strategy == StrategyConsistentHash {
+		return b.writeHashed(metrics, write)
+	}
+
+	for _, i := range b.order() {
+		if b.write(i, metrics, write) {
+			return nil
+		}
+	}
+	return ErrNoEndpoint
+}
+
+// order returns the endpoints in the order they are tried, the unhealthy
+// endpoints are tried last.
+func (b *Balancer) order() []int {
+	var order []int
+	switch b.strategy {
+	case StrategyRandom:
+		order = rand.Perm(len(b.endpoints))
+	case StrategyRoundRobin:
+		for i := range b.endpoints {
+			order = append(order, (b.next+i)%len(b.endpoints))
+		}
+		b.next = (b.next + 1) % len(b.endpoints)
+	default:
+		for i := range b.endpoints {
+			order = append(order, i)
+		}
+	}
+
+	now := b.now()
+	sort.SliceStable(order, func(i, j int) bool {
+		return b.endpoints[order[i]].up(now) && !b.endpoints[order[j]].up(now)
+	})
+	return order
+}
+
+func (b *Balancer) writeHashed(metrics []telegraf.Metric, write WriteFunc) error {
+	// Skip the metrics written during the previous, failed, writes.
+	if b.written != nil {
+		remaining := make([]telegraf.Metric, 0, len(metrics))
+		for _, m := range metrics {
+			if !b.written[m] {
+				remaining = append(remaining, m)
+			}
+		}
+		metrics = remaining
+	}
+
+	failed := make(map[int]bool)
+	for len(metrics) > 0 {
+		batches := make(map[int][]telegraf.Metric)
+		for _, m := range metrics {
+			i, ok := b.lookup(b.key(m), failed)
+			if !ok {
+				return ErrNoEndpoint
+			}
+			batches[i] = append(batches[i], m)
+		}
+
+		metrics = metrics[:0:0]
+		for i := range b.endpoints {
+			batch, ok := batches[i]
+			if !ok {
+				continue
+			}
+			if !b.write(i, batch, write) {
+				failed[i] = true
+				metrics = append(metrics, batch...)
+				continue
+			}
+			if b.written == nil {
+				b.written = make(map[telegraf.Metric]bool)
+			}
+			for _, m := range batch {
+				b.written[m] = true
+			}
+		}
+	}
+	b.written = nil
+	return nil
+}
+
+// lookup returns the endpoint owning the key on the hash ring, skipping the
+// endpoints that failed and, if possible, the unhealthy ones.
+func (b *Balancer) lookup(key uint64, failed map[int]bool) (int, bool) {
+	start := sort.Search(len(b.ring), func(i int) bool {
+		return b.ring[i].hash >= key
+	})
+
+	now := b.now()
+	fallback := -1
+	for n := 0; n < len(b.ring); n++ {
+		i := b.ring[(start+n)%len(b.ring)].endpoint
+		if failed[i] {
+			continue
+		}
+		if b.endpoints[i].up(now) {
+			return i, true
+		}
+		if fallback < 0 {
+			fallback = i
+		}
+	}
+	return fallback, fallback >= 0
+}
+
+// key returns the hash of the series of the metric, or of the values of the
+// hash tags if they are configured.
+func (b *Balancer) key(m telegraf.Metric) uint64 {
+	h := fnv.New64a()
+	if len(b.hashTags) > 0 {
+		for _, tag := range b.hashTags {
+			value, _ := m.GetTag(tag)
+			h.Write([]byte(value))
+			h.Write([]byte{0})
+		}
+		return mix(h.Sum64())
+	}
+
+	h.Write([]byte(m.Name()))
+	h.Write([]byte{0})
+	for _, tag := range m.TagList() {
+		h.Write([]byte(tag.Key))
+		h.Write([]byte{0})
+		h.Write([]byte(tag.Value))
+		h.Write([]byte{0})
+	}
+	return mix(h.Sum64())
+}
+
+// write writes the metrics to the endpoint and updates its health.
+func (b *Balancer) write(i int, metrics []telegraf.Metric, write WriteFunc) bool {
+	e := b.endpoints[i]
+	e.writes.Incr(1)
+	if err := write(i, metrics); err != nil {
+		e.writeErrors.Incr(1)
+		e.healthy.Set(0)
+		e.downUntil = b.now().Add(b.retryInterval)
+		return false
+	}
+
+	e.metricsWritten.Incr(int64(len(metrics)))
+	e.healthy.Set(1)
+	e.downUntil = time.Time{}
+	return true
+}
+
+func (e *endpoint) up(now time.Time) bool {
+	return !now.Before(e.downUntil)
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return mix(h.Sum64())
+}
+
+// mix spreads the FNV hash of similar strings over the whole ring, it is the
+// finalizer of MurmurHash3.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,263 @@
+package balancer
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a WriteFunc recording which endpoint each write went to, the
+// endpoints set as down fail their writes.
+type recorder struct {
+	down    map[int]bool
+	writes  []int
+	written map[int][]telegraf.Metric
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		down:    make(map[int]bool),
+		written: make(map[int][]telegraf.Metric),
+	}
+}
+
+func (r *recorder) write(i int, metrics []telegraf.Metric) error {
+	r.writes = append(r.writes, i)
+	if r.down[i] {
+		return errors.New("down")
+	}
+	r.written[i] = append(r.written[i], metrics...)
+	return nil
+}
+
+func newTestBalancer(t *testing.T, strategy string, n int) *Balancer {
+	var endpoints []string
+	for i := 0; i < n; i++ {
+		endpoints = append(endpoints, "http://server"+strconv.Itoa(i))
+	}
+	config := &Config{
+		BalanceStrategy:      strategy,
+		BalanceRetryInterval: internal.Duration{Duration: time.Minute},
+	}
+	b, err := config.NewBalancer("test", endpoints)
+	require.NoError(t, err)
+	return b
+}
+
+func series(n int) []telegraf.Metric {
+	var metrics []telegraf.Metric
+	for i := 0; i < n; i++ {
+		metrics = append(metrics, testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "host" + strconv.Itoa(i)},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0),
+		))
+	}
+	return metrics
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	config := &Config{BalanceStrategy: "least_loaded"}
+	_, err := config.NewBalancer("test", []string{"a"})
+	require.Error(t, err)
+}
+
+func TestFailover(t *testing.T) {
+	b := newTestBalancer(t, StrategyFailover, 3)
+	now := time.Unix(0, 0)
+	b.now = func() time.Time { return now }
+
+	r := newRecorder()
+	require.NoError(t, b.Write(series(1), r.write))
+	require.NoError(t, b.Write(series(1), r.write))
+	require.Equal(t, []int{0, 0}, r.writes)
+
+	// The failing endpoint is skipped until the retry interval has passed.
+	r = newRecorder()
+	r.down[0] = true
+	require.NoError(t, b.Write(series(1), r.write))
+	require.NoError(t, b.Write(series(1), r.write))
+	require.Equal(t, []int{0, 1, 1}, r.writes)
+
+	now = now.Add(time.Minute)
+	r.down[0] = false
+	require.NoError(t, b.Write(series(1), r.write))
+	require.Equal(t, []int{0, 1, 1, 0}, r.writes)
+}
+
+func TestRoundRobin(t *testing.T) {
+	b := newTestBalancer(t, StrategyRoundRobin, 3)
+
+	r := newRecorder()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, b.Write(series(1), r.write))
+	}
+	require.Equal(t, []int{0, 1, 2, 0}, r.writes)
+}
+
+func TestAllEndpointsDown(t *testing.T) {
+	for _, strategy := range []string{StrategyRandom, StrategyFailover, StrategyRoundRobin, StrategyConsistentHash} {
+		t.Run(strategy, func(t *testing.T) {
+			b := newTestBalancer(t, strategy, 3)
+			r := newRecorder()
+			r.down = map[int]bool{0: true, 1: true, 2: true}
+			require.Equal(t, ErrNoEndpoint, b.Write(series(10), r.write))
+
+			// Unhealthy endpoints are still tried when none is healthy.
+			r.down[2] = false
+			require.NoError(t, b.Write(series(10), r.write))
+			require.Len(t, r.written[2], 10)
+		})
+	}
+}
+
+func TestConsistentHash(t *testing.T) {
+	b := newTestBalancer(t, StrategyConsistentHash, 3)
+	metrics := series(300)
+
+	r := newRecorder()
+	require.NoError(t, b.Write(metrics, r.write))
+	owner := make(map[string]int)
+	for i, written := range r.written {
+		// Each endpoint receives a share of the series.
+		require.True(t, len(written) > 30, "endpoint %d got %d series", i, len(written))
+		for _, m := range written {
+			owner[m.Tags()["host"]] = i
+		}
+	}
+	require.Len(t, owner, 300)
+
+	// Series stay on the same endpoint.
+	r = newRecorder()
+	require.NoError(t, b.Write(metrics, r.write))
+	for i, written := range r.written {
+		for _, m := range written {
+			require.Equal(t, owner[m.Tags()["host"]], i)
+		}
+	}
+
+	// Only the series of a failing endpoint move.
+	r = newRecorder()
+	r.down[1] = true
+	require.NoError(t, b.Write(metrics, r.write))
+	require.Empty(t, r.written[1])
+	for i, written := range r.written {
+		for _, m := range written {
+			if owner[m.Tags()["host"]] != 1 {
+				require.Equal(t, owner[m.Tags()["host"]], i)
+			}
+		}
+	}
+	require.Len(t, r.written[0], len(series(300))-len(r.written[2]))
+}
+
+// flakyRecorder fails the writes of the endpoints after they wrote once.
+type flakyRecorder struct {
+	*recorder
+	wrote map[int]bool
+}
+
+func (r *flakyRecorder) write(i int, metrics []telegraf.Metric) error {
+	if r.wrote[i] {
+		r.writes = append(r.writes, i)
+		return errors.New("down")
+	}
+	r.wrote[i] = true
+	return r.recorder.write(i, metrics)
+}
+
+func TestConsistentHashPartialFailure(t *testing.T) {
+	b := newTestBalancer(t, StrategyConsistentHash, 3)
+	metrics := series(300)
+
+	// Endpoint 1 fails, and its series moved to the other endpoints fail
+	// as well since they can only write once.
+	r := &flakyRecorder{recorder: newRecorder(), wrote: make(map[int]bool)}
+	r.down[1] = true
+	require.Equal(t, ErrNoEndpoint, b.Write(metrics, r.write))
+	written := len(r.written[0]) + len(r.written[2])
+	require.True(t, written > 0 && written < len(metrics))
+
+	// The retry of the batch only writes the remaining series.
+	r2 := newRecorder()
+	require.NoError(t, b.Write(metrics, r2.write))
+	seen := make(map[telegraf.Metric]int)
+	for _, rec := range []*recorder{r.recorder, r2} {
+		for _, ms := range rec.written {
+			for _, m := range ms {
+				seen[m]++
+			}
+		}
+	}
+	require.Len(t, seen, len(metrics))
+	for _, count := range seen {
+		require.Equal(t, 1, count)
+	}
+
+	// The next batches are written entirely.
+	r3 := newRecorder()
+	require.NoError(t, b.Write(metrics, r3.write))
+	require.Equal(t, len(metrics), len(r3.written[0])+len(r3.written[1])+len(r3.written[2]))
+}
+
+func TestConsistentHashConsecutiveFailures(t *testing.T) {
+	b := newTestBalancer(t, StrategyConsistentHash, 3)
+	metrics := series(300)
+
+	r1 := &flakyRecorder{recorder: newRecorder(), wrote: make(map[int]bool)}
+	r1.down[1] = true
+	require.Equal(t, ErrNoEndpoint, b.Write(metrics, r1.write))
+
+	// The series of endpoint 1 are partly written to endpoint 0 before the
+	// write fails again.
+	r2 := &flakyRecorder{recorder: newRecorder(), wrote: make(map[int]bool)}
+	r2.down[1] = true
+	r2.down[2] = true
+	require.Equal(t, ErrNoEndpoint, b.Write(metrics, r2.write))
+	require.NotEmpty(t, r2.written[0])
+
+	r3 := newRecorder()
+	require.NoError(t, b.Write(metrics, r3.write))
+
+	seen := make(map[telegraf.Metric]int)
+	for _, rec := range []*recorder{r1.recorder, r2.recorder, r3} {
+		for _, ms := range rec.written {
+			for _, m := range ms {
+				seen[m]++
+			}
+		}
+	}
+	require.Len(t, seen, len(metrics))
+	for _, count := range seen {
+		require.Equal(t, 1, count)
+	}
+}
+
+func TestConsistentHashTags(t *testing.T) {
+	b := newTestBalancer(t, StrategyConsistentHash, 3)
+	b.hashTags = []string{"cluster"}
+
+	var metrics []telegraf.Metric
+	for i := 0; i < 20; i++ {
+		metrics = append(metrics, testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "host" + strconv.Itoa(i), "cluster": "a"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0),
+		))
+	}
+
+	r := newRecorder()
+	require.NoError(t, b.Write(metrics, r.write))
+	require.Len(t, r.written, 1)
+	for _, written := range r.written {
+		require.Len(t, written, 20)
+	}
+}
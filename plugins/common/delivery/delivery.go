@@ -0,0 +1,112 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+)
+
+// ErrTooManyUndelivered is returned when adding metrics would exceed the
+// maximum number of undelivered metrics.
+var ErrTooManyUndelivered = errors.New("too many undelivered metrics")
+
+// Tracker adds groups of metrics to a tracking accumulator and reports when
+// each group has been delivered to the outputs.  This allows service inputs
+// to acknowledge their clients only once the metrics are written.
+type Tracker struct {
+	acc            telegraf.TrackingAccumulator
+	maxUndelivered int
+
+	mu          sync.Mutex
+	undelivered int
+	pending     map[telegraf.TrackingID]*group
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type group struct {
+	size int
+	done chan bool
+}
+
+// NewTracker returns a Tracker that allows up to maxUndelivered metrics to be
+// awaiting delivery at once.
+func NewTracker(acc telegraf.Accumulator, maxUndelivered int) *Tracker {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Tracker{
+		acc:            acc.WithTracking(maxUndelivered),
+		maxUndelivered: maxUndelivered,
+		pending:        make(map[telegraf.TrackingID]*group),
+		cancel:         cancel,
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.onDelivery(ctx)
+	}()
+	return t
+}
+
+// Add adds the metrics to the accumulator as a single group.  The returned
+// channel receives true once all of the metrics have been delivered, or false
+// if any was rejected.  If the metrics would exceed the maximum number of
+// undelivered metrics they are not added and ErrTooManyUndelivered is
+// returned; a group larger than the maximum is accepted only when no other
+// metrics are undelivered.
+func (t *Tracker) Add(metrics []telegraf.Metric) (<-chan bool, error) {
+	done := make(chan bool, 1)
+	if len(metrics) == 0 {
+		done <- true
+		return done, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.undelivered > 0 && t.undelivered+len(metrics) > t.maxUndelivered {
+		return nil, ErrTooManyUndelivered
+	}
+
+	id := t.acc.AddTrackingMetricGroup(metrics)
+	t.undelivered += len(metrics)
+	t.pending[id] = &group{size: len(metrics), done: done}
+	return done, nil
+}
+
+// Undelivered returns the number of metrics awaiting delivery.
+func (t *Tracker) Undelivered() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.undelivered
+}
+
+// Stop stops processing delivery notifications.
+func (t *Tracker) Stop() {
+	t.cancel()
+	t.wg.Wait()
+}
+
+func (t *Tracker) onDelivery(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case info := <-t.acc.Delivered():
+			t.mu.Lock()
+			g, ok := t.pending[info.ID()]
+			if ok {
+				delete(t.pending, info.ID())
+				t.undelivered -= g.size
+			}
+			t.mu.Unlock()
+
+			if ok {
+				g.done <- info.Delivered()
+			}
+		}
+	}
+}
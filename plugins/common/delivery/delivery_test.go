@@ -0,0 +1,120 @@
+package delivery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// trackingAccumulator holds tracked metrics until they are accepted or
+// rejected by the test.
+type trackingAccumulator struct {
+	testutil.Accumulator
+	tracked   []telegraf.Metric
+	delivered chan telegraf.DeliveryInfo
+}
+
+func (a *trackingAccumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	a.delivered = make(chan telegraf.DeliveryInfo, maxTracked)
+	return a
+}
+
+func (a *trackingAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	tracked, id := metric.WithGroupTracking(group, func(info telegraf.DeliveryInfo) {
+		a.delivered <- info
+	})
+	a.tracked = append(a.tracked, tracked...)
+	return id
+}
+
+func (a *trackingAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return a.delivered
+}
+
+func (a *trackingAccumulator) finish(accept bool) {
+	for _, m := range a.tracked {
+		if accept {
+			m.Accept()
+		} else {
+			m.Reject()
+		}
+	}
+	a.tracked = nil
+}
+
+func metrics(n int) []telegraf.Metric {
+	var metrics []telegraf.Metric
+	for i := 0; i < n; i++ {
+		metrics = append(metrics, testutil.TestMetric(i))
+	}
+	return metrics
+}
+
+func wait(t *testing.T, done <-chan bool) bool {
+	select {
+	case delivered := <-done:
+		return delivered
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+	return false
+}
+
+func TestDelivered(t *testing.T) {
+	acc := &trackingAccumulator{}
+	tracker := NewTracker(acc, 10)
+	defer tracker.Stop()
+
+	done, err := tracker.Add(metrics(3))
+	require.NoError(t, err)
+	require.Equal(t, 3, tracker.Undelivered())
+
+	acc.finish(true)
+	require.True(t, wait(t, done))
+	require.Equal(t, 0, tracker.Undelivered())
+}
+
+func TestRejected(t *testing.T) {
+	acc := &trackingAccumulator{}
+	tracker := NewTracker(acc, 10)
+	defer tracker.Stop()
+
+	done, err := tracker.Add(metrics(3))
+	require.NoError(t, err)
+
+	acc.finish(false)
+	require.False(t, wait(t, done))
+}
+
+func TestTooManyUndelivered(t *testing.T) {
+	acc := &trackingAccumulator{}
+	tracker := NewTracker(acc, 4)
+	defer tracker.Stop()
+
+	// A group larger than the maximum is accepted when nothing is pending.
+	done, err := tracker.Add(metrics(5))
+	require.NoError(t, err)
+
+	_, err = tracker.Add(metrics(1))
+	require.Equal(t, ErrTooManyUndelivered, err)
+
+	acc.finish(true)
+	require.True(t, wait(t, done))
+
+	_, err = tracker.Add(metrics(4))
+	require.NoError(t, err)
+}
+
+func TestEmpty(t *testing.T) {
+	acc := &trackingAccumulator{}
+	tracker := NewTracker(acc, 1)
+	defer tracker.Stop()
+
+	done, err := tracker.Add(nil)
+	require.NoError(t, err)
+	require.True(t, wait(t, done))
+}
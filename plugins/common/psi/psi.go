@@ -0,0 +1,82 @@
+// Package psi parses the Pressure Stall Information of Linux, which is found
+// in /proc/pressure and in the cgroups of the unified hierarchy.
+package psi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	defaultHostProc = "/proc"
+	defaultHostSys  = "/sys"
+
+	envProc = "HOST_PROC"
+	envSys  = "HOST_SYS"
+)
+
+// HostProc returns the path of the proc filesystem, from the HOST_PROC
+// environment variable or /proc.
+func HostProc() string {
+	return getEnv(envProc, defaultHostProc)
+}
+
+// HostSys returns the path of the sysfs filesystem, from the HOST_SYS
+// environment variable or /sys.
+func HostSys() string {
+	return getEnv(envSys, defaultHostSys)
+}
+
+func getEnv(env, path string) string {
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	return path
+}
+
+// Gather parses the Pressure Stall Information of a resource, with a line per
+// type:
+//     some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//     full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+// A metric is added for each line, tagged with the type and the given tags.
+func Gather(acc telegraf.Accumulator, measurement string, tags map[string]string, data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		for _, part := range parts[1:] {
+			kv := strings.SplitN(part, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid pressure line %q", scanner.Text())
+			}
+			var value interface{}
+			var err error
+			if kv[0] == "total" {
+				value, err = strconv.ParseInt(kv[1], 10, 64)
+			} else {
+				value, err = strconv.ParseFloat(kv[1], 64)
+			}
+			if err != nil {
+				return fmt.Errorf("invalid pressure line %q: %v", scanner.Text(), err)
+			}
+			fields[kv[0]] = value
+		}
+
+		t := map[string]string{"type": parts[0]}
+		for k, v := range tags {
+			t[k] = v
+		}
+		acc.AddFields(measurement, fields, t)
+	}
+	return nil
+}
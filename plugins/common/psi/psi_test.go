@@ -0,0 +1,61 @@
+package psi
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGather(t *testing.T) {
+	data := []byte(`some avg10=1.50 avg60=0.75 avg300=0.25 total=1234
+full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+`)
+
+	var acc testutil.Accumulator
+	require.NoError(t, Gather(&acc, "pressure", map[string]string{"resource": "io"}, data))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("pressure",
+			map[string]string{"resource": "io", "type": "some"},
+			map[string]interface{}{"avg10": 1.5, "avg60": 0.75, "avg300": 0.25, "total": int64(1234)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("pressure",
+			map[string]string{"resource": "io", "type": "full"},
+			map[string]interface{}{"avg10": 0.0, "avg60": 0.0, "avg300": 0.0, "total": int64(0)},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestGatherInvalid(t *testing.T) {
+	for _, data := range []string{
+		"some avg10",
+		"some avg10=high",
+		"some total=1.5",
+	} {
+		var acc testutil.Accumulator
+		require.Error(t, Gather(&acc, "pressure", nil, []byte(data)), data)
+		require.Empty(t, acc.GetTelegrafMetrics())
+	}
+}
+
+func TestHostPaths(t *testing.T) {
+	defer func(proc, sys string) {
+		os.Setenv(envProc, proc)
+		os.Setenv(envSys, sys)
+	}(os.Getenv(envProc), os.Getenv(envSys))
+
+	os.Setenv(envProc, "")
+	os.Setenv(envSys, "")
+	require.Equal(t, "/proc", HostProc())
+	require.Equal(t, "/sys", HostSys())
+
+	os.Setenv(envProc, "/host/proc")
+	os.Setenv(envSys, "/host/sys")
+	require.Equal(t, "/host/proc", HostProc())
+	require.Equal(t, "/host/sys", HostSys())
+}
@@ -6,8 +6,11 @@ import (
 )
 
 type CGroup struct {
-	Paths []string `toml:"paths"`
-	Files []string `toml:"files"`
+	Paths    []string `toml:"paths"`
+	Files    []string `toml:"files"`
+	Unified  bool     `toml:"unified"`
+	MaxDepth int      `toml:"max_depth"`
+	HostSys  string   `toml:"host_sys"`
 }
 
 var sampleConfig = `
@@ -23,6 +26,18 @@ var sampleConfig = `
   ## cgroup stat fields, as file names, globs are supported.
   ## these file names are appended to each path from above.
   # files = ["memory.*usage*", "memory.limit_in_bytes"]
+
+  ## Walk the unified cgroup v2 hierarchy and parse the cpu.stat,
+  ## memory.stat, io.stat and pressure files into structured fields.  The
+  ## paths default to the root of the hierarchy and the cgroups below them are
+  ## walked up to max_depth levels, 0 for no limit.  The files default to all
+  ## the supported files.
+  # unified = false
+  # max_depth = 0
+
+  ## Path of the sysfs mount holding the unified hierarchy in fs/cgroup,
+  ## defaults to the HOST_SYS environment variable or /sys.
+  # host_sys = "/sys"
 `
 
 func (g *CGroup) SampleConfig() string {
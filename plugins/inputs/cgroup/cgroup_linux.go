@@ -17,6 +17,10 @@ import (
 const metricName = "cgroup"
 
 func (g *CGroup) Gather(acc telegraf.Accumulator) error {
+	if g.Unified {
+		return g.gatherUnified(acc)
+	}
+
 	list := make(chan pathInfo)
 	go g.generateDirs(list)
 
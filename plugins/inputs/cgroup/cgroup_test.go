@@ -4,7 +4,9 @@ package cgroup
 
 import (
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -180,3 +182,145 @@ func TestCgroupStatistics_6(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "cgroup", fields, tags)
 }
+
+// ======================================================================
+
+func TestCgroupUnified(t *testing.T) {
+	cg := &CGroup{
+		Unified: true,
+		HostSys: "testdata/sys",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(cg.Gather))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cgroup",
+			map[string]string{"path": "/"},
+			map[string]interface{}{
+				"cpu_usage_usec":  int64(1000),
+				"cpu_user_usec":   int64(600),
+				"cpu_system_usec": int64(400),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup_pressure",
+			map[string]string{"path": "/", "resource": "cpu", "type": "some"},
+			map[string]interface{}{"avg10": 1.5, "avg60": 0.75, "avg300": 0.25, "total": int64(123456)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup_pressure",
+			map[string]string{"path": "/", "resource": "io", "type": "some"},
+			map[string]interface{}{"avg10": 0.0, "avg60": 0.0, "avg300": 0.0, "total": int64(42)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup_pressure",
+			map[string]string{"path": "/", "resource": "io", "type": "full"},
+			map[string]interface{}{"avg10": 0.0, "avg60": 0.0, "avg300": 0.0, "total": int64(21)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup",
+			map[string]string{"path": "/system.slice"},
+			map[string]interface{}{
+				"cpu_usage_usec":      int64(700),
+				"cpu_user_usec":       int64(500),
+				"cpu_system_usec":     int64(200),
+				"cpu_nr_periods":      int64(10),
+				"cpu_nr_throttled":    int64(2),
+				"cpu_throttled_usec":  int64(300),
+				"memory_anon":         int64(4096),
+				"memory_file":         int64(8192),
+				"memory_kernel_stack": int64(16384),
+				"memory_pgfault":      int64(1234),
+				"memory_current":      int64(12288),
+				"pids_current":        int64(7),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup_io",
+			map[string]string{"path": "/system.slice", "device": "8:0"},
+			map[string]interface{}{
+				"rbytes": int64(90430464),
+				"wbytes": int64(299008000),
+				"rios":   int64(8950),
+				"wios":   int64(1252),
+				"dbytes": int64(0),
+				"dios":   int64(0),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup_io",
+			map[string]string{"path": "/system.slice", "device": "253:0"},
+			map[string]interface{}{
+				"rbytes": int64(1024),
+				"wbytes": int64(2048),
+				"rios":   int64(1),
+				"wios":   int64(2),
+				"dbytes": int64(0),
+				"dios":   int64(0),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup_pressure",
+			map[string]string{"path": "/system.slice", "resource": "memory", "type": "some"},
+			map[string]interface{}{"avg10": 0.0, "avg60": 0.0, "avg300": 0.0, "total": int64(10)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup_pressure",
+			map[string]string{"path": "/system.slice", "resource": "memory", "type": "full"},
+			map[string]interface{}{"avg10": 0.0, "avg60": 0.0, "avg300": 0.0, "total": int64(5)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup",
+			map[string]string{"path": "/system.slice/docker.service"},
+			map[string]interface{}{
+				"cpu_usage_usec":  int64(1000),
+				"cpu_user_usec":   int64(600),
+				"cpu_system_usec": int64(400),
+				"memory_current":  int64(4096),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup",
+			map[string]string{"path": "/system.slice/docker.service/init.scope"},
+			map[string]interface{}{
+				"memory_current": int64(1024),
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(),
+		testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestCgroupUnifiedPathsAndFiles(t *testing.T) {
+	cg := &CGroup{
+		Unified:  true,
+		HostSys:  "testdata/sys",
+		Paths:    []string{"testdata/sys/fs/cgroup/system.*"},
+		Files:    []string{"memory.*"},
+		MaxDepth: 1,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(cg.Gather))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cgroup",
+			map[string]string{"path": "/system.slice"},
+			map[string]interface{}{
+				"memory_anon":         int64(4096),
+				"memory_file":         int64(8192),
+				"memory_kernel_stack": int64(16384),
+				"memory_pgfault":      int64(1234),
+				"memory_current":      int64(12288),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup_pressure",
+			map[string]string{"path": "/system.slice", "resource": "memory", "type": "some"},
+			map[string]interface{}{"avg10": 0.0, "avg60": 0.0, "avg300": 0.0, "total": int64(10)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup_pressure",
+			map[string]string{"path": "/system.slice", "resource": "memory", "type": "full"},
+			map[string]interface{}{"avg10": 0.0, "avg60": 0.0, "avg300": 0.0, "total": int64(5)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cgroup",
+			map[string]string{"path": "/system.slice/docker.service"},
+			map[string]interface{}{
+				"memory_current": int64(4096),
+			},
+			time.Unix(0, 0)),
+	}
+	// The init.scope cgroup is beyond the maximum depth
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(),
+		testutil.IgnoreTime(), testutil.SortMetrics())
+}
@@ -0,0 +1,187 @@
+// +build linux
+
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/psi"
+)
+
+// unifiedFiles are the files parsed in the unified hierarchy by default.
+var unifiedFiles = []string{
+	"cpu.stat",
+	"memory.stat",
+	"memory.current",
+	"memory.swap.current",
+	"pids.current",
+	"io.stat",
+	"*.pressure",
+}
+
+// gatherUnified walks the cgroups of the unified hierarchy below the paths.
+func (g *CGroup) gatherUnified(acc telegraf.Accumulator) error {
+	if g.HostSys == "" {
+		g.HostSys = psi.HostSys()
+	}
+	root := filepath.Join(g.HostSys, "fs", "cgroup")
+
+	paths := g.Paths
+	if len(paths) == 0 {
+		paths = []string{root}
+	}
+	files := g.Files
+	if len(files) == 0 {
+		files = unifiedFiles
+	}
+
+	for _, pattern := range paths {
+		dirs, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		for _, dir := range dirs {
+			depth := strings.Count(filepath.Clean(dir), string(filepath.Separator))
+			err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					// cgroups may be removed while walking
+					if os.IsNotExist(err) {
+						return nil
+					}
+					return err
+				}
+				if !info.IsDir() {
+					return nil
+				}
+				if err := g.gatherCgroup(acc, root, path, files); err != nil {
+					acc.AddError(err)
+				}
+				if g.MaxDepth > 0 && strings.Count(path, string(filepath.Separator))-depth >= g.MaxDepth {
+					return filepath.SkipDir
+				}
+				return nil
+			})
+			if err != nil {
+				acc.AddError(err)
+			}
+		}
+	}
+	return nil
+}
+
+// gatherCgroup parses the files of a cgroup, tagged with its path in the
+// hierarchy.
+func (g *CGroup) gatherCgroup(acc telegraf.Accumulator, root, dir string, patterns []string) error {
+	path := dir
+	if rel, err := filepath.Rel(root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		path = filepath.Join("/", rel)
+	}
+
+	fields := make(map[string]interface{})
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.Base(pattern)))
+		if err != nil {
+			return err
+		}
+		for _, file := range matches {
+			name := filepath.Base(file)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return err
+			}
+
+			switch {
+			case name == "io.stat":
+				gatherIOStat(acc, path, data)
+			case strings.HasSuffix(name, ".pressure"):
+				tags := map[string]string{
+					"path":     path,
+					"resource": strings.TrimSuffix(name, ".pressure"),
+				}
+				if err := psi.Gather(acc, "cgroup_pressure", tags, data); err != nil {
+					return fmt.Errorf("%s: %v", file, err)
+				}
+			case strings.HasSuffix(name, ".stat"):
+				prefix := strings.Replace(strings.TrimSuffix(name, ".stat"), ".", "_", -1)
+				parseFlatKeyed(prefix, fields, data)
+			default:
+				// Single values, such as memory.current, the "max" limits
+				// are skipped.
+				value, err := strconv.ParseInt(string(bytes.TrimSpace(data)), 10, 64)
+				if err == nil {
+					fields[strings.Replace(name, ".", "_", -1)] = value
+				}
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		acc.AddFields(metricName, fields, map[string]string{"path": path})
+	}
+	return nil
+}
+
+// parseFlatKeyed parses the lines of "key value" pairs, such as in cpu.stat.
+func parseFlatKeyed(prefix string, fields map[string]interface{}, data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		fields[prefix+"_"+parts[0]] = value
+	}
+}
+
+// gatherIOStat parses the nested keyed io.stat, with a line of "key=value"
+// pairs per device:
+//     8:0 rbytes=90430464 wbytes=299008000 rios=8950 wios=1252 dbytes=0 dios=0
+func gatherIOStat(acc telegraf.Accumulator, path string, data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 {
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		for _, part := range parts[1:] {
+			kv := strings.SplitN(part, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			value, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			fields[kv[0]] = value
+		}
+
+		tags := map[string]string{
+			"path":   path,
+			"device": parts[0],
+		}
+		acc.AddFields("cgroup_io", fields, tags)
+	}
+}
@@ -0,0 +1,454 @@
+package directory_monitor
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const sampleConfig = `
+  ## The directory to monitor for new files.
+  directory = ""
+
+  ## The directory to move files to once all of their metrics have been
+  ## delivered.
+  finished_directory = ""
+
+  ## The directory to move files to when they could not be parsed or their
+  ## metrics were not delivered.
+  error_directory = ""
+
+  ## Files to process, relative to the directory.  These accept standard unix
+  ## glob matching rules, but with the addition of ** as a "super asterisk".
+  ## Files ending in ".gz" are decompressed before parsing.
+  # files = ["*"]
+
+  ## Only process files that have not been modified for this long, to avoid
+  ## reading files that are still being written.
+  # min_file_age = "1s"
+
+  ## Maximum number of files to process at once.
+  # max_concurrent_files = 1
+
+  ## Maximum metrics to read from files that have not been written by an
+  ## output.  Processing of files pauses while this limit is reached.  For
+  ## best throughput set based on the output's metric_batch_size.
+  # max_undelivered_metrics = 10000
+
+  ## Name a tag containing the name of the file the data was parsed from.
+  ## Leave empty to disable.
+  # file_tag = ""
+
+  ## Data format to consume.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+const (
+	defaultMinFileAge            = time.Second
+	defaultMaxConcurrentFiles    = 1
+	defaultMaxUndeliveredMetrics = 10000
+)
+
+type empty struct{}
+type semaphore chan empty
+
+type DirectoryMonitor struct {
+	Directory             string            `toml:"directory"`
+	FinishedDirectory     string            `toml:"finished_directory"`
+	ErrorDirectory        string            `toml:"error_directory"`
+	Files                 []string          `toml:"files"`
+	MinFileAge            internal.Duration `toml:"min_file_age"`
+	MaxConcurrentFiles    int               `toml:"max_concurrent_files"`
+	MaxUndeliveredMetrics int               `toml:"max_undelivered_metrics"`
+	FileTag               string            `toml:"file_tag"`
+	Log                   telegraf.Logger   `toml:"-"`
+
+	parserFunc parsers.ParserFunc
+	globs      []*globpath.GlobPath
+
+	acc    telegraf.TrackingAccumulator
+	sem    semaphore
+	queue  chan string
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// acquireMu serializes reservations from the semaphore so that workers
+	// cannot deadlock each holding part of the tokens they need.
+	acquireMu sync.Mutex
+
+	mu         sync.Mutex
+	processing map[string]bool
+	groups     map[telegraf.TrackingID]*group
+
+	filesProcessed selfstat.Stat
+	filesDropped   selfstat.Stat
+}
+
+// file is a file whose metrics have been added for delivery.
+type file struct {
+	path     string
+	pending  int
+	rejected bool
+}
+
+// group is a tracked group of metrics from a file.
+type group struct {
+	file *file
+	size int
+}
+
+func (m *DirectoryMonitor) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *DirectoryMonitor) Description() string {
+	return "Ingests files in a directory and then moves them to another directory."
+}
+
+func (m *DirectoryMonitor) SetParserFunc(fn parsers.ParserFunc) {
+	m.parserFunc = fn
+}
+
+func (m *DirectoryMonitor) Init() error {
+	if m.Directory == "" || m.FinishedDirectory == "" || m.ErrorDirectory == "" {
+		return errors.New("directory, finished_directory and error_directory must be set")
+	}
+
+	if len(m.Files) == 0 {
+		m.Files = []string{"*"}
+	}
+
+	m.globs = m.globs[:0]
+	for _, pattern := range m.Files {
+		g, err := globpath.Compile(filepath.Join(m.Directory, pattern))
+		if err != nil {
+			return fmt.Errorf("could not compile glob %q: %v", pattern, err)
+		}
+		m.globs = append(m.globs, g)
+	}
+
+	if m.MaxConcurrentFiles <= 0 {
+		m.MaxConcurrentFiles = defaultMaxConcurrentFiles
+	}
+	if m.MaxUndeliveredMetrics <= 0 {
+		m.MaxUndeliveredMetrics = defaultMaxUndeliveredMetrics
+	}
+
+	tags := map[string]string{
+		"directory": m.Directory,
+	}
+	m.filesProcessed = selfstat.Register("directory_monitor", "files_processed", tags)
+	m.filesDropped = selfstat.Register("directory_monitor", "files_dropped", tags)
+	return nil
+}
+
+func (m *DirectoryMonitor) Start(acc telegraf.Accumulator) error {
+	for _, dir := range []string{m.FinishedDirectory, m.ErrorDirectory} {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.acc = acc.WithTracking(m.MaxUndeliveredMetrics)
+	m.sem = make(semaphore, m.MaxUndeliveredMetrics)
+	m.queue = make(chan string, m.MaxConcurrentFiles)
+	m.processing = make(map[string]bool)
+	m.groups = make(map[telegraf.TrackingID]*group)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.onDelivery(ctx)
+	}()
+
+	for i := 0; i < m.MaxConcurrentFiles; i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case path := <-m.queue:
+					m.processFile(ctx, path)
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// Gather queues any new files found in the directory.
+func (m *DirectoryMonitor) Gather(acc telegraf.Accumulator) error {
+	now := time.Now()
+	for _, g := range m.globs {
+		for _, path := range g.Match() {
+			if m.isExcluded(path) {
+				continue
+			}
+
+			stat, err := os.Stat(path)
+			if err != nil || !stat.Mode().IsRegular() {
+				continue
+			}
+
+			if now.Sub(stat.ModTime()) < m.MinFileAge.Duration {
+				continue
+			}
+
+			m.mu.Lock()
+			if m.processing[path] {
+				m.mu.Unlock()
+				continue
+			}
+			m.processing[path] = true
+			m.mu.Unlock()
+
+			select {
+			case m.queue <- path:
+			default:
+				// All workers are busy; pick the file up on a later
+				// interval.
+				m.mu.Lock()
+				delete(m.processing, path)
+				m.mu.Unlock()
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (m *DirectoryMonitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// isExcluded returns true if the path is within the finished or error
+// directory, which may be located inside of the monitored directory.
+func (m *DirectoryMonitor) isExcluded(path string) bool {
+	for _, dir := range []string{m.FinishedDirectory, m.ErrorDirectory} {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *DirectoryMonitor) processFile(ctx context.Context, path string) {
+	metrics, err := m.readFile(path)
+	if err != nil {
+		m.acc.AddError(fmt.Errorf("could not process file %q: %v", path, err))
+		m.finish(&file{path: path, rejected: true})
+		return
+	}
+
+	if m.FileTag != "" {
+		for _, metric := range metrics {
+			metric.AddTag(m.FileTag, filepath.Base(path))
+		}
+	}
+
+	var chunks [][]telegraf.Metric
+	for len(metrics) > m.MaxUndeliveredMetrics {
+		chunks = append(chunks, metrics[:m.MaxUndeliveredMetrics])
+		metrics = metrics[m.MaxUndeliveredMetrics:]
+	}
+	if len(metrics) > 0 {
+		chunks = append(chunks, metrics)
+	}
+
+	f := &file{path: path, pending: len(chunks)}
+	if len(chunks) == 0 {
+		m.finish(f)
+		return
+	}
+
+	for _, chunk := range chunks {
+		if !m.acquire(ctx, len(chunk)) {
+			// Shutting down; the file is left in place and will be read
+			// again on the next start.
+			return
+		}
+
+		m.mu.Lock()
+		id := m.acc.AddTrackingMetricGroup(chunk)
+		m.groups[id] = &group{file: f, size: len(chunk)}
+		m.mu.Unlock()
+	}
+}
+
+func (m *DirectoryMonitor) readFile(path string) ([]telegraf.Metric, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	parser, err := m.parserFunc()
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse(data)
+}
+
+// acquire reserves n metrics from the undelivered metrics semaphore,
+// returning false if the context is cancelled first.
+func (m *DirectoryMonitor) acquire(ctx context.Context, n int) bool {
+	m.acquireMu.Lock()
+	defer m.acquireMu.Unlock()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			m.release(i)
+			return false
+		case m.sem <- empty{}:
+		}
+	}
+	return true
+}
+
+func (m *DirectoryMonitor) release(n int) {
+	for i := 0; i < n; i++ {
+		<-m.sem
+	}
+}
+
+func (m *DirectoryMonitor) onDelivery(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case track := <-m.acc.Delivered():
+			m.mu.Lock()
+			g, ok := m.groups[track.ID()]
+			if !ok {
+				m.mu.Unlock()
+				continue
+			}
+			delete(m.groups, track.ID())
+			g.file.pending--
+			if !track.Delivered() {
+				g.file.rejected = true
+			}
+			done := g.file.pending == 0
+			m.mu.Unlock()
+
+			m.release(g.size)
+			if done {
+				m.finish(g.file)
+			}
+		}
+	}
+}
+
+// finish moves the file to the finished or error directory.
+func (m *DirectoryMonitor) finish(f *file) {
+	dir := m.FinishedDirectory
+	if f.rejected {
+		dir = m.ErrorDirectory
+		m.filesDropped.Incr(1)
+	} else {
+		m.filesProcessed.Incr(1)
+	}
+
+	rel, err := filepath.Rel(m.Directory, f.path)
+	if err != nil {
+		rel = filepath.Base(f.path)
+	}
+
+	err = moveFile(f.path, filepath.Join(dir, rel))
+	if err != nil {
+		m.acc.AddError(fmt.Errorf("could not move file %q: %v", f.path, err))
+	}
+
+	m.mu.Lock()
+	delete(m.processing, f.path)
+	m.mu.Unlock()
+}
+
+// moveFile renames src to dst, falling back to copying the file when they
+// are on different filesystems.
+func moveFile(src, dst string) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+func init() {
+	inputs.Add("directory_monitor", func() telegraf.Input {
+		return &DirectoryMonitor{
+			Files:                 []string{"*"},
+			MinFileAge:            internal.Duration{Duration: defaultMinFileAge},
+			MaxConcurrentFiles:    defaultMaxConcurrentFiles,
+			MaxUndeliveredMetrics: defaultMaxUndeliveredMetrics,
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package directory_monitor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// trackingAccumulator accepts or rejects every tracked metric as soon as it
+// is added.
+type trackingAccumulator struct {
+	testutil.Accumulator
+	reject    bool
+	delivered chan telegraf.DeliveryInfo
+}
+
+func (a *trackingAccumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	a.delivered = make(chan telegraf.DeliveryInfo, maxTracked)
+	return a
+}
+
+func (a *trackingAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	tracked, id := metric.WithGroupTracking(group, func(info telegraf.DeliveryInfo) {
+		a.delivered <- info
+	})
+	for _, m := range tracked {
+		a.AddMetric(m)
+		if a.reject {
+			m.Reject()
+		} else {
+			m.Accept()
+		}
+	}
+	return id
+}
+
+func (a *trackingAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return a.delivered
+}
+
+func newInfluxParser() (parsers.Parser, error) {
+	return parsers.NewInfluxParser()
+}
+
+func newMonitor(t *testing.T, dir string) *DirectoryMonitor {
+	m := &DirectoryMonitor{
+		Directory:             filepath.Join(dir, "spool"),
+		FinishedDirectory:     filepath.Join(dir, "finished"),
+		ErrorDirectory:        filepath.Join(dir, "error"),
+		MaxConcurrentFiles:    2,
+		MaxUndeliveredMetrics: 2,
+		FileTag:               "file",
+		Log:                   testutil.Logger{},
+	}
+	m.SetParserFunc(newInfluxParser)
+	require.NoError(t, os.MkdirAll(m.Directory, 0755))
+	require.NoError(t, m.Init())
+	return m
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	require.NoError(t, ioutil.WriteFile(path, data, 0644))
+}
+
+func waitForFile(t *testing.T, path string) {
+	for i := 0; i < 500; i++ {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("file %q was not created", path)
+}
+
+func TestProcessFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := newMonitor(t, dir)
+
+	writeFile(t, filepath.Join(m.Directory, "metrics.influx"), []byte(
+		"cpu value=1 1574000000000000000\n"+
+			"cpu value=2 1574000001000000000\n"+
+			"cpu value=3 1574000002000000000\n"))
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write([]byte("mem free=42i 1574000000000000000\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	writeFile(t, filepath.Join(m.Directory, "metrics.influx.gz"), buf.Bytes())
+
+	acc := &trackingAccumulator{}
+	require.NoError(t, m.Start(acc))
+	require.NoError(t, m.Gather(acc))
+
+	waitForFile(t, filepath.Join(m.FinishedDirectory, "metrics.influx"))
+	waitForFile(t, filepath.Join(m.FinishedDirectory, "metrics.influx.gz"))
+	m.Stop()
+
+	_, err = os.Stat(filepath.Join(m.Directory, "metrics.influx"))
+	require.True(t, os.IsNotExist(err))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"file": "metrics.influx"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(1574000000, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{"file": "metrics.influx"},
+			map[string]interface{}{"value": 2.0},
+			time.Unix(1574000001, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{"file": "metrics.influx"},
+			map[string]interface{}{"value": 3.0},
+			time.Unix(1574000002, 0)),
+		testutil.MustMetric("mem",
+			map[string]string{"file": "metrics.influx.gz"},
+			map[string]interface{}{"free": int64(42)},
+			time.Unix(1574000000, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
+}
+
+func TestParseErrorMovesToErrorDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := newMonitor(t, dir)
+	writeFile(t, filepath.Join(m.Directory, "invalid.influx"), []byte("not line protocol\n"))
+
+	acc := &trackingAccumulator{}
+	require.NoError(t, m.Start(acc))
+	require.NoError(t, m.Gather(acc))
+
+	waitForFile(t, filepath.Join(m.ErrorDirectory, "invalid.influx"))
+	m.Stop()
+	require.Len(t, acc.Errors, 1)
+}
+
+func TestRejectedMovesToErrorDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := newMonitor(t, dir)
+	writeFile(t, filepath.Join(m.Directory, "metrics.influx"), []byte("cpu value=1 1574000000000000000\n"))
+
+	acc := &trackingAccumulator{reject: true}
+	require.NoError(t, m.Start(acc))
+	defer m.Stop()
+
+	require.NoError(t, m.Gather(acc))
+
+	waitForFile(t, filepath.Join(m.ErrorDirectory, "metrics.influx"))
+}
+
+func TestSkipsRecentFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := newMonitor(t, dir)
+	m.MinFileAge = internal.Duration{Duration: time.Hour}
+	m.Files = []string{"*.influx"}
+	require.NoError(t, m.Init())
+
+	writeFile(t, filepath.Join(m.Directory, "metrics.influx"), []byte("cpu value=1 1574000000000000000\n"))
+	writeFile(t, filepath.Join(m.Directory, "ignored.txt"), []byte("cpu value=1 1574000000000000000\n"))
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(m.Directory, "ignored.txt"), old, old))
+
+	acc := &trackingAccumulator{}
+	require.NoError(t, m.Start(acc))
+	require.NoError(t, m.Gather(acc))
+	m.Stop()
+
+	require.Equal(t, uint64(0), acc.NMetrics())
+	_, err = os.Stat(filepath.Join(m.Directory, "metrics.influx"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(m.Directory, "ignored.txt"))
+	require.NoError(t, err)
+}
+
+func TestInitRequiresDirectories(t *testing.T) {
+	m := &DirectoryMonitor{Directory: "/tmp"}
+	require.Error(t, m.Init())
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/delivery"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
@@ -24,6 +25,11 @@ import (
 // 500 MB
 const defaultMaxBodySize = 500 * 1024 * 1024
 
+const (
+	defaultDeliveryTimeout       = 5 * time.Second
+	defaultMaxUndeliveredMetrics = 10000
+)
+
 const (
 	body  = "body"
 	query = "query"
@@ -46,6 +52,10 @@ type HTTPListenerV2 struct {
 	BasicPassword  string            `toml:"basic_password"`
 	tlsint.ServerConfig
 
+	WaitForDelivery       bool              `toml:"wait_for_delivery"`
+	DeliveryTimeout       internal.Duration `toml:"delivery_timeout"`
+	MaxUndeliveredMetrics int               `toml:"max_undelivered_metrics"`
+
 	TimeFunc
 	Log telegraf.Logger
 
@@ -54,7 +64,8 @@ type HTTPListenerV2 struct {
 	listener net.Listener
 
 	parsers.Parser
-	acc telegraf.Accumulator
+	acc     telegraf.Accumulator
+	tracker *delivery.Tracker
 }
 
 const sampleConfig = `
@@ -93,6 +104,18 @@ const sampleConfig = `
   # basic_username = "foobar"
   # basic_password = "barfoo"
 
+  ## Hold the response to requests until their metrics have been written by
+  ## the outputs.  Requests are answered with 204 once delivered, 500 if an
+  ## output rejected the metrics or 503 if they were not delivered within the
+  ## delivery_timeout.
+  # wait_for_delivery = false
+  # delivery_timeout = "5s"
+
+  ## Maximum metrics that have not been written by an output, requests are
+  ## answered with 429 while the limit is reached.  Defaults to 10000 when
+  ## wait_for_delivery is set, 0 disables the limit.
+  # max_undelivered_metrics = 0
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -131,6 +154,16 @@ func (h *HTTPListenerV2) Start(acc telegraf.Accumulator) error {
 
 	h.acc = acc
 
+	if h.WaitForDelivery && h.MaxUndeliveredMetrics <= 0 {
+		h.MaxUndeliveredMetrics = defaultMaxUndeliveredMetrics
+	}
+	if h.DeliveryTimeout.Duration <= 0 {
+		h.DeliveryTimeout.Duration = defaultDeliveryTimeout
+	}
+	if h.MaxUndeliveredMetrics > 0 {
+		h.tracker = delivery.NewTracker(acc, h.MaxUndeliveredMetrics)
+	}
+
 	tlsConf, err := h.ServerConfig.TLSConfig()
 	if err != nil {
 		return err
@@ -171,6 +204,9 @@ func (h *HTTPListenerV2) Start(acc telegraf.Accumulator) error {
 func (h *HTTPListenerV2) Stop() {
 	h.listener.Close()
 	h.wg.Wait()
+	if h.tracker != nil {
+		h.tracker.Stop()
+	}
 }
 
 func (h *HTTPListenerV2) ServeHTTP(res http.ResponseWriter, req *http.Request) {
@@ -224,11 +260,35 @@ func (h *HTTPListenerV2) serveWrite(res http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	for _, m := range metrics {
-		h.acc.AddMetric(m)
+	if h.tracker == nil {
+		for _, m := range metrics {
+			h.acc.AddMetric(m)
+		}
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	done, err := h.tracker.Add(metrics)
+	if err != nil {
+		tooManyRequests(res)
+		return
 	}
 
-	res.WriteHeader(http.StatusNoContent)
+	if !h.WaitForDelivery {
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	select {
+	case delivered := <-done:
+		if !delivered {
+			rejected(res)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	case <-time.After(h.DeliveryTimeout.Duration):
+		serviceUnavailable(res)
+	}
 }
 
 func (h *HTTPListenerV2) collectBody(res http.ResponseWriter, req *http.Request) ([]byte, bool) {
@@ -281,6 +341,24 @@ func methodNotAllowed(res http.ResponseWriter) {
 	res.Write([]byte(`{"error":"http: method not allowed"}`))
 }
 
+func tooManyRequests(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusTooManyRequests)
+	res.Write([]byte(`{"error":"http: too many undelivered metrics"}`))
+}
+
+func rejected(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusInternalServerError)
+	res.Write([]byte(`{"error":"http: metrics were rejected by an output"}`))
+}
+
+func serviceUnavailable(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusServiceUnavailable)
+	res.Write([]byte(`{"error":"http: timeout waiting for metrics to be delivered"}`))
+}
+
 func internalServerError(res http.ResponseWriter) {
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusInternalServerError)
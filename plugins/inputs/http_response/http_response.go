@@ -7,6 +7,8 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -17,6 +19,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/tidwall/gjson"
 )
 
 // HTTPResponse struct
@@ -30,13 +33,46 @@ type HTTPResponse struct {
 	Headers             map[string]string
 	FollowRedirects     bool
 	ResponseStringMatch string
+	ResponseStatusCode  int
+	ResponseHeaderMatch map[string]string
+	JSONAssertions      []*JSONAssertion `toml:"json_assertion"`
+	TimingBreakdown     bool
 	Interface           string
+	Steps               []*Step `toml:"step"`
 	tls.ClientConfig
 
 	Log telegraf.Logger
 
+	checks []*Step
+	client *http.Client
+}
+
+// Step is a request along with the expectations on its response.  The steps
+// of a multi-step check are run in order and share their cookies.
+type Step struct {
+	Name                string            `toml:"name"`
+	URL                 string            `toml:"url"`
+	Method              string            `toml:"method"`
+	Body                string            `toml:"body"`
+	Headers             map[string]string `toml:"headers"`
+	ResponseStringMatch string            `toml:"response_string_match"`
+	ResponseStatusCode  int               `toml:"response_status_code"`
+	ResponseHeaderMatch map[string]string `toml:"response_header_match"`
+	JSONAssertions      []*JSONAssertion  `toml:"json_assertion"`
+
 	compiledStringMatch *regexp.Regexp
-	client              *http.Client
+	compiledHeaderMatch map[string]*regexp.Regexp
+}
+
+// JSONAssertion checks the value at a GJSON path of the response body.  The
+// value must equal Equals or match the Match regex; without either, the path
+// must exist.
+type JSONAssertion struct {
+	Path   string `toml:"path"`
+	Equals string `toml:"equals"`
+	Match  string `toml:"match"`
+
+	compiledMatch *regexp.Regexp
 }
 
 // Description returns the plugin Description
@@ -74,6 +110,13 @@ var sampleConfig = `
   # response_string_match = "ok"
   # response_string_match = "\".*_status\".?:.?\"up\""
 
+  ## Optional expected HTTP status code of the response
+  # response_status_code = 200
+
+  ## Report the time spent in each phase of the request: DNS lookup,
+  ## connection, TLS handshake, wait for the first byte and transfer.
+  # timing_breakdown = false
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -87,6 +130,38 @@ var sampleConfig = `
 
   ## Interface to use when dialing an address
   # interface = "eth0"
+
+  ## Optional regex matches on the headers of the response
+  # [inputs.http_response.response_header_match]
+  #   Content-Type = "^application/json"
+
+  ## Optional assertions on a JSON response body, the path uses the GJSON
+  ## syntax.  The value at the path must equal "equals" or match the "match"
+  ## regex; without either the path must exist.
+  # [[inputs.http_response.json_assertion]]
+  #   path = "status"
+  #   equals = "up"
+
+  ## Optional multi-step check, such as a login flow.  The steps are run in
+  ## order on each interval and share their cookies, the remaining steps are
+  ## skipped when a step fails.  The headers, the response timeout, the TLS
+  ## and redirect settings apply to the steps.  Each step supports the
+  ## response_string_match, response_status_code, response_header_match and
+  ## json_assertion options.
+  # [[inputs.http_response.step]]
+  #   name = "login"
+  #   url = "https://example.org/login"
+  #   method = "POST"
+  #   body = '{"user": "telegraf", "password": "secret"}'
+  #   response_status_code = 200
+  #   [inputs.http_response.step.headers]
+  #     Content-Type = "application/json"
+  # [[inputs.http_response.step]]
+  #   name = "profile"
+  #   url = "https://example.org/profile"
+  #   [[inputs.http_response.step.json_assertion]]
+  #     path = "user.name"
+  #     equals = "telegraf"
 `
 
 // SampleConfig returns the plugin SampleConfig
@@ -171,12 +246,15 @@ func localAddress(interfaceName string) (net.Addr, error) {
 
 func setResult(result_string string, fields map[string]interface{}, tags map[string]string) {
 	result_codes := map[string]int{
-		"success":                  0,
-		"response_string_mismatch": 1,
-		"body_read_error":          2,
-		"connection_failed":        3,
-		"timeout":                  4,
-		"dns_error":                5,
+		"success":                       0,
+		"response_string_mismatch":      1,
+		"body_read_error":               2,
+		"connection_failed":             3,
+		"timeout":                       4,
+		"dns_error":                     5,
+		"response_status_code_mismatch": 6,
+		"response_header_mismatch":      7,
+		"json_assertion_failed":         8,
 	}
 
 	tags["result"] = result_string
@@ -212,38 +290,101 @@ func setError(err error, fields map[string]interface{}, tags map[string]string)
 	return nil
 }
 
+// init compiles the expectations of the step.
+func (s *Step) init() error {
+	if s.Method == "" {
+		s.Method = "GET"
+	}
+
+	if s.ResponseStringMatch != "" {
+		var err error
+		s.compiledStringMatch, err = regexp.Compile(s.ResponseStringMatch)
+		if err != nil {
+			return fmt.Errorf("Failed to compile regular expression %s : %s", s.ResponseStringMatch, err)
+		}
+	}
+
+	s.compiledHeaderMatch = make(map[string]*regexp.Regexp, len(s.ResponseHeaderMatch))
+	for header, match := range s.ResponseHeaderMatch {
+		re, err := regexp.Compile(match)
+		if err != nil {
+			return fmt.Errorf("Failed to compile regular expression %s : %s", match, err)
+		}
+		s.compiledHeaderMatch[header] = re
+	}
+
+	for _, a := range s.JSONAssertions {
+		if a.Path == "" {
+			return errors.New("json_assertion requires a path")
+		}
+		if a.Match != "" {
+			var err error
+			a.compiledMatch, err = regexp.Compile(a.Match)
+			if err != nil {
+				return fmt.Errorf("Failed to compile regular expression %s : %s", a.Match, err)
+			}
+		}
+	}
+	return nil
+}
+
+// check returns whether the assertion holds for the JSON body.
+func (a *JSONAssertion) check(body []byte) bool {
+	result := gjson.GetBytes(body, a.Path)
+	if !result.Exists() {
+		return false
+	}
+
+	switch {
+	case a.compiledMatch != nil:
+		return a.compiledMatch.MatchString(result.String())
+	case a.Equals != "":
+		return result.String() == a.Equals
+	}
+	return true
+}
+
 // HTTPGather gathers all fields and returns any errors it encounters
-func (h *HTTPResponse) httpGather(u string) (map[string]interface{}, map[string]string, error) {
+func (h *HTTPResponse) httpGather(client *http.Client, step *Step) (map[string]interface{}, map[string]string, error) {
 	// Prepare fields and tags
 	fields := make(map[string]interface{})
-	tags := map[string]string{"server": u, "method": h.Method}
+	tags := map[string]string{"server": step.URL, "method": step.Method}
+	if step.Name != "" {
+		tags["step"] = step.Name
+	}
 
 	var body io.Reader
-	if h.Body != "" {
-		body = strings.NewReader(h.Body)
+	if step.Body != "" {
+		body = strings.NewReader(step.Body)
 	}
-	request, err := http.NewRequest(h.Method, u, body)
+	request, err := http.NewRequest(step.Method, step.URL, body)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	for key, val := range h.Headers {
+	for key, val := range step.Headers {
 		request.Header.Add(key, val)
 		if key == "Host" {
 			request.Host = val
 		}
 	}
 
+	var phases *timings
+	if h.TimingBreakdown {
+		phases = &timings{}
+		request = request.WithContext(httptrace.WithClientTrace(request.Context(), phases.trace()))
+	}
+
 	// Start Timer
 	start := time.Now()
-	resp, err := h.client.Do(request)
+	resp, err := client.Do(request)
 	response_time := time.Since(start).Seconds()
 
 	// If an error in returned, it means we are dealing with a network error, as
 	// HTTP error codes do not generate errors in the net/http library
 	if err != nil {
 		// Log error
-		h.Log.Debugf("Network error while polling %s: %s", u, err.Error())
+		h.Log.Debugf("Network error while polling %s: %s", step.URL, err.Error())
 
 		// Get error details
 		netErr := setError(err, fields, tags)
@@ -270,12 +411,26 @@ func (h *HTTPResponse) httpGather(u string) (map[string]interface{}, map[string]
 	tags["status_code"] = strconv.Itoa(resp.StatusCode)
 	fields["http_response_code"] = resp.StatusCode
 
+	// Report the certificate of the chain expiring first
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expiry := resp.TLS.PeerCertificates[0].NotAfter
+		for _, cert := range resp.TLS.PeerCertificates[1:] {
+			if cert.NotAfter.Before(expiry) {
+				expiry = cert.NotAfter
+			}
+		}
+		fields["cert_expiry"] = int64(time.Until(expiry).Seconds())
+	}
+
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if phases != nil {
+		phases.addFields(fields)
+	}
 	if err != nil {
 		h.Log.Debugf("Failed to read body of HTTP Response : %s", err.Error())
 		setResult("body_read_error", fields, tags)
 		fields["content_length"] = len(bodyBytes)
-		if h.ResponseStringMatch != "" {
+		if step.ResponseStringMatch != "" {
 			fields["response_string_match"] = 0
 		}
 		return fields, tags, nil
@@ -283,33 +438,68 @@ func (h *HTTPResponse) httpGather(u string) (map[string]interface{}, map[string]
 
 	fields["content_length"] = len(bodyBytes)
 
+	// The result is the first expectation failing
+	result := "success"
+	fail := func(r string) {
+		if result == "success" {
+			result = r
+		}
+	}
+
+	// Check the status code of the response
+	if step.ResponseStatusCode != 0 {
+		if resp.StatusCode == step.ResponseStatusCode {
+			fields["response_status_code_match"] = 1
+		} else {
+			fields["response_status_code_match"] = 0
+			fail("response_status_code_mismatch")
+		}
+	}
+
+	// Check the headers of the response, a missing header doesn't match
+	if len(step.compiledHeaderMatch) > 0 {
+		fields["response_header_match"] = 1
+		for header, re := range step.compiledHeaderMatch {
+			values, ok := resp.Header[http.CanonicalHeaderKey(header)]
+			if !ok || !re.MatchString(strings.Join(values, ", ")) {
+				fields["response_header_match"] = 0
+				fail("response_header_mismatch")
+				break
+			}
+		}
+	}
+
 	// Check the response for a regex match.
-	if h.ResponseStringMatch != "" {
-		if h.compiledStringMatch.Match(bodyBytes) {
-			setResult("success", fields, tags)
+	if step.compiledStringMatch != nil {
+		if step.compiledStringMatch.Match(bodyBytes) {
 			fields["response_string_match"] = 1
 		} else {
-			setResult("response_string_mismatch", fields, tags)
 			fields["response_string_match"] = 0
+			fail("response_string_mismatch")
 		}
-	} else {
-		setResult("success", fields, tags)
 	}
 
-	return fields, tags, nil
-}
-
-// Gather gets all metric fields and tags and returns any errors it encounters
-func (h *HTTPResponse) Gather(acc telegraf.Accumulator) error {
-	// Compile the body regex if it exist
-	if h.compiledStringMatch == nil {
-		var err error
-		h.compiledStringMatch, err = regexp.Compile(h.ResponseStringMatch)
-		if err != nil {
-			return fmt.Errorf("Failed to compile regular expression %s : %s", h.ResponseStringMatch, err)
+	// Check the JSON assertions, a body which isn't JSON fails all of them
+	if len(step.JSONAssertions) > 0 {
+		failed := 0
+		valid := gjson.ValidBytes(bodyBytes)
+		for _, a := range step.JSONAssertions {
+			if !valid || !a.check(bodyBytes) {
+				failed++
+			}
+		}
+		fields["json_assertions_failed"] = failed
+		if failed > 0 {
+			fail("json_assertion_failed")
 		}
 	}
 
+	setResult(result, fields, tags)
+	return fields, tags, nil
+}
+
+// init validates the configuration and creates a check for each URL.
+func (h *HTTPResponse) init() error {
 	// Set default values
 	if h.ResponseTimeout.Duration < time.Second {
 		h.ResponseTimeout.Duration = time.Second * 5
@@ -319,7 +509,7 @@ func (h *HTTPResponse) Gather(acc telegraf.Accumulator) error {
 		h.Method = "GET"
 	}
 
-	if len(h.URLs) == 0 {
+	if len(h.URLs) == 0 && len(h.Steps) == 0 {
 		if h.Address == "" {
 			h.URLs = []string{"http://localhost"}
 		} else {
@@ -328,32 +518,86 @@ func (h *HTTPResponse) Gather(acc telegraf.Accumulator) error {
 		}
 	}
 
-	if h.client == nil {
-		client, err := h.createHttpClient()
-		if err != nil {
+	var checks []*Step
+	for _, u := range h.URLs {
+		check := &Step{
+			URL:                 u,
+			Method:              h.Method,
+			Body:                h.Body,
+			Headers:             h.Headers,
+			ResponseStringMatch: h.ResponseStringMatch,
+			ResponseStatusCode:  h.ResponseStatusCode,
+			ResponseHeaderMatch: h.ResponseHeaderMatch,
+			JSONAssertions:      h.JSONAssertions,
+		}
+		if err := check.init(); err != nil {
 			return err
 		}
-		h.client = client
+		checks = append(checks, check)
 	}
 
-	for _, u := range h.URLs {
-		addr, err := url.Parse(u)
-		if err != nil {
-			acc.AddError(err)
-			continue
+	for i, step := range h.Steps {
+		if step.URL == "" {
+			return fmt.Errorf("step %d: missing url", i+1)
+		}
+		if step.Name == "" {
+			step.Name = strconv.Itoa(i + 1)
 		}
 
-		if addr.Scheme != "http" && addr.Scheme != "https" {
-			acc.AddError(errors.New("Only http and https are supported"))
-			continue
+		// The headers of the plugin apply to all steps
+		headers := make(map[string]string, len(h.Headers)+len(step.Headers))
+		for k, v := range h.Headers {
+			headers[k] = v
+		}
+		for k, v := range step.Headers {
+			headers[k] = v
 		}
+		step.Headers = headers
 
-		// Prepare data
-		var fields map[string]interface{}
-		var tags map[string]string
+		if err := step.init(); err != nil {
+			return fmt.Errorf("step %q: %v", step.Name, err)
+		}
+	}
+
+	client, err := h.createHttpClient()
+	if err != nil {
+		return err
+	}
+
+	h.checks = checks
+	h.client = client
+	return nil
+}
+
+// validURL checks the scheme of the URL, only http and https are supported
+func validURL(u string) error {
+	addr, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+
+	if addr.Scheme != "http" && addr.Scheme != "https" {
+		return errors.New("Only http and https are supported")
+	}
+	return nil
+}
+
+// Gather gets all metric fields and tags and returns any errors it encounters
+func (h *HTTPResponse) Gather(acc telegraf.Accumulator) error {
+	if h.client == nil {
+		if err := h.init(); err != nil {
+			return err
+		}
+	}
+
+	for _, check := range h.checks {
+		if err := validURL(check.URL); err != nil {
+			acc.AddError(err)
+			continue
+		}
 
 		// Gather data
-		fields, tags, err = h.httpGather(u)
+		fields, tags, err := h.httpGather(h.client, check)
 		if err != nil {
 			acc.AddError(err)
 			continue
@@ -363,9 +607,44 @@ func (h *HTTPResponse) Gather(acc telegraf.Accumulator) error {
 		acc.AddFields("http_response", fields, tags)
 	}
 
+	if len(h.Steps) > 0 {
+		h.gatherSteps(acc)
+	}
+
 	return nil
 }
 
+// gatherSteps runs the steps in order with a new cookie jar, until a step
+// fails.
+func (h *HTTPResponse) gatherSteps(acc telegraf.Accumulator) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	client := *h.client
+	client.Jar = jar
+
+	for _, step := range h.Steps {
+		if err := validURL(step.URL); err != nil {
+			acc.AddError(fmt.Errorf("step %q: %v", step.Name, err))
+			return
+		}
+
+		fields, tags, err := h.httpGather(&client, step)
+		if err != nil {
+			acc.AddError(fmt.Errorf("step %q: %v", step.Name, err))
+			return
+		}
+		acc.AddFields("http_response", fields, tags)
+
+		if tags["result"] != "success" {
+			h.Log.Debugf("Step %q failed with result %s, skipping the remaining steps", step.Name, tags["result"])
+			return
+		}
+	}
+}
+
 func init() {
 	inputs.Add("http_response", func() telegraf.Input {
 		return &HTTPResponse{}
@@ -864,3 +864,295 @@ func TestRedirect(t *testing.T) {
 
 	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
 }
+
+func TestResponseStatusCode(t *testing.T) {
+	mux := setUpTestMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:                testutil.Logger{},
+		URLs:               []string{ts.URL + "/mustbepostmethod"},
+		ResponseStatusCode: http.StatusOK,
+		ResponseTimeout:    internal.Duration{Duration: time.Second * 20},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(&acc))
+
+	expectedFields := map[string]interface{}{
+		"http_response_code":         http.StatusMethodNotAllowed,
+		"response_status_code_match": 0,
+		"result_type":                "response_status_code_mismatch",
+		"result_code":                6,
+	}
+	expectedTags := map[string]interface{}{
+		"status_code": "405",
+		"result":      "response_status_code_mismatch",
+	}
+	checkOutput(t, &acc, expectedFields, expectedTags, nil, nil)
+
+	h = &HTTPResponse{
+		Log:                testutil.Logger{},
+		URLs:               []string{ts.URL + "/good"},
+		ResponseStatusCode: http.StatusOK,
+		ResponseTimeout:    internal.Duration{Duration: time.Second * 20},
+	}
+
+	acc = testutil.Accumulator{}
+	require.NoError(t, h.Gather(&acc))
+
+	expectedFields = map[string]interface{}{
+		"http_response_code":         http.StatusOK,
+		"response_status_code_match": 1,
+		"result_type":                "success",
+		"result_code":                0,
+	}
+	checkOutput(t, &acc, expectedFields, nil, nil, nil)
+}
+
+func TestResponseHeaderMatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		match   map[string]string
+		matched int
+		result  string
+	}{
+		{
+			name:    "match",
+			match:   map[string]string{"content-type": "^application/json"},
+			matched: 1,
+			result:  "success",
+		},
+		{
+			name:    "mismatch",
+			match:   map[string]string{"Content-Type": "^text/html"},
+			matched: 0,
+			result:  "response_header_mismatch",
+		},
+		{
+			name:    "missing header",
+			match:   map[string]string{"Content-Type": ".*", "X-Request-Id": ".*"},
+			matched: 0,
+			result:  "response_header_mismatch",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &HTTPResponse{
+				Log:                 testutil.Logger{},
+				URLs:                []string{ts.URL},
+				ResponseHeaderMatch: tt.match,
+			}
+
+			var acc testutil.Accumulator
+			require.NoError(t, h.Gather(&acc))
+
+			expectedFields := map[string]interface{}{
+				"response_header_match": tt.matched,
+				"result_type":           tt.result,
+			}
+			checkOutput(t, &acc, expectedFields, map[string]interface{}{"result": tt.result}, nil, nil)
+		})
+	}
+}
+
+func TestJSONAssertions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status": "up", "version": "1.14.2", "checks": [{"name": "db", "ok": true}]}`)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		assertions []*JSONAssertion
+		failed     int
+		result     string
+	}{
+		{
+			name: "success",
+			url:  ts.URL,
+			assertions: []*JSONAssertion{
+				{Path: "status", Equals: "up"},
+				{Path: "version", Match: `^1\.14\.`},
+				{Path: "checks.0.ok", Equals: "true"},
+				{Path: "checks.#(name==db)"},
+			},
+			failed: 0,
+			result: "success",
+		},
+		{
+			name: "failed",
+			url:  ts.URL,
+			assertions: []*JSONAssertion{
+				{Path: "status", Equals: "down"},
+				{Path: "version", Match: `^1\.14\.`},
+				{Path: "uptime"},
+			},
+			failed: 2,
+			result: "json_assertion_failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &HTTPResponse{
+				Log:            testutil.Logger{},
+				URLs:           []string{tt.url},
+				JSONAssertions: tt.assertions,
+			}
+
+			var acc testutil.Accumulator
+			require.NoError(t, h.Gather(&acc))
+
+			expectedFields := map[string]interface{}{
+				"json_assertions_failed": tt.failed,
+				"result_type":            tt.result,
+			}
+			checkOutput(t, &acc, expectedFields, map[string]interface{}{"result": tt.result}, nil, nil)
+		})
+	}
+}
+
+func TestJSONAssertionsInvalidBody(t *testing.T) {
+	mux := setUpTestMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:  testutil.Logger{},
+		URLs: []string{ts.URL + "/jsonresponse"},
+		JSONAssertions: []*JSONAssertion{
+			{Path: "service_status", Equals: "up"},
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(&acc))
+
+	expectedFields := map[string]interface{}{
+		"json_assertions_failed": 1,
+		"result_code":            8,
+	}
+	checkOutput(t, &acc, expectedFields, nil, nil, nil)
+}
+
+func TestTimingBreakdown(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "ok")
+	}))
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		URLs:            []string{ts.URL},
+		TimingBreakdown: true,
+	}
+	h.InsecureSkipVerify = true
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(&acc))
+
+	expectedFields := map[string]interface{}{
+		"connect_time":       nil,
+		"tls_handshake_time": nil,
+		"first_byte_time":    nil,
+		"transfer_time":      nil,
+		"cert_expiry":        nil,
+		"result_type":        "success",
+	}
+	// The server listens on an IP address, there is no DNS lookup.
+	absentFields := []string{"dns_lookup_time"}
+	checkOutput(t, &acc, expectedFields, nil, absentFields, nil)
+
+	expiry, ok := acc.Int64Field("http_response", "cert_expiry")
+	require.True(t, ok)
+	require.True(t, expiry > 0)
+}
+
+func TestSteps(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad login", http.StatusBadRequest)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/profile", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil || cookie.Value != "secret" {
+			http.Error(w, "not logged in", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"user": {"name": "telegraf"}}`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:     testutil.Logger{},
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Steps: []*Step{
+			{
+				Name:               "login",
+				URL:                ts.URL + "/login",
+				Method:             "POST",
+				Body:               `{"user": "telegraf"}`,
+				ResponseStatusCode: http.StatusNoContent,
+			},
+			{
+				URL: ts.URL + "/profile",
+				JSONAssertions: []*JSONAssertion{
+					{Path: "user.name", Equals: "telegraf"},
+				},
+			},
+		},
+	}
+
+	// The cookies are shared between the steps, but not between the
+	// gathers.
+	for i := 0; i < 2; i++ {
+		var acc testutil.Accumulator
+		require.NoError(t, h.Gather(&acc))
+
+		metrics := acc.GetTelegrafMetrics()
+		require.Len(t, metrics, 2)
+		require.Equal(t, map[string]string{
+			"server":      ts.URL + "/login",
+			"method":      "POST",
+			"step":        "login",
+			"status_code": "204",
+			"result":      "success",
+		}, metrics[0].Tags())
+		require.Equal(t, map[string]string{
+			"server":      ts.URL + "/profile",
+			"method":      "GET",
+			"step":        "2",
+			"status_code": "200",
+			"result":      "success",
+		}, metrics[1].Tags())
+	}
+
+	// The remaining steps are skipped after a failure.
+	h = &HTTPResponse{
+		Log: testutil.Logger{},
+		Steps: []*Step{
+			{URL: ts.URL + "/login", Method: "POST", ResponseStatusCode: http.StatusNoContent},
+			{URL: ts.URL + "/profile"},
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(&acc))
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 1)
+	require.Equal(t, "response_status_code_mismatch", metrics[0].Tags()["result"])
+}
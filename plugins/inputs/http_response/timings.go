@@ -0,0 +1,75 @@
+package http_response
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// timings records the start and end of the phases of a request.  With
+// redirects the phases of the last request are kept.
+type timings struct {
+	sync.Mutex
+
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	wroteRequest              time.Time
+	firstByte                 time.Time
+}
+
+// set records the current time, the hooks of the trace may be called
+// concurrently.
+func (t *timings) set(field *time.Time) {
+	t.Lock()
+	*field = time.Now()
+	t.Unlock()
+}
+
+func (t *timings) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.set(&t.dnsDone) },
+		// Only the first connection attempt is timed when several
+		// addresses are dialed.
+		ConnectStart: func(string, string) {
+			t.Lock()
+			if t.connectStart.IsZero() || !t.connectDone.IsZero() {
+				t.connectStart, t.connectDone = time.Now(), time.Time{}
+			}
+			t.Unlock()
+		},
+		ConnectDone: func(string, string, error) {
+			t.Lock()
+			if t.connectDone.IsZero() {
+				t.connectDone = time.Now()
+			}
+			t.Unlock()
+		},
+		TLSHandshakeStart:    func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.set(&t.tlsDone) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.set(&t.wroteRequest) },
+		GotFirstResponseByte: func() { t.set(&t.firstByte) },
+	}
+}
+
+// addFields adds the duration of the phases that happened, the transfer ends
+// now.
+func (t *timings) addFields(fields map[string]interface{}) {
+	done := time.Now()
+
+	t.Lock()
+	defer t.Unlock()
+
+	phase := func(name string, start, end time.Time) {
+		if !start.IsZero() && !end.IsZero() && !end.Before(start) {
+			fields[name] = end.Sub(start).Seconds()
+		}
+	}
+	phase("dns_lookup_time", t.dnsStart, t.dnsDone)
+	phase("connect_time", t.connectStart, t.connectDone)
+	phase("tls_handshake_time", t.tlsStart, t.tlsDone)
+	phase("first_byte_time", t.wroteRequest, t.firstByte)
+	phase("transfer_time", t.firstByte, done)
+}
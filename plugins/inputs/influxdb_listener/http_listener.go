@@ -16,6 +16,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/delivery"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/selfstat"
@@ -31,6 +32,9 @@ const (
 	// a single InfluxDB point.
 	// 64 KB
 	DEFAULT_MAX_LINE_SIZE = 64 * 1024
+
+	defaultDeliveryTimeout       = 5 * time.Second
+	defaultMaxUndeliveredMetrics = 10000
 )
 
 type TimeFunc func() time.Time
@@ -49,6 +53,10 @@ type HTTPListener struct {
 	BasicPassword string            `toml:"basic_password"`
 	DatabaseTag   string            `toml:"database_tag"`
 
+	WaitForDelivery       bool              `toml:"wait_for_delivery"`
+	DeliveryTimeout       internal.Duration `toml:"delivery_timeout"`
+	MaxUndeliveredMetrics int               `toml:"max_undelivered_metrics"`
+
 	TimeFunc
 
 	mu sync.Mutex
@@ -60,6 +68,7 @@ type HTTPListener struct {
 	parser  *influx.Parser
 	acc     telegraf.Accumulator
 	pool    *pool
+	tracker *delivery.Tracker
 
 	BytesRecv       selfstat.Stat
 	RequestsServed  selfstat.Stat
@@ -115,6 +124,19 @@ const sampleConfig = `
   ## You probably want to make sure you have TLS configured above for this.
   # basic_username = "foobar"
   # basic_password = "barfoo"
+
+  ## Hold the response to writes until their metrics have been written by the
+  ## outputs.  Writes are answered with 204 once delivered, 500 if an output
+  ## rejected the metrics or 503 if they were not delivered within the
+  ## delivery_timeout.  Writes containing an invalid line are rejected
+  ## entirely.
+  # wait_for_delivery = false
+  # delivery_timeout = "5s"
+
+  ## Maximum metrics that have not been written by an output, writes are
+  ## answered with 429 while the limit is reached.  Defaults to 10000 when
+  ## wait_for_delivery is set, 0 disables the limit.
+  # max_undelivered_metrics = 0
 `
 
 func (h *HTTPListener) SampleConfig() string {
@@ -169,6 +191,16 @@ func (h *HTTPListener) Start(acc telegraf.Accumulator) error {
 	h.acc = acc
 	h.pool = NewPool(200, int(h.MaxLineSize.Size))
 
+	if h.WaitForDelivery && h.MaxUndeliveredMetrics <= 0 {
+		h.MaxUndeliveredMetrics = defaultMaxUndeliveredMetrics
+	}
+	if h.DeliveryTimeout.Duration <= 0 {
+		h.DeliveryTimeout.Duration = defaultDeliveryTimeout
+	}
+	if h.MaxUndeliveredMetrics > 0 {
+		h.tracker = delivery.NewTracker(acc, h.MaxUndeliveredMetrics)
+	}
+
 	tlsConf, err := h.ServerConfig.TLSConfig()
 	if err != nil {
 		return err
@@ -215,6 +247,9 @@ func (h *HTTPListener) Stop() {
 
 	h.listener.Close()
 	h.wg.Wait()
+	if h.tracker != nil {
+		h.tracker.Stop()
+	}
 
 	h.Log.Infof("Stopped HTTP listener service on %s", h.ServiceAddress)
 }
@@ -283,6 +318,9 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 	}
 	body = http.MaxBytesReader(res, body, h.MaxBodySize.Size)
 
+	// When tracking delivery the metrics are added once the whole body is
+	// parsed.
+	var metrics []telegraf.Metric
 	var return400 bool
 	var hangingBytes bool
 	buf := h.pool.get()
@@ -302,7 +340,7 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 			if return400 {
 				badRequest(res, "")
 			} else {
-				res.WriteHeader(http.StatusNoContent)
+				h.respond(res, metrics)
 			}
 			return
 		}
@@ -325,11 +363,12 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 
 		if err == io.ErrUnexpectedEOF {
 			// finished reading the request body
-			err = h.parse(buf[:n+bufStart], now, precision, db)
+			parsed, err := h.parse(buf[:n+bufStart], now, precision, db)
 			if err != nil {
 				h.Log.Debugf("%s: %s", err.Error(), bufStart+n)
 				return400 = true
 			}
+			metrics = h.add(metrics, parsed)
 			if return400 {
 				if err != nil {
 					badRequest(res, err.Error())
@@ -337,7 +376,7 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 					badRequest(res, "")
 				}
 			} else {
-				res.WriteHeader(http.StatusNoContent)
+				h.respond(res, metrics)
 			}
 			return
 		}
@@ -356,10 +395,12 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 			bufStart = 0
 			continue
 		}
-		if err := h.parse(buf[:i+1], now, precision, db); err != nil {
+		parsed, err := h.parse(buf[:i+1], now, precision, db)
+		if err != nil {
 			h.Log.Debug(err.Error())
 			return400 = true
 		}
+		metrics = h.add(metrics, parsed)
 		// rotate the bit remaining after the last newline to the front of the buffer
 		i++ // start copying after the newline
 		bufStart = len(buf) - i
@@ -369,7 +410,7 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func (h *HTTPListener) parse(b []byte, t time.Time, precision, db string) error {
+func (h *HTTPListener) parse(b []byte, t time.Time, precision, db string) ([]telegraf.Metric, error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -377,7 +418,7 @@ func (h *HTTPListener) parse(b []byte, t time.Time, precision, db string) error
 	h.handler.SetTimeFunc(func() time.Time { return t })
 	metrics, err := h.parser.Parse(b)
 	if err != nil {
-		return fmt.Errorf("unable to parse: %s", err.Error())
+		return nil, fmt.Errorf("unable to parse: %s", err.Error())
 	}
 
 	for _, m := range metrics {
@@ -388,10 +429,53 @@ func (h *HTTPListener) parse(b []byte, t time.Time, precision, db string) error
 		if h.DatabaseTag != "" && db != "" {
 			m.AddTag(h.DatabaseTag, db)
 		}
+	}
+
+	return metrics, nil
+}
+
+// add adds the parsed metrics to the accumulator, or when tracking delivery
+// appends them to the metrics of the request.
+func (h *HTTPListener) add(metrics []telegraf.Metric, parsed []telegraf.Metric) []telegraf.Metric {
+	if h.tracker != nil {
+		return append(metrics, parsed...)
+	}
+
+	for _, m := range parsed {
 		h.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
 	}
+	return metrics
+}
 
-	return nil
+// respond writes the response to a successful write, first adding the
+// metrics for delivery when tracking is enabled.
+func (h *HTTPListener) respond(res http.ResponseWriter, metrics []telegraf.Metric) {
+	if h.tracker == nil {
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	done, err := h.tracker.Add(metrics)
+	if err != nil {
+		writeError(res, http.StatusTooManyRequests, err.Error())
+		return
+	}
+
+	if !h.WaitForDelivery {
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	select {
+	case delivered := <-done:
+		if !delivered {
+			writeError(res, http.StatusInternalServerError, "metrics were rejected by an output")
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	case <-time.After(h.DeliveryTimeout.Duration):
+		writeError(res, http.StatusServiceUnavailable, "timeout waiting for metrics to be delivered")
+	}
 }
 
 func tooLarge(res http.ResponseWriter) {
@@ -403,13 +487,17 @@ func tooLarge(res http.ResponseWriter) {
 }
 
 func badRequest(res http.ResponseWriter, errString string) {
-	res.Header().Set("Content-Type", "application/json")
-	res.Header().Set("X-Influxdb-Version", "1.0")
 	if errString == "" {
 		errString = "http: bad request"
 	}
+	writeError(res, http.StatusBadRequest, errString)
+}
+
+func writeError(res http.ResponseWriter, code int, errString string) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("X-Influxdb-Version", "1.0")
 	res.Header().Set("X-Influxdb-Error", errString)
-	res.WriteHeader(http.StatusBadRequest)
+	res.WriteHeader(code)
 	res.Write([]byte(fmt.Sprintf(`{"error":%q}`, errString)))
 }
 
@@ -0,0 +1,353 @@
+package influxdb_v2_listener
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	// defaultMaxBodySize is the default maximum request body size, in bytes.
+	// if the request body is over this size, we will return an HTTP 413 error.
+	defaultMaxBodySize = 32 * 1024 * 1024
+)
+
+type TimeFunc func() time.Time
+
+type InfluxDBV2Listener struct {
+	ServiceAddress string `toml:"service_address"`
+	tlsint.ServerConfig
+
+	ReadTimeout  internal.Duration `toml:"read_timeout"`
+	WriteTimeout internal.Duration `toml:"write_timeout"`
+	MaxBodySize  internal.Size     `toml:"max_body_size"`
+	Token        string            `toml:"token"`
+	BucketTag    string            `toml:"bucket_tag"`
+
+	timeFunc  TimeFunc
+	startTime time.Time
+
+	server   http.Server
+	listener net.Listener
+	acc      telegraf.Accumulator
+
+	bytesRecv       selfstat.Stat
+	requestsServed  selfstat.Stat
+	writesServed    selfstat.Stat
+	readysServed    selfstat.Stat
+	healthsServed   selfstat.Stat
+	requestsRecv    selfstat.Stat
+	notFoundsServed selfstat.Stat
+	authFailures    selfstat.Stat
+
+	Log telegraf.Logger `toml:"-"`
+
+	mux *http.ServeMux
+}
+
+const sampleConfig = `
+  ## Address and port to host InfluxDB listener on
+  ## (Double check the port. Could be 9999 if using OSS Beta)
+  service_address = ":8086"
+
+  ## Maximum allowed HTTP request body size in bytes.
+  ## 0 means to use the default of 32MiB.
+  # max_body_size = "32MiB"
+
+  ## Maximum duration before timing out read of the request
+  # read_timeout = "10s"
+  ## Maximum duration before timing out write of the response
+  # write_timeout = "10s"
+
+  ## Optional tag to determine the bucket.
+  ## If the write has a bucket in the query string then it will be kept in this tag name.
+  ## This tag can be used in downstream outputs.
+  ## The default value of nothing means it will be off and the bucket will not be recorded.
+  # bucket_tag = ""
+
+  ## Set one or more allowed client CA certificate file names to
+  ## enable mutually authenticated TLS connections
+  # tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
+
+  ## Add service certificate and key
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+
+  ## Optional token to accept for HTTP authentication.
+  ## You probably want to make sure you have TLS configured above for this.
+  # token = "some-long-shared-secret-token"
+`
+
+func (h *InfluxDBV2Listener) SampleConfig() string {
+	return sampleConfig
+}
+
+func (h *InfluxDBV2Listener) Description() string {
+	return "Accept metrics over InfluxDB 2.x HTTP API"
+}
+
+func (h *InfluxDBV2Listener) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+func (h *InfluxDBV2Listener) routes() {
+	authHandler := internal.TokenAuthHandler(h.Token, h.authFailed)
+
+	h.mux.Handle("/api/v2/write", authHandler(h.handleWrite()))
+	h.mux.Handle("/api/v2/ready", h.handleReady())
+	h.mux.Handle("/health", h.handleHealth())
+	h.mux.Handle("/", authHandler(h.handleDefault()))
+}
+
+func (h *InfluxDBV2Listener) Init() error {
+	tags := map[string]string{
+		"address": h.ServiceAddress,
+	}
+	h.bytesRecv = selfstat.Register("influxdb_v2_listener", "bytes_received", tags)
+	h.requestsServed = selfstat.Register("influxdb_v2_listener", "requests_served", tags)
+	h.writesServed = selfstat.Register("influxdb_v2_listener", "writes_served", tags)
+	h.readysServed = selfstat.Register("influxdb_v2_listener", "readys_served", tags)
+	h.healthsServed = selfstat.Register("influxdb_v2_listener", "healths_served", tags)
+	h.requestsRecv = selfstat.Register("influxdb_v2_listener", "requests_received", tags)
+	h.notFoundsServed = selfstat.Register("influxdb_v2_listener", "not_founds_served", tags)
+	h.authFailures = selfstat.Register("influxdb_v2_listener", "auth_failures", tags)
+	h.mux = http.NewServeMux()
+	h.routes()
+
+	if h.MaxBodySize.Size == 0 {
+		h.MaxBodySize.Size = defaultMaxBodySize
+	}
+
+	return nil
+}
+
+// Start starts the InfluxDB listener service.
+func (h *InfluxDBV2Listener) Start(acc telegraf.Accumulator) error {
+	h.acc = acc
+
+	tlsConf, err := h.ServerConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	if h.ReadTimeout.Duration < time.Second {
+		h.ReadTimeout.Duration = time.Second * 10
+	}
+	if h.WriteTimeout.Duration < time.Second {
+		h.WriteTimeout.Duration = time.Second * 10
+	}
+
+	h.server = http.Server{
+		Addr:         h.ServiceAddress,
+		Handler:      h,
+		ReadTimeout:  h.ReadTimeout.Duration,
+		WriteTimeout: h.WriteTimeout.Duration,
+		TLSConfig:    tlsConf,
+	}
+
+	var listener net.Listener
+	if tlsConf != nil {
+		listener, err = tls.Listen("tcp", h.ServiceAddress, tlsConf)
+	} else {
+		listener, err = net.Listen("tcp", h.ServiceAddress)
+	}
+	if err != nil {
+		return err
+	}
+	h.listener = listener
+	h.startTime = h.timeFunc()
+
+	go func() {
+		err := h.server.Serve(listener)
+		if err != http.ErrServerClosed {
+			h.Log.Errorf("Error serving HTTP on %s: %v", h.ServiceAddress, err)
+		}
+	}()
+
+	h.Log.Infof("Started HTTP listener service on %s", h.ServiceAddress)
+
+	return nil
+}
+
+// Stop cleans up all resources
+func (h *InfluxDBV2Listener) Stop() {
+	err := h.server.Shutdown(context.Background())
+	if err != nil {
+		h.Log.Errorf("Error shutting down HTTP server: %v", err)
+	}
+}
+
+func (h *InfluxDBV2Listener) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	h.requestsRecv.Incr(1)
+	h.mux.ServeHTTP(res, req)
+	h.requestsServed.Incr(1)
+}
+
+func (h *InfluxDBV2Listener) authFailed(res http.ResponseWriter, code int) {
+	h.authFailures.Incr(1)
+	writeError(res, code, "unauthorized", "unauthorized access")
+}
+
+func (h *InfluxDBV2Listener) handleReady() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		defer h.readysServed.Incr(1)
+
+		// respond to ready requests
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
+		b, _ := json.Marshal(map[string]string{
+			"started": h.startTime.Format(time.RFC3339Nano),
+			"status":  "ready",
+			"up":      h.timeFunc().Sub(h.startTime).String()})
+		res.Write(b)
+	}
+}
+
+func (h *InfluxDBV2Listener) handleHealth() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		defer h.healthsServed.Incr(1)
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
+		b, _ := json.Marshal(map[string]string{
+			"name":    "telegraf",
+			"message": "ready for writes",
+			"status":  "pass",
+			"version": internal.Version()})
+		res.Write(b)
+	}
+}
+
+func (h *InfluxDBV2Listener) handleDefault() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		defer h.notFoundsServed.Incr(1)
+		http.NotFound(res, req)
+	}
+}
+
+func (h *InfluxDBV2Listener) handleWrite() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		defer h.writesServed.Incr(1)
+
+		if req.Method != http.MethodPost {
+			writeError(res, http.StatusMethodNotAllowed, "method not allowed",
+				fmt.Sprintf("method %s is not allowed", req.Method))
+			return
+		}
+
+		// Check that the content length is not too large for us to handle.
+		if req.ContentLength > h.MaxBodySize.Size {
+			writeError(res, http.StatusRequestEntityTooLarge, "request too large",
+				fmt.Sprintf("request body exceeds the maximum of %d bytes", h.MaxBodySize.Size))
+			return
+		}
+
+		bucket := req.URL.Query().Get("bucket")
+
+		body := req.Body
+		// Handle gzip request bodies
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			var err error
+			body, err = gzip.NewReader(body)
+			if err != nil {
+				h.Log.Debugf("Error decompressing request body: %v", err.Error())
+				writeError(res, http.StatusBadRequest, "invalid", err.Error())
+				return
+			}
+			defer body.Close()
+		}
+
+		// The limit applies to the decompressed body.
+		bytes, err := ioutil.ReadAll(io.LimitReader(body, h.MaxBodySize.Size+1))
+		if err != nil {
+			h.Log.Debugf("Error reading the request body: %v", err.Error())
+			writeError(res, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+		if int64(len(bytes)) > h.MaxBodySize.Size {
+			writeError(res, http.StatusRequestEntityTooLarge, "request too large",
+				fmt.Sprintf("request body exceeds the maximum of %d bytes", h.MaxBodySize.Size))
+			return
+		}
+		h.bytesRecv.Incr(int64(len(bytes)))
+
+		precision, err := getPrecisionMultiplier(req.URL.Query().Get("precision"))
+		if err != nil {
+			writeError(res, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+
+		now := h.timeFunc()
+		metricHandler := influx.NewMetricHandler()
+		metricHandler.SetTimePrecision(precision)
+		metricHandler.SetTimeFunc(func() time.Time { return now })
+		parser := influx.NewParser(metricHandler)
+
+		metrics, err := parser.Parse(bytes)
+		if err != nil {
+			h.Log.Debugf("Error parsing the request body: %v", err.Error())
+			writeError(res, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+
+		for _, m := range metrics {
+			// Handle bucket_tag override
+			if h.BucketTag != "" && bucket != "" {
+				m.AddTag(h.BucketTag, bucket)
+			}
+
+			h.acc.AddMetric(m)
+		}
+
+		// http request success
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeError writes an error in the format of the InfluxDB 2.x API.
+func writeError(res http.ResponseWriter, code int, errorCode string, message string) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("X-Platform-Error-Code", errorCode)
+	res.WriteHeader(code)
+	b, _ := json.Marshal(map[string]string{
+		"code":    errorCode,
+		"message": message})
+	res.Write(b)
+}
+
+func getPrecisionMultiplier(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ns":
+		return time.Nanosecond, nil
+	case "us":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	}
+	return 0, fmt.Errorf("invalid precision %q", precision)
+}
+
+func init() {
+	inputs.Add("influxdb_v2_listener", func() telegraf.Input {
+		return &InfluxDBV2Listener{
+			ServiceAddress: ":8086",
+			timeFunc:       time.Now,
+		}
+	})
+}
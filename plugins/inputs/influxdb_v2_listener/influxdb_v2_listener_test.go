@@ -0,0 +1,230 @@
+package influxdb_v2_listener
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testMsg = "cpu_load_short,host=server01 value=12.0 1422568543702900257\n"
+
+	testMsgs = `cpu_load_short,host=server02 value=12.0 1422568543702900257
+cpu_load_short,host=server03 value=12.0 1422568543702900257
+cpu_load_short,host=server04 value=12.0 1422568543702900257
+`
+	badMsg = "blahblahblah: 42\n"
+
+	token = "super-secure-token"
+)
+
+var (
+	pki = testutil.NewPKI("../../../testutil/pki")
+)
+
+func newTestListener() *InfluxDBV2Listener {
+	listener := &InfluxDBV2Listener{
+		Log:            testutil.Logger{},
+		ServiceAddress: "localhost:0",
+		BucketTag:      "bucket",
+		timeFunc:       time.Now,
+	}
+	return listener
+}
+
+func start(t *testing.T, listener *InfluxDBV2Listener, acc telegraf.Accumulator) {
+	require.NoError(t, listener.Init())
+	require.NoError(t, listener.Start(acc))
+}
+
+func createURL(listener *InfluxDBV2Listener, scheme string, path string, rawquery string) string {
+	return fmt.Sprintf("%s://%s%s?%s", scheme, listener.listener.Addr(), path, rawquery)
+}
+
+func TestWrite(t *testing.T) {
+	listener := newTestListener()
+	acc := &testutil.Accumulator{}
+	start(t, listener, acc)
+	defer listener.Stop()
+
+	resp, err := http.Post(createURL(listener, "http", "/api/v2/write", "org=myorg&bucket=mybucket"), "", bytes.NewBuffer([]byte(testMsgs)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 204, resp.StatusCode)
+
+	acc.Wait(3)
+	for _, host := range []string{"server02", "server03", "server04"} {
+		acc.AssertContainsTaggedFields(t, "cpu_load_short",
+			map[string]interface{}{"value": float64(12)},
+			map[string]string{"host": host, "bucket": "mybucket"},
+		)
+	}
+}
+
+func TestWriteGzip(t *testing.T) {
+	listener := newTestListener()
+	acc := &testutil.Accumulator{}
+	start(t, listener, acc)
+	defer listener.Stop()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(testMsg))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	req, err := http.NewRequest("POST", createURL(listener, "http", "/api/v2/write", "bucket=mybucket"), &buf)
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 204, resp.StatusCode)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "cpu_load_short",
+		map[string]interface{}{"value": float64(12)},
+		map[string]string{"host": "server01", "bucket": "mybucket"},
+	)
+}
+
+func TestWritePrecision(t *testing.T) {
+	listener := newTestListener()
+	acc := &testutil.Accumulator{}
+	start(t, listener, acc)
+	defer listener.Stop()
+
+	msg := "xyzzy value=42 1422568543\n"
+	resp, err := http.Post(createURL(listener, "http", "/api/v2/write", "bucket=mybucket&precision=s"), "", bytes.NewBuffer([]byte(msg)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 204, resp.StatusCode)
+
+	acc.Wait(1)
+	require.Equal(t, time.Unix(1422568543, 0), acc.Metrics[0].Time)
+
+	resp, err = http.Post(createURL(listener, "http", "/api/v2/write", "bucket=mybucket&precision=h"), "", bytes.NewBuffer([]byte(msg)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 400, resp.StatusCode)
+}
+
+func TestWriteBadRequests(t *testing.T) {
+	listener := newTestListener()
+	acc := &testutil.Accumulator{}
+	start(t, listener, acc)
+	defer listener.Stop()
+
+	resp, err := http.Post(createURL(listener, "http", "/api/v2/write", ""), "", bytes.NewBuffer([]byte(badMsg)))
+	require.NoError(t, err)
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	require.EqualValues(t, 400, resp.StatusCode)
+	require.Equal(t, "invalid", body["code"])
+
+	resp, err = http.Get(createURL(listener, "http", "/api/v2/write", ""))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 405, resp.StatusCode)
+}
+
+func TestWriteMaxBodySize(t *testing.T) {
+	listener := newTestListener()
+	listener.MaxBodySize = internal.Size{Size: 16}
+	acc := &testutil.Accumulator{}
+	start(t, listener, acc)
+	defer listener.Stop()
+
+	resp, err := http.Post(createURL(listener, "http", "/api/v2/write", ""), "", bytes.NewBuffer([]byte(testMsgs)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 413, resp.StatusCode)
+}
+
+func TestWriteToken(t *testing.T) {
+	listener := newTestListener()
+	listener.Token = token
+	acc := &testutil.Accumulator{}
+	start(t, listener, acc)
+	defer listener.Stop()
+
+	resp, err := http.Post(createURL(listener, "http", "/api/v2/write", "bucket=mybucket"), "", bytes.NewBuffer([]byte(testMsg)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 401, resp.StatusCode)
+
+	req, err := http.NewRequest("POST", createURL(listener, "http", "/api/v2/write", "bucket=mybucket"), bytes.NewBuffer([]byte(testMsg)))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Token "+token)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 204, resp.StatusCode)
+
+	// The ready and health endpoints do not require the token.
+	resp, err = http.Get(createURL(listener, "http", "/api/v2/ready", ""))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 200, resp.StatusCode)
+}
+
+func TestWriteHTTPS(t *testing.T) {
+	listener := newTestListener()
+	listener.ServerConfig = *pki.TLSServerConfig()
+	acc := &testutil.Accumulator{}
+	start(t, listener, acc)
+	defer listener.Stop()
+
+	tlsConfig, err := pki.TLSClientConfig().TLSConfig()
+	require.NoError(t, err)
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	resp, err := client.Post(createURL(listener, "https", "/api/v2/write", "bucket=mybucket"), "", bytes.NewBuffer([]byte(testMsg)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 204, resp.StatusCode)
+}
+
+func TestReadyAndHealth(t *testing.T) {
+	listener := newTestListener()
+	acc := &testutil.Accumulator{}
+	start(t, listener, acc)
+	defer listener.Stop()
+
+	resp, err := http.Get(createURL(listener, "http", "/api/v2/ready", ""))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 200, resp.StatusCode)
+	require.Contains(t, string(body), `"status":"ready"`)
+
+	resp, err = http.Get(createURL(listener, "http", "/health", ""))
+	require.NoError(t, err)
+	body, err = ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 200, resp.StatusCode)
+	require.Contains(t, string(body), `"status":"pass"`)
+
+	resp, err = http.Get(createURL(listener, "http", "/query", ""))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 404, resp.StatusCode)
+}
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/psi"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -23,16 +25,25 @@ var (
 	boot_time        = []byte("btime")
 )
 
+const sampleConfig = `
+  ## Additional statistics to collect
+  ##   psi - Pressure Stall Information of /proc/pressure
+  # collect = []
+`
+
 type Kernel struct {
+	Collect []string `toml:"collect"`
+
 	statFile        string
 	entropyStatFile string
+	pressureDir     string
 }
 
 func (k *Kernel) Description() string {
 	return "Get kernel statistics from /proc/stat"
 }
 
-func (k *Kernel) SampleConfig() string { return "" }
+func (k *Kernel) SampleConfig() string { return sampleConfig }
 
 func (k *Kernel) Gather(acc telegraf.Accumulator) error {
 
@@ -99,6 +110,38 @@ func (k *Kernel) Gather(acc telegraf.Accumulator) error {
 
 	acc.AddCounter("kernel", fields, map[string]string{})
 
+	for _, c := range k.Collect {
+		if c == "psi" {
+			if err := k.gatherPressure(acc); err != nil {
+				acc.AddError(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// gatherPressure reads the Pressure Stall Information of the resources.
+func (k *Kernel) gatherPressure(acc telegraf.Accumulator) error {
+	files, err := filepath.Glob(filepath.Join(k.pressureDir, "*"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("kernel: %s does not exist, pressure stall information requires Linux 4.20 or later", k.pressureDir)
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		tags := map[string]string{"resource": filepath.Base(file)}
+		if err := psi.Gather(acc, "pressure", tags, data); err != nil {
+			return fmt.Errorf("kernel: %s: %v", file, err)
+		}
+	}
 	return nil
 }
 
@@ -119,9 +162,11 @@ func (k *Kernel) getProcStat() ([]byte, error) {
 
 func init() {
 	inputs.Add("kernel", func() telegraf.Input {
+		hostProc := psi.HostProc()
 		return &Kernel{
-			statFile:        "/proc/stat",
-			entropyStatFile: "/proc/sys/kernel/random/entropy_avail",
+			statFile:        filepath.Join(hostProc, "stat"),
+			entropyStatFile: filepath.Join(hostProc, "sys/kernel/random/entropy_avail"),
+			pressureDir:     filepath.Join(hostProc, "pressure"),
 		}
 	})
 }
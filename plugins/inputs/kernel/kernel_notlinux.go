@@ -7,14 +7,21 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
+const sampleConfig = `
+  ## Additional statistics to collect
+  ##   psi - Pressure Stall Information of /proc/pressure
+  # collect = []
+`
+
 type Kernel struct {
+	Collect []string `toml:"collect"`
 }
 
 func (k *Kernel) Description() string {
 	return "Get kernel statistics from /proc/stat"
 }
 
-func (k *Kernel) SampleConfig() string { return "" }
+func (k *Kernel) SampleConfig() string { return sampleConfig }
 
 func (k *Kernel) Gather(acc telegraf.Accumulator) error {
 	return nil
@@ -6,10 +6,14 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFullProcFile(t *testing.T) {
@@ -108,6 +112,71 @@ func TestNoProcFile(t *testing.T) {
 	assert.Contains(t, err.Error(), "does not exist")
 }
 
+func TestPressure(t *testing.T) {
+	os.Setenv("HOST_PROC", "testdata/proc")
+	defer os.Unsetenv("HOST_PROC")
+
+	k := inputs.Inputs["kernel"]().(*Kernel)
+	k.Collect = []string{"psi"}
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, k.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("kernel",
+			map[string]string{},
+			map[string]interface{}{
+				"boot_time":        int64(1457505775),
+				"context_switches": int64(2626618),
+				"disk_pages_in":    int64(5741),
+				"disk_pages_out":   int64(1808),
+				"interrupts":       int64(1472736),
+				"processes_forked": int64(10673),
+				"entropy_avail":    int64(1024),
+			},
+			time.Unix(0, 0),
+			telegraf.Counter),
+		testutil.MustMetric("pressure",
+			map[string]string{"resource": "cpu", "type": "some"},
+			map[string]interface{}{"avg10": 0.12, "avg60": 0.34, "avg300": 0.56, "total": int64(789)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("pressure",
+			map[string]string{"resource": "io", "type": "some"},
+			map[string]interface{}{"avg10": 1.0, "avg60": 2.0, "avg300": 3.0, "total": int64(400)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("pressure",
+			map[string]string{"resource": "io", "type": "full"},
+			map[string]interface{}{"avg10": 0.5, "avg60": 1.0, "avg300": 1.5, "total": int64(200)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("pressure",
+			map[string]string{"resource": "memory", "type": "some"},
+			map[string]interface{}{"avg10": 0.0, "avg60": 0.0, "avg300": 0.0, "total": int64(100)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("pressure",
+			map[string]string{"resource": "memory", "type": "full"},
+			map[string]interface{}{"avg10": 0.0, "avg60": 0.0, "avg300": 0.0, "total": int64(50)},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestPressureNotAvailable(t *testing.T) {
+	k := Kernel{
+		Collect:         []string{"psi"},
+		statFile:        "testdata/proc/stat",
+		entropyStatFile: "testdata/proc/sys/kernel/random/entropy_avail",
+		pressureDir:     "testdata/proc/missing",
+	}
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, k.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "does not exist")
+	require.True(t, acc.HasMeasurement("kernel"))
+	require.False(t, acc.HasMeasurement("pressure"))
+}
+
 const statFile_Full = `cpu  6796 252 5655 10444977 175 0 101 0 0 0
 cpu0 6796 252 5655 10444977 175 0 101 0 0 0
 intr 1472736 57 10 0 0 0 0 0 0 0 0 0 0 156 0 0 0 0 0 0 111551 42541 12356 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
@@ -0,0 +1,193 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// exceptionError is an exception response of a slave.
+type exceptionError struct {
+	code byte
+}
+
+var exceptionNames = map[byte]string{
+	1:  "illegal function",
+	2:  "illegal data address",
+	3:  "illegal data value",
+	4:  "slave device failure",
+	5:  "acknowledge",
+	6:  "slave device busy",
+	8:  "memory parity error",
+	10: "gateway path unavailable",
+	11: "gateway target device failed to respond",
+}
+
+func (e *exceptionError) Error() string {
+	if name, ok := exceptionNames[e.code]; ok {
+		return fmt.Sprintf("exception %d (%s)", e.code, name)
+	}
+	return fmt.Sprintf("exception %d", e.code)
+}
+
+// client sends the requests to the slaves over a TCP connection, either
+// framed with the MBAP header of Modbus TCP or as RTU frames.
+type client struct {
+	conn    net.Conn
+	rtu     bool
+	timeout time.Duration
+
+	transactionID uint16
+}
+
+func dial(address string, rtu bool, timeout time.Duration) (*client, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &client{conn: conn, rtu: rtu, timeout: timeout}, nil
+}
+
+func (c *client) Close() error {
+	return c.conn.Close()
+}
+
+// read reads count bits or registers of a slave, starting at address, and
+// returns the data of the response.
+func (c *client) read(slave byte, kind *registerType, address, count int) ([]byte, error) {
+	pdu := make([]byte, 5)
+	pdu[0] = kind.function
+	binary.BigEndian.PutUint16(pdu[1:], uint16(address))
+	binary.BigEndian.PutUint16(pdu[3:], uint16(count))
+
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+
+	length := count * 2
+	if kind.bits {
+		length = (count + 7) / 8
+	}
+
+	var resp []byte
+	var err error
+	if c.rtu {
+		resp, err = c.sendRTU(slave, pdu, length)
+	} else {
+		resp, err = c.sendTCP(slave, pdu)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resp[0] == kind.function|0x80 {
+		return nil, &exceptionError{code: resp[1]}
+	}
+	if resp[0] != kind.function {
+		return nil, fmt.Errorf("unexpected function %d in response", resp[0])
+	}
+
+	if int(resp[1]) != length || len(resp) != length+2 {
+		return nil, fmt.Errorf("unexpected length %d of response", resp[1])
+	}
+	return resp[2:], nil
+}
+
+// sendTCP sends a request with the MBAP header and returns the PDU of the
+// response.
+func (c *client) sendTCP(slave byte, pdu []byte) ([]byte, error) {
+	c.transactionID++
+
+	frame := make([]byte, 7+len(pdu))
+	binary.BigEndian.PutUint16(frame[0:], c.transactionID)
+	binary.BigEndian.PutUint16(frame[2:], 0) // protocol
+	binary.BigEndian.PutUint16(frame[4:], uint16(len(pdu)+1))
+	frame[6] = slave
+	copy(frame[7:], pdu)
+	if _, err := c.conn.Write(frame); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return nil, err
+	}
+	if id := binary.BigEndian.Uint16(header[0:]); id != c.transactionID {
+		return nil, fmt.Errorf("unexpected transaction id %d, expected %d", id, c.transactionID)
+	}
+	if protocol := binary.BigEndian.Uint16(header[2:]); protocol != 0 {
+		return nil, fmt.Errorf("unexpected protocol %d", protocol)
+	}
+	length := int(binary.BigEndian.Uint16(header[4:]))
+	if length < 3 || length > 254 {
+		return nil, fmt.Errorf("invalid length %d", length)
+	}
+	if header[6] != slave {
+		return nil, fmt.Errorf("unexpected unit id %d", header[6])
+	}
+
+	resp := make([]byte, length-1)
+	if _, err := io.ReadFull(c.conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// sendRTU sends a request as an RTU frame and returns the PDU of the
+// response.  The length of the response is known from its byte count, as
+// the RTU frames are delimited by silences on serial lines only, and the
+// byte count must be the expected length of the data.
+func (c *client) sendRTU(slave byte, pdu []byte, length int) ([]byte, error) {
+	frame := make([]byte, 0, len(pdu)+3)
+	frame = append(frame, slave)
+	frame = append(frame, pdu...)
+	crc := crc16(frame)
+	frame = append(frame, byte(crc), byte(crc>>8))
+	if _, err := c.conn.Write(frame); err != nil {
+		return nil, err
+	}
+
+	// Slave address, function and byte count or exception code
+	resp := make([]byte, 3, 3+length+2)
+	if _, err := io.ReadFull(c.conn, resp); err != nil {
+		return nil, err
+	}
+	remaining := 2 // CRC
+	if resp[1]&0x80 == 0 {
+		if int(resp[2]) != length {
+			return nil, fmt.Errorf("unexpected length %d of response", resp[2])
+		}
+		remaining += length
+	}
+	resp = resp[:3+remaining]
+	if _, err := io.ReadFull(c.conn, resp[3:]); err != nil {
+		return nil, err
+	}
+
+	n := len(resp) - 2
+	if crc := crc16(resp[:n]); resp[n] != byte(crc) || resp[n+1] != byte(crc>>8) {
+		return nil, fmt.Errorf("invalid CRC")
+	}
+	if resp[0] != slave {
+		return nil, fmt.Errorf("unexpected slave address %d", resp[0])
+	}
+	return resp[1:n], nil
+}
+
+// crc16 computes the CRC of the RTU frames, sent low byte first.
+func crc16(data []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xa001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
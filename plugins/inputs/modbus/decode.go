@@ -0,0 +1,62 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// registers returns the bytes of a value held by one or more registers in
+// big endian order, from the byte and word order of the slave.
+func (s *Slave) registers(data []byte) []byte {
+	b := make([]byte, len(data))
+	copy(b, data)
+
+	if s.WordOrder == "little" {
+		for i, j := 0, len(b)-2; i < j; i, j = i+2, j-2 {
+			b[i], b[i+1], b[j], b[j+1] = b[j], b[j+1], b[i], b[i+1]
+		}
+	}
+	if s.ByteOrder == "little" {
+		for i := 0; i+1 < len(b); i += 2 {
+			b[i], b[i+1] = b[i+1], b[i]
+		}
+	}
+	return b
+}
+
+// convert decodes a big endian value of the field type, and applies the
+// scale.
+func (f *Field) convert(b []byte) interface{} {
+	var v interface{}
+	switch f.Type {
+	case "INT16":
+		v = int64(int16(binary.BigEndian.Uint16(b)))
+	case "UINT16":
+		v = uint64(binary.BigEndian.Uint16(b))
+	case "INT32":
+		v = int64(int32(binary.BigEndian.Uint32(b)))
+	case "UINT32":
+		v = uint64(binary.BigEndian.Uint32(b))
+	case "INT64":
+		v = int64(binary.BigEndian.Uint64(b))
+	case "UINT64":
+		v = binary.BigEndian.Uint64(b)
+	case "FLOAT32":
+		v = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case "FLOAT64":
+		v = math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+
+	if f.Scale == 0 || f.Scale == 1 {
+		return v
+	}
+	switch v := v.(type) {
+	case int64:
+		return float64(v) * f.Scale
+	case uint64:
+		return float64(v) * f.Scale
+	case float64:
+		return v * f.Scale
+	}
+	return v
+}
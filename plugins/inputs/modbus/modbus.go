@@ -0,0 +1,354 @@
+package modbus
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type Modbus struct {
+	Name             string            `toml:"name"`
+	Controller       string            `toml:"controller"`
+	TransmissionMode string            `toml:"transmission_mode"`
+	Timeout          internal.Duration `toml:"timeout"`
+	Slaves           []*Slave          `toml:"slave"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	address string
+	client  *client
+}
+
+// Slave is a device behind the controller, with the fields to read from
+// each of its register types.
+type Slave struct {
+	SlaveID          int               `toml:"slave_id"`
+	ByteOrder        string            `toml:"byte_order"`
+	WordOrder        string            `toml:"word_order"`
+	Tags             map[string]string `toml:"tags"`
+	Coils            []*Field          `toml:"coils"`
+	DiscreteInputs   []*Field          `toml:"discrete_inputs"`
+	HoldingRegisters []*Field          `toml:"holding_registers"`
+	InputRegisters   []*Field          `toml:"input_registers"`
+
+	requests []*request
+}
+
+type Field struct {
+	Name    string  `toml:"name"`
+	Address int     `toml:"address"`
+	Type    string  `toml:"type"`
+	Scale   float64 `toml:"scale"`
+
+	// length of the field in bits or registers
+	length int
+}
+
+// registerType is one of the four Modbus data tables.
+type registerType struct {
+	name     string
+	function byte
+	bits     bool
+	// maximum number of bits or registers of a single request
+	max int
+}
+
+var (
+	coils            = &registerType{name: "coil", function: 1, bits: true, max: 2000}
+	discreteInputs   = &registerType{name: "discrete_input", function: 2, bits: true, max: 2000}
+	holdingRegisters = &registerType{name: "holding_register", function: 3, max: 125}
+	inputRegisters   = &registerType{name: "input_register", function: 4, max: 125}
+)
+
+// request reads a range of contiguous bits or registers holding one or
+// more fields.
+type request struct {
+	kind    *registerType
+	address int
+	count   int
+	fields  []*Field
+}
+
+// registerLengths are the number of registers of the data types.
+var registerLengths = map[string]int{
+	"INT16":   1,
+	"UINT16":  1,
+	"INT32":   2,
+	"UINT32":  2,
+	"INT64":   4,
+	"UINT64":  4,
+	"FLOAT32": 2,
+	"FLOAT64": 4,
+}
+
+const sampleConfig = `
+  ## Name of the device, added as the name tag
+  name = "device"
+
+  ## Address of the Modbus TCP server or of the serial gateway
+  controller = "tcp://localhost:502"
+
+  ## Framing of the requests, either:
+  ##   TCP        - Modbus TCP, with the MBAP header
+  ##   RTUoverTCP - Modbus RTU frames sent over TCP, such as to a serial gateway
+  # transmission_mode = "TCP"
+
+  ## Timeout of the requests
+  # timeout = "1s"
+
+  ## The fields of each slave are grouped into as few requests as possible,
+  ## the contiguous addresses of a register type being read at once.
+  ##
+  ## The coils and discrete inputs are boolean fields, the registers are
+  ## decoded with one of the types:
+  ##   INT16, UINT16, INT32, UINT32, INT64, UINT64, FLOAT32, FLOAT64
+  ## UINT16 is the default.  The values multiplied by a scale other than 1
+  ## are floats.
+  [[inputs.modbus.slave]]
+    slave_id = 1
+
+    ## Order of the bytes within the registers and of the registers within
+    ## the values of more than 16 bits, either "big" or "little".  Modbus
+    ## is big endian but some devices use a little endian word order.
+    # byte_order = "big"
+    # word_order = "big"
+
+    coils = [
+      { name = "motor_running", address = 0 },
+    ]
+    discrete_inputs = [
+      { name = "door_open", address = 0 },
+    ]
+    holding_registers = [
+      { name = "setpoint", address = 0, type = "INT16", scale = 0.1 },
+      { name = "counter",  address = 1, type = "UINT32" },
+    ]
+    input_registers = [
+      { name = "temperature", address = 0, type = "FLOAT32" },
+    ]
+
+    ## Additional tags of the metrics of the slave
+    # [inputs.modbus.slave.tags]
+    #   location = "line1"
+`
+
+func (m *Modbus) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *Modbus) Description() string {
+	return "Read coils, discrete inputs and registers of Modbus devices"
+}
+
+func (m *Modbus) Init() error {
+	u, err := url.Parse(m.Controller)
+	if err != nil {
+		return fmt.Errorf("invalid controller %q: %v", m.Controller, err)
+	}
+	if u.Scheme != "tcp" || u.Host == "" {
+		return fmt.Errorf("invalid controller %q, expected tcp://host:port", m.Controller)
+	}
+	m.address = u.Host
+
+	switch m.TransmissionMode {
+	case "":
+		m.TransmissionMode = "TCP"
+	case "TCP", "RTUoverTCP":
+	default:
+		return fmt.Errorf("invalid transmission mode %q", m.TransmissionMode)
+	}
+
+	if m.Timeout.Duration == 0 {
+		m.Timeout.Duration = time.Second
+	}
+
+	if len(m.Slaves) == 0 {
+		return fmt.Errorf("no slave configured")
+	}
+	for _, slave := range m.Slaves {
+		if err := slave.init(); err != nil {
+			return fmt.Errorf("slave %d: %v", slave.SlaveID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Slave) init() error {
+	if s.SlaveID < 0 || s.SlaveID > 255 {
+		return fmt.Errorf("invalid slave id")
+	}
+	for _, order := range []*string{&s.ByteOrder, &s.WordOrder} {
+		switch *order {
+		case "":
+			*order = "big"
+		case "big", "little":
+		default:
+			return fmt.Errorf("invalid order %q", *order)
+		}
+	}
+
+	tables := []struct {
+		kind   *registerType
+		fields []*Field
+	}{
+		{coils, s.Coils},
+		{discreteInputs, s.DiscreteInputs},
+		{holdingRegisters, s.HoldingRegisters},
+		{inputRegisters, s.InputRegisters},
+	}
+	s.requests = nil
+	for _, table := range tables {
+		names := make(map[string]bool, len(table.fields))
+		for _, f := range table.fields {
+			if err := f.init(table.kind); err != nil {
+				return err
+			}
+			if names[f.Name] {
+				return fmt.Errorf("duplicate %s field %q", table.kind.name, f.Name)
+			}
+			names[f.Name] = true
+		}
+		s.requests = append(s.requests, groupRequests(table.kind, table.fields)...)
+	}
+	if len(s.requests) == 0 {
+		return fmt.Errorf("no field configured")
+	}
+	return nil
+}
+
+func (f *Field) init(kind *registerType) error {
+	if f.Name == "" {
+		return fmt.Errorf("missing name of %s at address %d", kind.name, f.Address)
+	}
+
+	f.length = 1
+	if !kind.bits {
+		if f.Type == "" {
+			f.Type = "UINT16"
+		}
+		length, ok := registerLengths[f.Type]
+		if !ok {
+			return fmt.Errorf("field %q: invalid type %q", f.Name, f.Type)
+		}
+		f.length = length
+	}
+
+	if f.Address < 0 || f.Address+f.length > 65536 {
+		return fmt.Errorf("field %q: invalid address %d", f.Name, f.Address)
+	}
+	return nil
+}
+
+// groupRequests groups the fields into the smallest number of requests,
+// each request reading contiguous or overlapping fields.
+func groupRequests(kind *registerType, fields []*Field) []*request {
+	sorted := make([]*Field, len(fields))
+	copy(sorted, fields)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Address < sorted[j].Address
+	})
+
+	var requests []*request
+	var current *request
+	for _, f := range sorted {
+		end := f.Address + f.length
+		if current != nil && f.Address <= current.address+current.count && end-current.address <= kind.max {
+			if end > current.address+current.count {
+				current.count = end - current.address
+			}
+			current.fields = append(current.fields, f)
+			continue
+		}
+		current = &request{
+			kind:    kind,
+			address: f.Address,
+			count:   f.length,
+			fields:  []*Field{f},
+		}
+		requests = append(requests, current)
+	}
+	return requests
+}
+
+func (m *Modbus) Gather(acc telegraf.Accumulator) error {
+	if m.client == nil {
+		c, err := dial(m.address, m.TransmissionMode == "RTUoverTCP", m.Timeout.Duration)
+		if err != nil {
+			return err
+		}
+		m.client = c
+	}
+
+	for _, slave := range m.Slaves {
+		if err := m.gatherSlave(acc, slave); err != nil {
+			// The connection is reopened at the next gather, as the
+			// response may still be received.
+			m.client.Close()
+			m.client = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// gatherSlave reads the fields of a slave into one metric per register type.
+// The exceptions returned by the slave are reported while the other errors
+// abort the gather.
+func (m *Modbus) gatherSlave(acc telegraf.Accumulator, slave *Slave) error {
+	fields := make(map[*registerType]map[string]interface{})
+	var order []*registerType
+	for _, req := range slave.requests {
+		data, err := m.client.read(byte(slave.SlaveID), req.kind, req.address, req.count)
+		if err != nil {
+			_, exception := err.(*exceptionError)
+			err = fmt.Errorf("slave %d: reading %d %ss at address %d: %v",
+				slave.SlaveID, req.count, req.kind.name, req.address, err)
+			if exception {
+				acc.AddError(err)
+				continue
+			}
+			return err
+		}
+
+		if _, ok := fields[req.kind]; !ok {
+			fields[req.kind] = make(map[string]interface{})
+			order = append(order, req.kind)
+		}
+		for _, f := range req.fields {
+			offset := f.Address - req.address
+			if req.kind.bits {
+				fields[req.kind][f.Name] = data[offset/8]>>(uint(offset)%8)&1 == 1
+				continue
+			}
+			value := slave.registers(data[offset*2 : (offset+f.length)*2])
+			fields[req.kind][f.Name] = f.convert(value)
+		}
+	}
+
+	for _, kind := range order {
+		tags := map[string]string{
+			"slave_id": strconv.Itoa(slave.SlaveID),
+			"type":     kind.name,
+		}
+		if m.Name != "" {
+			tags["name"] = m.Name
+		}
+		for k, v := range slave.Tags {
+			tags[k] = v
+		}
+		acc.AddFields("modbus", fields[kind], tags)
+	}
+	return nil
+}
+
+func init() {
+	inputs.Add("modbus", func() telegraf.Input {
+		return &Modbus{}
+	})
+}
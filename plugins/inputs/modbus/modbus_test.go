@@ -0,0 +1,460 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer is a Modbus server answering the read requests from its data
+// tables, framed either as Modbus TCP or as RTU over TCP.
+type testServer struct {
+	listener net.Listener
+	rtu      bool
+
+	coils            []bool
+	discreteInputs   []bool
+	holdingRegisters []uint16
+	inputRegisters   []uint16
+
+	mu       sync.Mutex
+	requests int
+}
+
+func newTestServer(t *testing.T, rtu bool) *testServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &testServer{
+		listener:         listener,
+		rtu:              rtu,
+		coils:            make([]bool, 32),
+		discreteInputs:   make([]bool, 32),
+		holdingRegisters: make([]uint16, 256),
+		inputRegisters:   make([]uint16, 256),
+	}
+	go s.serve()
+	return s
+}
+
+func (s *testServer) controller() string {
+	return "tcp://" + s.listener.Addr().String()
+}
+
+func (s *testServer) Close() {
+	s.listener.Close()
+}
+
+func (s *testServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *testServer) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		if s.rtu {
+			frame := make([]byte, 8)
+			if _, err := io.ReadFull(conn, frame); err != nil {
+				return
+			}
+			crc := crc16(frame[:6])
+			if frame[6] != byte(crc) || frame[7] != byte(crc>>8) {
+				return
+			}
+			resp := append([]byte{frame[0]}, s.respond(frame[1:6])...)
+			crc = crc16(resp)
+			resp = append(resp, byte(crc), byte(crc>>8))
+			if _, err := conn.Write(resp); err != nil {
+				return
+			}
+			continue
+		}
+
+		frame := make([]byte, 12)
+		if _, err := io.ReadFull(conn, frame); err != nil {
+			return
+		}
+		pdu := s.respond(frame[7:12])
+		resp := make([]byte, 7, 7+len(pdu))
+		copy(resp, frame[0:4])
+		binary.BigEndian.PutUint16(resp[4:], uint16(len(pdu)+1))
+		resp[6] = frame[6]
+		resp = append(resp, pdu...)
+		if _, err := conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (s *testServer) respond(pdu []byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+
+	function := pdu[0]
+	address := int(binary.BigEndian.Uint16(pdu[1:]))
+	count := int(binary.BigEndian.Uint16(pdu[3:]))
+
+	var bits []bool
+	var registers []uint16
+	switch function {
+	case 1:
+		bits = s.coils
+	case 2:
+		bits = s.discreteInputs
+	case 3:
+		registers = s.holdingRegisters
+	case 4:
+		registers = s.inputRegisters
+	default:
+		return []byte{function | 0x80, 1}
+	}
+
+	if bits != nil {
+		if address+count > len(bits) {
+			return []byte{function | 0x80, 2}
+		}
+		data := make([]byte, (count+7)/8)
+		for i := 0; i < count; i++ {
+			if bits[address+i] {
+				data[i/8] |= 1 << uint(i%8)
+			}
+		}
+		return append([]byte{function, byte(len(data))}, data...)
+	}
+
+	if address+count > len(registers) {
+		return []byte{function | 0x80, 2}
+	}
+	data := make([]byte, count*2)
+	for i := 0; i < count; i++ {
+		binary.BigEndian.PutUint16(data[i*2:], registers[address+i])
+	}
+	return append([]byte{function, byte(len(data))}, data...)
+}
+
+func TestCRC16(t *testing.T) {
+	crc := crc16([]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0a})
+	require.Equal(t, []byte{0xc5, 0xcd}, []byte{byte(crc), byte(crc >> 8)})
+}
+
+func TestGather(t *testing.T) {
+	for _, mode := range []string{"TCP", "RTUoverTCP"} {
+		t.Run(mode, func(t *testing.T) {
+			server := newTestServer(t, mode == "RTUoverTCP")
+			defer server.Close()
+
+			server.coils[0] = true
+			server.coils[9] = true
+			server.discreteInputs[3] = true
+			server.holdingRegisters[0] = 0xff38 // -200
+			server.holdingRegisters[1] = 0x0001
+			server.holdingRegisters[2] = 0x86a0 // 100000
+			f := math.Float32bits(21.5)
+			server.inputRegisters[0] = uint16(f >> 16)
+			server.inputRegisters[1] = uint16(f)
+
+			m := &Modbus{
+				Name:             "device",
+				Controller:       server.controller(),
+				TransmissionMode: mode,
+				Slaves: []*Slave{
+					{
+						SlaveID: 1,
+						Tags:    map[string]string{"location": "line1"},
+						Coils: []*Field{
+							{Name: "motor_running", Address: 0},
+							{Name: "pump_running", Address: 9},
+						},
+						DiscreteInputs: []*Field{
+							{Name: "door_open", Address: 3},
+						},
+						HoldingRegisters: []*Field{
+							{Name: "setpoint", Address: 0, Type: "INT16", Scale: 0.1},
+							{Name: "counter", Address: 1, Type: "UINT32"},
+						},
+						InputRegisters: []*Field{
+							{Name: "temperature", Address: 0, Type: "FLOAT32"},
+						},
+					},
+				},
+				Log: testutil.Logger{},
+			}
+			require.NoError(t, m.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, acc.GatherError(m.Gather))
+
+			tags := func(kind string) map[string]string {
+				return map[string]string{
+					"name":     "device",
+					"slave_id": "1",
+					"type":     kind,
+					"location": "line1",
+				}
+			}
+			expected := []telegraf.Metric{
+				testutil.MustMetric("modbus", tags("coil"),
+					map[string]interface{}{"motor_running": true, "pump_running": true}, time.Unix(0, 0)),
+				testutil.MustMetric("modbus", tags("discrete_input"),
+					map[string]interface{}{"door_open": true}, time.Unix(0, 0)),
+				testutil.MustMetric("modbus", tags("holding_register"),
+					map[string]interface{}{"setpoint": -20.0, "counter": uint64(100000)}, time.Unix(0, 0)),
+				testutil.MustMetric("modbus", tags("input_register"),
+					map[string]interface{}{"temperature": 21.5}, time.Unix(0, 0)),
+			}
+			testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+			// The coils are not contiguous, the other fields are read with
+			// one request per register type.
+			require.Equal(t, 5, server.requestCount())
+		})
+	}
+}
+
+func TestGatherByteWordOrder(t *testing.T) {
+	server := newTestServer(t, false)
+	defer server.Close()
+
+	// 0x0102030405060708 with the registers and the bytes swapped
+	server.holdingRegisters[0] = 0x0807
+	server.holdingRegisters[1] = 0x0605
+	server.holdingRegisters[2] = 0x0403
+	server.holdingRegisters[3] = 0x0201
+	// -2.5 as a float32 with the registers swapped
+	f := math.Float32bits(-2.5)
+	server.holdingRegisters[4] = uint16(f)
+	server.holdingRegisters[5] = uint16(f >> 16)
+
+	m := &Modbus{
+		Controller: server.controller(),
+		Slaves: []*Slave{
+			{
+				SlaveID:   1,
+				ByteOrder: "little",
+				WordOrder: "little",
+				HoldingRegisters: []*Field{
+					{Name: "value", Address: 0, Type: "UINT64"},
+				},
+			},
+			{
+				SlaveID:   2,
+				WordOrder: "little",
+				HoldingRegisters: []*Field{
+					{Name: "value", Address: 4, Type: "FLOAT32"},
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, m.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(m.Gather))
+
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{"value": uint64(0x0102030405060708)},
+		map[string]string{"slave_id": "1", "type": "holding_register"})
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{"value": -2.5},
+		map[string]string{"slave_id": "2", "type": "holding_register"})
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		typ      string
+		scale    float64
+		data     []byte
+		expected interface{}
+	}{
+		{"INT16", 0, []byte{0x80, 0x00}, int64(-32768)},
+		{"UINT16", 0, []byte{0x80, 0x00}, uint64(32768)},
+		{"INT32", 0, []byte{0xff, 0xff, 0xff, 0xfe}, int64(-2)},
+		{"UINT32", 0, []byte{0xff, 0xff, 0xff, 0xfe}, uint64(4294967294)},
+		{"INT64", 0, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, int64(-1)},
+		{"UINT64", 1, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}, uint64(256)},
+		{"FLOAT32", 0, []byte{0x3f, 0xc0, 0x00, 0x00}, 1.5},
+		{"FLOAT64", 2, []byte{0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, 3.0},
+		{"UINT16", 0.01, []byte{0x00, 0x64}, 1.0},
+	}
+	for _, tt := range tests {
+		f := &Field{Type: tt.typ, Scale: tt.scale}
+		require.Equal(t, tt.expected, f.convert(tt.data), tt.typ)
+	}
+}
+
+func TestGroupRequests(t *testing.T) {
+	fields := []*Field{
+		{Name: "e", Address: 10},
+		{Name: "a", Address: 0},
+		{Name: "c", Address: 2, Type: "FLOAT32"},
+		{Name: "b", Address: 1},
+		// overlaps c
+		{Name: "d", Address: 3},
+	}
+	for _, f := range fields {
+		require.NoError(t, f.init(holdingRegisters))
+	}
+
+	requests := groupRequests(holdingRegisters, fields)
+	require.Len(t, requests, 2)
+	require.Equal(t, 0, requests[0].address)
+	require.Equal(t, 4, requests[0].count)
+	require.Len(t, requests[0].fields, 4)
+	require.Equal(t, 10, requests[1].address)
+	require.Equal(t, 1, requests[1].count)
+}
+
+func TestGroupRequestsMaxLength(t *testing.T) {
+	var fields []*Field
+	for i := 0; i < 70; i++ {
+		f := &Field{Name: string(rune('a'+i%26)) + string(rune('a'+i/26)), Address: i * 2, Type: "UINT32"}
+		require.NoError(t, f.init(inputRegisters))
+		fields = append(fields, f)
+	}
+
+	requests := groupRequests(inputRegisters, fields)
+	require.Len(t, requests, 2)
+	require.Equal(t, 124, requests[0].count)
+	require.Equal(t, 124, requests[1].address)
+	require.Equal(t, 16, requests[1].count)
+}
+
+func TestGatherException(t *testing.T) {
+	server := newTestServer(t, false)
+	defer server.Close()
+
+	m := &Modbus{
+		Controller: server.controller(),
+		Slaves: []*Slave{
+			{
+				SlaveID: 1,
+				HoldingRegisters: []*Field{
+					{Name: "missing", Address: 1000},
+				},
+				InputRegisters: []*Field{
+					{Name: "value", Address: 0},
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, m.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, m.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "illegal data address")
+	require.True(t, acc.HasUIntField("modbus", "value"))
+}
+
+func TestRTUInvalidByteCount(t *testing.T) {
+	conn, gateway := net.Pipe()
+	defer gateway.Close()
+	c := &client{conn: conn, rtu: true, timeout: time.Second}
+	defer c.Close()
+
+	// The gateway answers a read of 2 registers with 255 bytes of data
+	go func() {
+		request := make([]byte, 8)
+		if _, err := io.ReadFull(gateway, request); err != nil {
+			return
+		}
+		resp := append([]byte{1, 3, 255}, make([]byte, 255)...)
+		crc := crc16(resp)
+		gateway.Write(append(resp, byte(crc), byte(crc>>8)))
+	}()
+
+	_, err := c.read(1, holdingRegisters, 0, 2)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unexpected length 255")
+}
+
+func TestGatherConnectionError(t *testing.T) {
+	server := newTestServer(t, false)
+	controller := server.controller()
+	server.Close()
+
+	m := &Modbus{
+		Controller: controller,
+		Slaves: []*Slave{
+			{
+				SlaveID:          1,
+				HoldingRegisters: []*Field{{Name: "value", Address: 0}},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, m.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(m.Gather))
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modbus *Modbus
+	}{
+		{
+			name:   "invalid controller",
+			modbus: &Modbus{Controller: "udp://localhost:502", Slaves: []*Slave{{HoldingRegisters: []*Field{{Name: "a"}}}}},
+		},
+		{
+			name:   "invalid transmission mode",
+			modbus: &Modbus{Controller: "tcp://localhost:502", TransmissionMode: "ASCII", Slaves: []*Slave{{HoldingRegisters: []*Field{{Name: "a"}}}}},
+		},
+		{
+			name:   "no slave",
+			modbus: &Modbus{Controller: "tcp://localhost:502"},
+		},
+		{
+			name:   "no field",
+			modbus: &Modbus{Controller: "tcp://localhost:502", Slaves: []*Slave{{SlaveID: 1}}},
+		},
+		{
+			name:   "invalid type",
+			modbus: &Modbus{Controller: "tcp://localhost:502", Slaves: []*Slave{{HoldingRegisters: []*Field{{Name: "a", Type: "INT8"}}}}},
+		},
+		{
+			name:   "invalid order",
+			modbus: &Modbus{Controller: "tcp://localhost:502", Slaves: []*Slave{{WordOrder: "middle", HoldingRegisters: []*Field{{Name: "a"}}}}},
+		},
+		{
+			name:   "duplicate field",
+			modbus: &Modbus{Controller: "tcp://localhost:502", Slaves: []*Slave{{HoldingRegisters: []*Field{{Name: "a"}, {Name: "a", Address: 1}}}}},
+		},
+		{
+			name:   "missing name",
+			modbus: &Modbus{Controller: "tcp://localhost:502", Slaves: []*Slave{{Coils: []*Field{{Address: 1}}}}},
+		},
+		{
+			name:   "address out of range",
+			modbus: &Modbus{Controller: "tcp://localhost:502", Slaves: []*Slave{{InputRegisters: []*Field{{Name: "a", Address: 65535, Type: "FLOAT32"}}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.modbus.Init())
+		})
+	}
+}
@@ -50,6 +50,7 @@ type MQTTConsumer struct {
 	QoS                    int               `toml:"qos"`
 	ConnectionTimeout      internal.Duration `toml:"connection_timeout"`
 	MaxUndeliveredMessages int               `toml:"max_undelivered_messages"`
+	TopicParsing           []*TopicParsing   `toml:"topic_parsing"`
 
 	parser parsers.Parser
 
@@ -139,6 +140,19 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
   data_format = "influx"
+
+  ## Rules mapping the segments of a topic to the measurement name, tags and
+  ## fields.  The topic pattern accepts the '+' and '#' wildcards, the other
+  ## patterns have one entry per topic segment, with '_' skipping a segment.
+  ## All matching rules are applied in order.  Fields are strings unless a
+  ## type of int, uint, float or bool is set.
+  # [[inputs.mqtt_consumer.topic_parsing]]
+  #   topic = "site/+/+/+/+"
+  #   measurement = "_/_/_/measurement/_"
+  #   tags = "_/plant/line/_/_"
+  #   fields = "_/_/_/_/sensor_id"
+  #   [inputs.mqtt_consumer.topic_parsing.types]
+  #     sensor_id = "int"
 `
 
 func (m *MQTTConsumer) SampleConfig() string {
@@ -173,6 +187,12 @@ func (m *MQTTConsumer) Init() error {
 		m.topicTag = *m.TopicTag
 	}
 
+	for _, p := range m.TopicParsing {
+		if err := p.init(); err != nil {
+			return err
+		}
+	}
+
 	opts, err := m.createOpts()
 	if err != nil {
 		return err
@@ -279,13 +299,19 @@ func (m *MQTTConsumer) onMessage(acc telegraf.TrackingAccumulator, msg mqtt.Mess
 		return err
 	}
 
+	topic := msg.Topic()
 	if m.topicTag != "" {
-		topic := msg.Topic()
 		for _, metric := range metrics {
 			metric.AddTag(m.topicTag, topic)
 		}
 	}
 
+	for _, p := range m.TopicParsing {
+		if err := p.apply(topic, metrics); err != nil {
+			return err
+		}
+	}
+
 	id := acc.AddTrackingMetricGroup(metrics)
 	m.messages[id] = true
 	return nil
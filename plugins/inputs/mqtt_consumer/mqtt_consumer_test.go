@@ -148,6 +148,8 @@ func TestPersistentClientIDFail(t *testing.T) {
 }
 
 type Message struct {
+	topic   string
+	payload string
 }
 
 func (m *Message) Duplicate() bool {
@@ -163,7 +165,10 @@ func (m *Message) Retained() bool {
 }
 
 func (m *Message) Topic() string {
-	return "telegraf"
+	if m.topic == "" {
+		return "telegraf"
+	}
+	return m.topic
 }
 
 func (m *Message) MessageID() uint16 {
@@ -171,7 +176,10 @@ func (m *Message) MessageID() uint16 {
 }
 
 func (m *Message) Payload() []byte {
-	return []byte("cpu time_idle=42i")
+	if m.payload == "" {
+		return []byte("cpu time_idle=42i")
+	}
+	return []byte(m.payload)
 }
 
 func (m *Message) Ack() {
@@ -284,6 +292,234 @@ func TestTopicTag(t *testing.T) {
 	}
 }
 
+func TestTopicParsing(t *testing.T) {
+	tests := []struct {
+		name     string
+		topic    string
+		rules    []*TopicParsing
+		expected []telegraf.Metric
+	}{
+		{
+			name:  "measurement and tags",
+			topic: "site/plant1/line3/temperature",
+			rules: []*TopicParsing{
+				{
+					Topic:       "site/+/+/+",
+					Measurement: "_/_/_/measurement",
+					Tags:        "_/plant/line/_",
+				},
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"temperature",
+					map[string]string{
+						"plant": "plant1",
+						"line":  "line3",
+					},
+					map[string]interface{}{
+						"value": 21.5,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:  "typed fields",
+			topic: "sensors/42/true/1.5",
+			rules: []*TopicParsing{
+				{
+					Topic:  "sensors/+/+/+",
+					Fields: "_/id/active/gain",
+					Types: map[string]string{
+						"id":     "int",
+						"active": "bool",
+						"gain":   "float",
+					},
+				},
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value":  21.5,
+						"id":     int64(42),
+						"active": true,
+						"gain":   1.5,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:  "multi-level wildcard",
+			topic: "site/plant1/line3/temperature",
+			rules: []*TopicParsing{
+				{
+					Topic: "site/+/#",
+					Tags:  "_/plant/sensor",
+				},
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{
+						"plant":  "plant1",
+						"sensor": "line3/temperature",
+					},
+					map[string]interface{}{
+						"value": 21.5,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:  "all matching rules are applied",
+			topic: "site/plant1/temperature",
+			rules: []*TopicParsing{
+				{
+					Topic: "site/plant2/+",
+					Tags:  "_/_/skipped",
+				},
+				{
+					Topic: "site/+/+",
+					Tags:  "_/plant/_",
+				},
+				{
+					Topic:       "+/+/temperature",
+					Measurement: "_/_/measurement",
+				},
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"temperature",
+					map[string]string{
+						"plant": "plant1",
+					},
+					map[string]interface{}{
+						"value": 21.5,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:  "no match",
+			topic: "site/plant1/line3",
+			rules: []*TopicParsing{
+				{
+					Topic: "site/+",
+					Tags:  "_/plant",
+				},
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 21.5,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handler mqtt.MessageHandler
+			client := &FakeClient{
+				ConnectF: func() mqtt.Token {
+					return &FakeToken{}
+				},
+				AddRouteF: func(topic string, callback mqtt.MessageHandler) {
+					handler = callback
+				},
+				SubscribeMultipleF: func(filters map[string]byte, callback mqtt.MessageHandler) mqtt.Token {
+					return &FakeToken{}
+				},
+				DisconnectF: func(quiesce uint) {
+				},
+			}
+
+			plugin := New(func(o *mqtt.ClientOptions) Client {
+				return client
+			})
+			plugin.Log = testutil.Logger{}
+			plugin.Topics = []string{"#"}
+			topicTag := ""
+			plugin.TopicTag = &topicTag
+			plugin.TopicParsing = tt.rules
+
+			parser, err := parsers.NewInfluxParser()
+			require.NoError(t, err)
+			plugin.SetParser(parser)
+
+			err = plugin.Init()
+			require.NoError(t, err)
+
+			var acc testutil.Accumulator
+			err = plugin.Start(&acc)
+			require.NoError(t, err)
+
+			handler(nil, &Message{topic: tt.topic, payload: "cpu value=21.5"})
+
+			plugin.Stop()
+
+			testutil.RequireMetricsEqual(t, tt.expected, acc.GetTelegrafMetrics(),
+				testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestTopicParsingInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *TopicParsing
+	}{
+		{
+			name: "missing topic",
+			rule: &TopicParsing{Tags: "a"},
+		},
+		{
+			name: "wildcard not last",
+			rule: &TopicParsing{Topic: "a/#/b"},
+		},
+		{
+			name: "too many segments",
+			rule: &TopicParsing{Topic: "a/+", Tags: "_/b/c"},
+		},
+		{
+			name: "several measurement segments",
+			rule: &TopicParsing{Topic: "+/+", Measurement: "a/b"},
+		},
+		{
+			name: "unknown type",
+			rule: &TopicParsing{Topic: "+", Fields: "a", Types: map[string]string{"a": "time"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := New(nil)
+			plugin.Log = testutil.Logger{}
+			plugin.TopicParsing = []*TopicParsing{tt.rule}
+			require.Error(t, plugin.Init())
+		})
+	}
+}
+
+func TestTopicParsingConversionError(t *testing.T) {
+	rule := &TopicParsing{
+		Topic:  "sensors/+",
+		Fields: "_/id",
+		Types:  map[string]string{"id": "int"},
+	}
+	require.NoError(t, rule.init())
+
+	m := testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.Error(t, rule.apply("sensors/abc", []telegraf.Metric{m}))
+}
+
 func TestAddRouteCalledForEachTopic(t *testing.T) {
 	client := &FakeClient{
 		ConnectF: func() mqtt.Token {
@@ -0,0 +1,160 @@
+package mqtt_consumer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// TopicParsing maps the segments of the topics matching a pattern to the
+// measurement name, tags and fields of the metrics.
+type TopicParsing struct {
+	Topic       string            `toml:"topic"`
+	Measurement string            `toml:"measurement"`
+	Tags        string            `toml:"tags"`
+	Fields      string            `toml:"fields"`
+	Types       map[string]string `toml:"types"`
+
+	topic       []string
+	measurement []string
+	tags        []string
+	fields      []string
+}
+
+func (p *TopicParsing) init() error {
+	if p.Topic == "" {
+		return fmt.Errorf("topic_parsing: topic must be set")
+	}
+	p.topic = strings.Split(p.Topic, "/")
+	for i, segment := range p.topic {
+		if segment == "#" && i != len(p.topic)-1 {
+			return fmt.Errorf("topic_parsing: %q: '#' must be the last segment", p.Topic)
+		}
+	}
+
+	var err error
+	if p.measurement, err = p.split(p.Measurement); err != nil {
+		return err
+	}
+	if p.tags, err = p.split(p.Tags); err != nil {
+		return err
+	}
+	if p.fields, err = p.split(p.Fields); err != nil {
+		return err
+	}
+
+	var names int
+	for _, name := range p.measurement {
+		if name != "_" {
+			names++
+		}
+	}
+	if names > 1 {
+		return fmt.Errorf("topic_parsing: %q: measurement must select a single segment", p.Measurement)
+	}
+
+	for name, typ := range p.Types {
+		switch typ {
+		case "int", "uint", "float", "bool", "string":
+		default:
+			return fmt.Errorf("topic_parsing: unknown type %q for %q", typ, name)
+		}
+	}
+	return nil
+}
+
+// split splits a mapping pattern, which may not have more segments than the
+// topic pattern.
+func (p *TopicParsing) split(pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	segments := strings.Split(pattern, "/")
+	if len(segments) > len(p.topic) {
+		return nil, fmt.Errorf("topic_parsing: %q has more segments than topic %q", pattern, p.Topic)
+	}
+	return segments, nil
+}
+
+// match returns the segments of the topic matching the pattern, with the
+// segments matched by a trailing '#' joined into the last one.
+func (p *TopicParsing) match(topic string) ([]string, bool) {
+	segments := strings.Split(topic, "/")
+	for i, pattern := range p.topic {
+		if pattern == "#" {
+			return append(segments[:i], strings.Join(segments[i:], "/")), true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		if pattern != "+" && pattern != segments[i] {
+			return nil, false
+		}
+	}
+	if len(segments) != len(p.topic) {
+		return nil, false
+	}
+	return segments, true
+}
+
+// apply sets the measurement name, tags and fields of the metrics from the
+// topic, metrics of topics not matching the pattern are left unchanged.
+func (p *TopicParsing) apply(topic string, metrics []telegraf.Metric) error {
+	segments, ok := p.match(topic)
+	if !ok {
+		return nil
+	}
+
+	for i, name := range p.measurement {
+		if name != "_" && i < len(segments) {
+			for _, metric := range metrics {
+				metric.SetName(segments[i])
+			}
+		}
+	}
+
+	for i, key := range p.tags {
+		if key != "_" && i < len(segments) {
+			for _, metric := range metrics {
+				metric.AddTag(key, segments[i])
+			}
+		}
+	}
+
+	for i, key := range p.fields {
+		if key == "_" || i >= len(segments) {
+			continue
+		}
+		value, err := p.convert(key, segments[i])
+		if err != nil {
+			return err
+		}
+		for _, metric := range metrics {
+			metric.AddField(key, value)
+		}
+	}
+	return nil
+}
+
+func (p *TopicParsing) convert(key, value string) (interface{}, error) {
+	var v interface{}
+	var err error
+	switch p.Types[key] {
+	case "int":
+		v, err = strconv.ParseInt(value, 10, 64)
+	case "uint":
+		v, err = strconv.ParseUint(value, 10, 64)
+	case "float":
+		v, err = strconv.ParseFloat(value, 64)
+	case "bool":
+		v, err = strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("topic_parsing: converting field %q: %v", key, err)
+	}
+	return v, nil
+}
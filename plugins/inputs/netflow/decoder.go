@@ -0,0 +1,141 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var errTruncated = errors.New("truncated packet")
+
+// decoder decodes the flow export packets into metrics.  It is not safe for
+// concurrent use, as it caches the templates of the exporters.
+type decoder struct {
+	log telegraf.Logger
+
+	// templates of NetFlow v9 and IPFIX, by exporter
+	templates map[templateKey]*template
+
+	now func() time.Time
+}
+
+func newDecoder(log telegraf.Logger) *decoder {
+	return &decoder{
+		log:       log,
+		templates: make(map[templateKey]*template),
+		now:       time.Now,
+	}
+}
+
+// decode decodes a packet sent by the src exporter.  The protocol is
+// detected from the version at the beginning of the packet, which is a 16
+// bits integer for NetFlow and IPFIX, and a 32 bits integer for sFlow.
+func (d *decoder) decode(src net.IP, packet []byte) ([]telegraf.Metric, error) {
+	if len(packet) < 4 {
+		return nil, errTruncated
+	}
+
+	switch version := binary.BigEndian.Uint16(packet); version {
+	case 5:
+		return d.decodeV5(src, packet)
+	case 9:
+		return d.decodeV9(src, packet)
+	case 10:
+		return d.decodeIPFIX(src, packet)
+	case 0:
+		if version := binary.BigEndian.Uint32(packet); version != 5 {
+			return nil, fmt.Errorf("unsupported sFlow version %d", version)
+		}
+		return d.decodeSFlow(src, packet)
+	default:
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+}
+
+// reader reads the big endian integers of a packet.  Reading past the end
+// of the packet returns zeros and sets err, to be checked once the whole
+// structure has been read.
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) len() int {
+	return len(r.b)
+}
+
+// zeros is returned for the integers read past the end of the packet.
+var zeros [16]byte
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || len(r.b) < n {
+		r.err = errTruncated
+		// The lengths read from the packet are not trusted to allocate
+		if n >= 0 && n <= len(zeros) {
+			return zeros[:n]
+		}
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *reader) uint8() uint8 {
+	return r.bytes(1)[0]
+}
+
+func (r *reader) uint16() uint16 {
+	return binary.BigEndian.Uint16(r.bytes(2))
+}
+
+func (r *reader) uint32() uint32 {
+	return binary.BigEndian.Uint32(r.bytes(4))
+}
+
+func (r *reader) uint64() uint64 {
+	return binary.BigEndian.Uint64(r.bytes(8))
+}
+
+func (r *reader) ip(n int) string {
+	return net.IP(r.bytes(n)).String()
+}
+
+// protocols are the names of the most common IP protocols, the others are
+// reported by number.
+var protocols = map[uint8]string{
+	1:   "icmp",
+	2:   "igmp",
+	6:   "tcp",
+	17:  "udp",
+	41:  "ipv6",
+	47:  "gre",
+	50:  "esp",
+	51:  "ah",
+	58:  "ipv6-icmp",
+	89:  "ospf",
+	103: "pim",
+	112: "vrrp",
+	132: "sctp",
+}
+
+func protocolName(protocol uint8) string {
+	if name, ok := protocols[protocol]; ok {
+		return name
+	}
+	return strconv.Itoa(int(protocol))
+}
+
+func newMetric(src net.IP, version string, fields map[string]interface{}, tm time.Time) (telegraf.Metric, error) {
+	tags := map[string]string{
+		"source":  src.String(),
+		"version": version,
+	}
+	return metric.New("netflow", tags, fields, tm)
+}
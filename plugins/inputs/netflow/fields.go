@@ -0,0 +1,118 @@
+package netflow
+
+import (
+	"net"
+)
+
+type fieldKind int
+
+const (
+	kindUint fieldKind = iota
+	kindIP
+	kindMAC
+	kindProtocol
+)
+
+type fieldType struct {
+	name string
+	kind fieldKind
+}
+
+// fieldTypes maps the NetFlow v9 field types and the IANA IPFIX information
+// elements to the field names, the NetFlow v9 types being a subset of the
+// IPFIX elements.  The other fields are ignored.  See
+// https://www.iana.org/assignments/ipfix/ipfix.xhtml
+var fieldTypes = map[uint16]fieldType{
+	1:   {"in_bytes", kindUint},
+	2:   {"in_packets", kindUint},
+	3:   {"flows", kindUint},
+	4:   {"protocol", kindProtocol},
+	5:   {"src_tos", kindUint},
+	6:   {"tcp_flags", kindUint},
+	7:   {"src_port", kindUint},
+	8:   {"src", kindIP},
+	9:   {"src_mask", kindUint},
+	10:  {"in_snmp", kindUint},
+	11:  {"dst_port", kindUint},
+	12:  {"dst", kindIP},
+	13:  {"dst_mask", kindUint},
+	14:  {"out_snmp", kindUint},
+	15:  {"next_hop", kindIP},
+	16:  {"bgp_src_as", kindUint},
+	17:  {"bgp_dst_as", kindUint},
+	18:  {"bgp_next_hop", kindIP},
+	21:  {"last_switched", kindUint},
+	22:  {"first_switched", kindUint},
+	23:  {"out_bytes", kindUint},
+	24:  {"out_packets", kindUint},
+	27:  {"src", kindIP},
+	28:  {"dst", kindIP},
+	29:  {"src_mask", kindUint},
+	30:  {"dst_mask", kindUint},
+	31:  {"flow_label", kindUint},
+	32:  {"icmp_type_code", kindUint},
+	34:  {"sampling_interval", kindUint},
+	35:  {"sampling_algorithm", kindUint},
+	56:  {"src_mac", kindMAC},
+	58:  {"src_vlan", kindUint},
+	59:  {"dst_vlan", kindUint},
+	60:  {"ip_version", kindUint},
+	61:  {"direction", kindUint},
+	62:  {"next_hop", kindIP},
+	63:  {"bgp_next_hop", kindIP},
+	80:  {"dst_mac", kindMAC},
+	89:  {"forwarding_status", kindUint},
+	136: {"flow_end_reason", kindUint},
+	139: {"icmp_type_code", kindUint},
+	148: {"flow_id", kindUint},
+	150: {"flow_start_seconds", kindUint},
+	151: {"flow_end_seconds", kindUint},
+	152: {"flow_start_milliseconds", kindUint},
+	153: {"flow_end_milliseconds", kindUint},
+	176: {"icmp_type", kindUint},
+	177: {"icmp_code", kindUint},
+	178: {"icmp_type", kindUint},
+	179: {"icmp_code", kindUint},
+	225: {"post_nat_src", kindIP},
+	226: {"post_nat_dst", kindIP},
+	227: {"post_napt_src_port", kindUint},
+	228: {"post_napt_dst_port", kindUint},
+}
+
+// decodeField decodes the value of a field, the values not fitting their
+// kind are skipped.
+func decodeField(fields map[string]interface{}, id uint16, value []byte) {
+	ft, ok := fieldTypes[id]
+	if !ok {
+		return
+	}
+
+	switch ft.kind {
+	case kindUint:
+		if len(value) > 0 && len(value) <= 8 {
+			fields[ft.name] = decodeUint(value)
+		}
+	case kindIP:
+		if len(value) == net.IPv4len || len(value) == net.IPv6len {
+			fields[ft.name] = net.IP(value).String()
+		}
+	case kindMAC:
+		if len(value) == 6 {
+			fields[ft.name] = net.HardwareAddr(value).String()
+		}
+	case kindProtocol:
+		if len(value) == 1 {
+			fields[ft.name] = protocolName(value[0])
+		}
+	}
+}
+
+// decodeUint decodes a big endian unsigned integer of up to 8 bytes, as
+// the exporters may reduce the size of the integer fields.
+func decodeUint(value []byte) uint64 {
+	var v uint64
+	for _, b := range value {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
@@ -0,0 +1,119 @@
+package netflow
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type NetFlow struct {
+	ServiceAddress string        `toml:"service_address"`
+	ReadBufferSize internal.Size `toml:"read_buffer_size"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	conn    *net.UDPConn
+	decoder *decoder
+	wg      sync.WaitGroup
+}
+
+const sampleConfig = `
+  ## Address to listen for flow exports on, such as
+  ##   udp://:2055 for NetFlow v5, v9 and IPFIX exports
+  ##   udp://:6343 for sFlow v5 datagrams
+  ## The protocol is detected from the version of each packet.
+  service_address = "udp://:2055"
+
+  ## Size of the receive buffer of the socket, the default of the OS is
+  ## used when not set.  Increase it when exporters send bursts of packets.
+  # read_buffer_size = "1MiB"
+`
+
+func (n *NetFlow) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *NetFlow) Description() string {
+	return "Receive NetFlow v5, v9, IPFIX and sFlow v5 flow exports"
+}
+
+func (n *NetFlow) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+func (n *NetFlow) Start(acc telegraf.Accumulator) error {
+	u, err := url.Parse(n.ServiceAddress)
+	if err != nil {
+		return fmt.Errorf("invalid service address %q: %v", n.ServiceAddress, err)
+	}
+	switch u.Scheme {
+	case "udp", "udp4", "udp6":
+	default:
+		return fmt.Errorf("unsupported protocol %q in %q", u.Scheme, n.ServiceAddress)
+	}
+
+	addr, err := net.ResolveUDPAddr(u.Scheme, u.Host)
+	if err != nil {
+		return err
+	}
+	n.conn, err = net.ListenUDP(u.Scheme, addr)
+	if err != nil {
+		return err
+	}
+	if n.ReadBufferSize.Size > 0 {
+		if err := n.conn.SetReadBuffer(int(n.ReadBufferSize.Size)); err != nil {
+			n.Log.Warnf("Unable to set read buffer: %v", err)
+		}
+	}
+	n.Log.Infof("Listening on %s://%s", u.Scheme, n.conn.LocalAddr())
+
+	n.decoder = newDecoder(n.Log)
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.listen(acc)
+	}()
+	return nil
+}
+
+func (n *NetFlow) listen(acc telegraf.Accumulator) {
+	buf := make([]byte, 64*1024) // 64kb - maximum size of IP packet
+	for {
+		count, src, err := n.conn.ReadFromUDP(buf)
+		if err != nil {
+			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+				n.Log.Errorf("Error reading packet: %v", err)
+				continue
+			}
+			return
+		}
+
+		metrics, err := n.decoder.decode(src.IP, buf[:count])
+		if err != nil {
+			acc.AddError(fmt.Errorf("decoding packet from %s: %v", src.IP, err))
+		}
+		for _, m := range metrics {
+			acc.AddMetric(m)
+		}
+	}
+}
+
+func (n *NetFlow) Stop() {
+	if n.conn != nil {
+		n.conn.Close()
+	}
+	n.wg.Wait()
+}
+
+func init() {
+	inputs.Add("netflow", func() telegraf.Input {
+		return &NetFlow{}
+	})
+}
@@ -0,0 +1,316 @@
+package netflow
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var exporter = net.ParseIP("192.0.2.1")
+
+// readPacket reads a captured packet from its hex dump in testdata.
+func readPacket(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name+".hex"))
+	require.NoError(t, err)
+	packet, err := hex.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+	require.NoError(t, err)
+	return packet
+}
+
+func newTestDecoder() *decoder {
+	d := newDecoder(testutil.Logger{})
+	d.now = func() time.Time { return time.Unix(0, 0) }
+	return d
+}
+
+func flowMetric(version string, fields map[string]interface{}) telegraf.Metric {
+	return testutil.MustMetric(
+		"netflow",
+		map[string]string{"source": "192.0.2.1", "version": version},
+		fields,
+		time.Unix(0, 0),
+	)
+}
+
+func TestDecodeV5(t *testing.T) {
+	d := newTestDecoder()
+	metrics, err := d.decode(exporter, readPacket(t, "netflow_v5"))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		flowMetric("NetFlowV5", map[string]interface{}{
+			"src":               "192.168.1.10",
+			"dst":               "10.0.0.1",
+			"next_hop":          "192.168.1.1",
+			"in_snmp":           uint64(2),
+			"out_snmp":          uint64(3),
+			"in_packets":        uint64(10),
+			"in_bytes":          uint64(5000),
+			"first_switched":    uint64(1000),
+			"last_switched":     uint64(2000),
+			"src_port":          uint64(54321),
+			"dst_port":          uint64(443),
+			"tcp_flags":         uint64(0x1b),
+			"protocol":          "tcp",
+			"src_tos":           uint64(0),
+			"bgp_src_as":        uint64(64512),
+			"bgp_dst_as":        uint64(15169),
+			"src_mask":          uint64(24),
+			"dst_mask":          uint64(8),
+			"engine_type":       uint64(0),
+			"engine_id":         uint64(1),
+			"sampling_interval": uint64(100),
+		}),
+		flowMetric("NetFlowV5", map[string]interface{}{
+			"src":               "192.168.1.11",
+			"dst":               "8.8.8.8",
+			"next_hop":          "192.168.1.1",
+			"in_snmp":           uint64(2),
+			"out_snmp":          uint64(3),
+			"in_packets":        uint64(1),
+			"in_bytes":          uint64(76),
+			"first_switched":    uint64(1500),
+			"last_switched":     uint64(1500),
+			"src_port":          uint64(53000),
+			"dst_port":          uint64(53),
+			"tcp_flags":         uint64(0),
+			"protocol":          "udp",
+			"src_tos":           uint64(0),
+			"bgp_src_as":        uint64(64512),
+			"bgp_dst_as":        uint64(15169),
+			"src_mask":          uint64(24),
+			"dst_mask":          uint64(0),
+			"engine_type":       uint64(0),
+			"engine_id":         uint64(1),
+			"sampling_interval": uint64(100),
+		}),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestDecodeV5Truncated(t *testing.T) {
+	packet := readPacket(t, "netflow_v5")
+
+	d := newTestDecoder()
+	_, err := d.decode(exporter, packet[:len(packet)-1])
+	require.Error(t, err)
+}
+
+func TestDecodeIPFIXInvalidLength(t *testing.T) {
+	// IPFIX header with a message length of 4, shorter than the header
+	packet := []byte{
+		0x00, 0x0a, 0x00, 0x04, 0x5e, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x02, 0x00, 0x04,
+	}
+
+	d := newTestDecoder()
+	_, err := d.decode(exporter, packet)
+	require.Error(t, err)
+}
+
+func TestDecodeV9(t *testing.T) {
+	d := newTestDecoder()
+
+	// The data received before the template cannot be decoded
+	metrics, err := d.decode(exporter, readPacket(t, "netflow_v9_data"))
+	require.NoError(t, err)
+	require.Empty(t, metrics)
+
+	metrics, err = d.decode(exporter, readPacket(t, "netflow_v9_template"))
+	require.NoError(t, err)
+	require.Empty(t, metrics)
+
+	// The options data are not reported
+	metrics, err = d.decode(exporter, readPacket(t, "netflow_v9_data"))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		flowMetric("NetFlowV9", map[string]interface{}{
+			"src":            "10.1.1.1",
+			"dst":            "10.2.2.2",
+			"src_port":       uint64(40000),
+			"dst_port":       uint64(80),
+			"protocol":       "tcp",
+			"tcp_flags":      uint64(0x18),
+			"in_bytes":       uint64(12000),
+			"in_packets":     uint64(20),
+			"in_snmp":        uint64(1),
+			"out_snmp":       uint64(2),
+			"first_switched": uint64(100),
+			"last_switched":  uint64(200),
+			"src_mac":        "00:11:22:33:44:55",
+			"direction":      uint64(0),
+		}),
+		flowMetric("NetFlowV9", map[string]interface{}{
+			"src":            "10.1.1.2",
+			"dst":            "10.2.2.3",
+			"src_port":       uint64(40001),
+			"dst_port":       uint64(53),
+			"protocol":       "udp",
+			"tcp_flags":      uint64(0),
+			"in_bytes":       uint64(120),
+			"in_packets":     uint64(2),
+			"in_snmp":        uint64(1),
+			"out_snmp":       uint64(2),
+			"first_switched": uint64(300),
+			"last_switched":  uint64(300),
+			"src_mac":        "00:11:22:33:44:66",
+			"direction":      uint64(1),
+		}),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestDecodeV9TemplatePerExporter(t *testing.T) {
+	d := newTestDecoder()
+
+	metrics, err := d.decode(exporter, readPacket(t, "netflow_v9_template"))
+	require.NoError(t, err)
+	require.Empty(t, metrics)
+
+	// The template ids are scoped to the exporter
+	metrics, err = d.decode(net.ParseIP("192.0.2.2"), readPacket(t, "netflow_v9_data"))
+	require.NoError(t, err)
+	require.Empty(t, metrics)
+
+	metrics, err = d.decode(exporter, readPacket(t, "netflow_v9_data"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+}
+
+func TestDecodeIPFIX(t *testing.T) {
+	d := newTestDecoder()
+	metrics, err := d.decode(exporter, readPacket(t, "ipfix"))
+	require.NoError(t, err)
+
+	// The interface names of variable length and the enterprise specific
+	// fields are skipped.
+	expected := []telegraf.Metric{
+		flowMetric("IPFIX", map[string]interface{}{
+			"src":                     "2001:db8::1",
+			"dst":                     "2001:db8::2",
+			"src_port":                uint64(50000),
+			"dst_port":                uint64(443),
+			"protocol":                "tcp",
+			"in_bytes":                uint64(4096),
+			"in_packets":              uint64(8),
+			"flow_start_milliseconds": uint64(1577836800000),
+			"flow_end_milliseconds":   uint64(1577836801000),
+		}),
+		flowMetric("IPFIX", map[string]interface{}{
+			"src":                     "2001:db8::3",
+			"dst":                     "2001:db8::4",
+			"src_port":                uint64(50001),
+			"dst_port":                uint64(123),
+			"protocol":                "udp",
+			"in_bytes":                uint64(76),
+			"in_packets":              uint64(1),
+			"flow_start_milliseconds": uint64(1577836800500),
+			"flow_end_milliseconds":   uint64(1577836800500),
+		}),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestDecodeSFlow(t *testing.T) {
+	d := newTestDecoder()
+	metrics, err := d.decode(exporter, readPacket(t, "sflow_v5"))
+	require.NoError(t, err)
+
+	// The counter sample is skipped
+	expected := []telegraf.Metric{
+		flowMetric("sFlowV5", map[string]interface{}{
+			"sampling_rate": uint64(512),
+			"drops":         uint64(0),
+			"in_snmp":       uint64(3),
+			"out_snmp":      uint64(7),
+			"in_bytes":      uint64(1518),
+			"in_packets":    uint64(1),
+			"dst_mac":       "00:11:22:33:44:55",
+			"src_mac":       "66:77:88:99:aa:bb",
+			"vlan":          uint64(100),
+			"ip_version":    uint64(4),
+			"src_tos":       uint64(0x10),
+			"protocol":      "tcp",
+			"src":           "172.16.0.1",
+			"dst":           "172.16.0.2",
+			"src_port":      uint64(12345),
+			"dst_port":      uint64(22),
+			"tcp_flags":     uint64(0x02),
+			"src_vlan":      uint64(100),
+			"dst_vlan":      uint64(200),
+		}),
+		flowMetric("sFlowV5", map[string]interface{}{
+			"sampling_rate": uint64(1024),
+			"drops":         uint64(1),
+			"in_snmp":       uint64(11),
+			"out_snmp":      uint64(12),
+			"in_bytes":      uint64(1280),
+			"in_packets":    uint64(1),
+			"protocol":      "udp",
+			"src":           "2001:db8::10",
+			"dst":           "2001:db8::20",
+			"src_port":      uint64(5353),
+			"dst_port":      uint64(53),
+			"tcp_flags":     uint64(0),
+			"src_tos":       uint64(0),
+		}),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestDecodeUnsupportedVersion(t *testing.T) {
+	d := newTestDecoder()
+	_, err := d.decode(exporter, []byte{0x00, 0x07, 0x00, 0x00})
+	require.Error(t, err)
+
+	_, err = d.decode(exporter, []byte{0x00, 0x00, 0x00, 0x04})
+	require.Error(t, err)
+}
+
+func TestReplay(t *testing.T) {
+	n := &NetFlow{
+		ServiceAddress: "udp://127.0.0.1:0",
+		Log:            testutil.Logger{},
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, n.Start(&acc))
+	defer n.Stop()
+
+	conn, err := net.Dial("udp", n.conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for _, name := range []string{"netflow_v5", "netflow_v9_template", "netflow_v9_data", "ipfix", "sflow_v5"} {
+		_, err := conn.Write(readPacket(t, name))
+		require.NoError(t, err)
+	}
+
+	acc.Wait(8)
+	require.Empty(t, acc.Errors)
+
+	versions := make(map[string]int)
+	for _, m := range acc.GetTelegrafMetrics() {
+		require.Equal(t, "127.0.0.1", m.Tags()["source"])
+		versions[m.Tags()["version"]]++
+	}
+	require.Equal(t, map[string]int{"NetFlowV5": 2, "NetFlowV9": 2, "IPFIX": 2, "sFlowV5": 2}, versions)
+}
+
+func TestInvalidServiceAddress(t *testing.T) {
+	n := &NetFlow{
+		ServiceAddress: "tcp://127.0.0.1:0",
+		Log:            testutil.Logger{},
+	}
+	var acc testutil.Accumulator
+	require.Error(t, n.Start(&acc))
+}
@@ -0,0 +1,73 @@
+package netflow
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	v5HeaderLength = 24
+	v5RecordLength = 48
+)
+
+// decodeV5 decodes a NetFlow v5 export, made of a header and of fixed
+// format records, see
+// https://www.cisco.com/c/en/us/td/docs/net_mgmt/netflow_collection_engine/3-6/user/guide/format.html
+func (d *decoder) decodeV5(src net.IP, packet []byte) ([]telegraf.Metric, error) {
+	r := &reader{b: packet}
+
+	r.uint16() // version
+	count := int(r.uint16())
+	r.uint32() // sys_uptime
+	r.uint32() // unix_secs
+	r.uint32() // unix_nsecs
+	r.uint32() // flow_sequence
+	engineType := r.uint8()
+	engineID := r.uint8()
+	sampling := r.uint16()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.len() < count*v5RecordLength {
+		return nil, fmt.Errorf("%d records announced in %d bytes: %v", count, len(packet), errTruncated)
+	}
+
+	now := d.now()
+	metrics := make([]telegraf.Metric, 0, count)
+	for i := 0; i < count; i++ {
+		fields := map[string]interface{}{
+			"src":               r.ip(4),
+			"dst":               r.ip(4),
+			"next_hop":          r.ip(4),
+			"in_snmp":           uint64(r.uint16()),
+			"out_snmp":          uint64(r.uint16()),
+			"in_packets":        uint64(r.uint32()),
+			"in_bytes":          uint64(r.uint32()),
+			"first_switched":    uint64(r.uint32()),
+			"last_switched":     uint64(r.uint32()),
+			"src_port":          uint64(r.uint16()),
+			"dst_port":          uint64(r.uint16()),
+			"engine_type":       uint64(engineType),
+			"engine_id":         uint64(engineID),
+			"sampling_interval": uint64(sampling & 0x3fff),
+		}
+		r.uint8() // pad1
+		fields["tcp_flags"] = uint64(r.uint8())
+		fields["protocol"] = protocolName(r.uint8())
+		fields["src_tos"] = uint64(r.uint8())
+		fields["bgp_src_as"] = uint64(r.uint16())
+		fields["bgp_dst_as"] = uint64(r.uint16())
+		fields["src_mask"] = uint64(r.uint8())
+		fields["dst_mask"] = uint64(r.uint8())
+		r.uint16() // pad2
+
+		m, err := newMetric(src, "NetFlowV5", fields, now)
+		if err != nil {
+			return metrics, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, r.err
+}
@@ -0,0 +1,271 @@
+package netflow
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	v9TemplateSetID        = 0
+	v9OptionsTemplateSetID = 1
+	ipfixTemplateSetID     = 2
+	ipfixOptionsSetID      = 3
+	minDataSetID           = 256
+
+	// variableLength is the length of the IPFIX fields whose length is
+	// given in the data records.
+	variableLength = 65535
+)
+
+// templateKey identifies a template, the template ids being scoped to the
+// exporter and to its source id or observation domain.
+type templateKey struct {
+	exporter string
+	version  uint16
+	domain   uint32
+	id       uint16
+}
+
+type templateField struct {
+	id         uint16
+	length     uint16
+	enterprise uint32
+}
+
+type template struct {
+	fields []templateField
+	// options templates describe the exporter, not flows
+	options bool
+}
+
+// minLength returns the minimum length of a record, counting one byte for
+// the length of the variable length fields.
+func (t *template) minLength() int {
+	var n int
+	for _, f := range t.fields {
+		if f.length == variableLength {
+			n++
+		} else {
+			n += int(f.length)
+		}
+	}
+	return n
+}
+
+// decodeV9 decodes a NetFlow v9 export, see RFC 3954.
+func (d *decoder) decodeV9(src net.IP, packet []byte) ([]telegraf.Metric, error) {
+	r := &reader{b: packet}
+
+	r.uint16() // version
+	r.uint16() // count
+	r.uint32() // sys_uptime
+	r.uint32() // unix_secs
+	r.uint32() // sequence
+	domain := r.uint32()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return d.decodeSets(src, 9, domain, r)
+}
+
+// decodeIPFIX decodes an IPFIX message, see RFC 7011.
+func (d *decoder) decodeIPFIX(src net.IP, packet []byte) ([]telegraf.Metric, error) {
+	r := &reader{b: packet}
+
+	r.uint16() // version
+	length := int(r.uint16())
+	r.uint32() // export time
+	r.uint32() // sequence
+	domain := r.uint32()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if length < 16 {
+		return nil, fmt.Errorf("invalid message length %d", length)
+	}
+	if length > len(packet) {
+		return nil, fmt.Errorf("message length %d larger than packet: %v", length, errTruncated)
+	}
+	r.b = packet[16:length]
+
+	return d.decodeSets(src, 10, domain, r)
+}
+
+// decodeSets decodes the template and data sets, or flowsets, of NetFlow v9
+// and IPFIX, which share the same layout.
+func (d *decoder) decodeSets(src net.IP, version uint16, domain uint32, r *reader) ([]telegraf.Metric, error) {
+	var metrics []telegraf.Metric
+	for r.len() >= 4 {
+		id := r.uint16()
+		length := int(r.uint16())
+		if length < 4 {
+			return metrics, fmt.Errorf("invalid set length %d", length)
+		}
+		set := &reader{b: r.bytes(length - 4)}
+		if r.err != nil {
+			return metrics, r.err
+		}
+
+		key := templateKey{exporter: src.String(), version: version, domain: domain}
+		var err error
+		switch {
+		case version == 9 && id == v9TemplateSetID, version == 10 && id == ipfixTemplateSetID:
+			err = d.decodeTemplates(key, set)
+		case version == 9 && id == v9OptionsTemplateSetID:
+			err = d.decodeV9OptionsTemplates(key, set)
+		case version == 10 && id == ipfixOptionsSetID:
+			err = d.decodeIPFIXOptionsTemplates(key, set)
+		case id >= minDataSetID:
+			key.id = id
+			var records []telegraf.Metric
+			records, err = d.decodeData(src, key, set)
+			metrics = append(metrics, records...)
+		}
+		if err != nil {
+			return metrics, err
+		}
+	}
+	return metrics, nil
+}
+
+func (d *decoder) decodeTemplates(key templateKey, r *reader) error {
+	// The sets may be padded with zeros
+	for r.len() >= 4 {
+		key.id = r.uint16()
+		count := int(r.uint16())
+		if key.id < minDataSetID {
+			break
+		}
+		if count == 0 {
+			// IPFIX template withdrawal
+			delete(d.templates, key)
+			continue
+		}
+
+		t := &template{}
+		for i := 0; i < count; i++ {
+			t.fields = append(t.fields, readTemplateField(r, key.version))
+		}
+		if r.err != nil {
+			return r.err
+		}
+		d.templates[key] = t
+	}
+	return nil
+}
+
+func (d *decoder) decodeV9OptionsTemplates(key templateKey, r *reader) error {
+	for r.len() >= 6 {
+		key.id = r.uint16()
+		scopeLength := int(r.uint16())
+		optionLength := int(r.uint16())
+		if key.id < minDataSetID {
+			break
+		}
+
+		t := &template{options: true}
+		for i := 0; i < (scopeLength+optionLength)/4; i++ {
+			t.fields = append(t.fields, readTemplateField(r, key.version))
+		}
+		if r.err != nil {
+			return r.err
+		}
+		d.templates[key] = t
+	}
+	return nil
+}
+
+func (d *decoder) decodeIPFIXOptionsTemplates(key templateKey, r *reader) error {
+	for r.len() >= 6 {
+		key.id = r.uint16()
+		count := int(r.uint16())
+		if key.id < minDataSetID {
+			break
+		}
+		if count == 0 {
+			delete(d.templates, key)
+			continue
+		}
+		r.uint16() // scope field count
+
+		t := &template{options: true}
+		for i := 0; i < count; i++ {
+			t.fields = append(t.fields, readTemplateField(r, key.version))
+		}
+		if r.err != nil {
+			return r.err
+		}
+		d.templates[key] = t
+	}
+	return nil
+}
+
+// readTemplateField reads a field specifier, the IPFIX fields with the
+// enterprise bit set are followed by the enterprise number.
+func readTemplateField(r *reader, version uint16) templateField {
+	f := templateField{id: r.uint16(), length: r.uint16()}
+	if version == 10 && f.id&0x8000 != 0 {
+		f.id &= 0x7fff
+		f.enterprise = r.uint32()
+	}
+	return f
+}
+
+func (d *decoder) decodeData(src net.IP, key templateKey, r *reader) ([]telegraf.Metric, error) {
+	t, ok := d.templates[key]
+	if !ok {
+		// The exporters send the templates periodically, the data received
+		// before are lost.
+		d.log.Debugf("Dropping data set of unknown template %d from %s", key.id, key.exporter)
+		return nil, nil
+	}
+	if t.options {
+		return nil, nil
+	}
+
+	version := "NetFlowV9"
+	if key.version == 10 {
+		version = "IPFIX"
+	}
+
+	minLength := t.minLength()
+	if minLength == 0 {
+		return nil, nil
+	}
+
+	now := d.now()
+	var metrics []telegraf.Metric
+	// The sets may be padded to a multiple of 4 bytes
+	for r.len() >= minLength {
+		fields := make(map[string]interface{}, len(t.fields))
+		for _, f := range t.fields {
+			length := int(f.length)
+			if f.length == variableLength {
+				length = int(r.uint8())
+				if length == 255 {
+					length = int(r.uint16())
+				}
+			}
+			value := r.bytes(length)
+			if f.enterprise == 0 {
+				decodeField(fields, f.id, value)
+			}
+		}
+		if r.err != nil {
+			return metrics, r.err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		m, err := newMetric(src, version, fields, now)
+		if err != nil {
+			return metrics, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,217 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/influxdata/telegraf"
+)
+
+// sFlow sample and record formats of the standard enterprise, see
+// https://sflow.org/sflow_version_5.txt
+const (
+	sflowFlowSample         = 1
+	sflowExpandedFlowSample = 3
+
+	sflowRawPacketHeader = 1
+	sflowIPv4Data        = 3
+	sflowIPv6Data        = 4
+	sflowExtendedSwitch  = 1001
+
+	sflowHeaderEthernet = 1
+)
+
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88a8
+)
+
+// decodeSFlow decodes a sFlow v5 datagram.  The flow samples are decoded
+// into metrics, the counter samples are ignored.
+func (d *decoder) decodeSFlow(src net.IP, packet []byte) ([]telegraf.Metric, error) {
+	r := &reader{b: packet}
+
+	r.uint32() // version
+	switch addressType := r.uint32(); addressType {
+	case 1:
+		r.bytes(net.IPv4len)
+	case 2:
+		r.bytes(net.IPv6len)
+	default:
+		return nil, fmt.Errorf("invalid agent address type %d", addressType)
+	}
+	r.uint32() // sub agent id
+	r.uint32() // sequence number
+	r.uint32() // uptime
+	count := int(r.uint32())
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	now := d.now()
+	var metrics []telegraf.Metric
+	for i := 0; i < count; i++ {
+		format := r.uint32()
+		sample := &reader{b: r.bytes(int(r.uint32()))}
+		if r.err != nil {
+			return metrics, r.err
+		}
+
+		var fields map[string]interface{}
+		switch format {
+		case sflowFlowSample:
+			fields = decodeSFlowFlowSample(sample, false)
+		case sflowExpandedFlowSample:
+			fields = decodeSFlowFlowSample(sample, true)
+		default:
+			continue
+		}
+		if sample.err != nil {
+			return metrics, sample.err
+		}
+
+		m, err := newMetric(src, "sFlowV5", fields, now)
+		if err != nil {
+			return metrics, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func decodeSFlowFlowSample(r *reader, expanded bool) map[string]interface{} {
+	fields := make(map[string]interface{})
+
+	r.uint32() // sequence number
+	if expanded {
+		r.uint32() // source id type
+		r.uint32() // source id index
+	} else {
+		r.uint32() // source id
+	}
+	fields["sampling_rate"] = uint64(r.uint32())
+	r.uint32() // sample pool
+	fields["drops"] = uint64(r.uint32())
+	if expanded {
+		r.uint32() // input format
+		fields["in_snmp"] = uint64(r.uint32())
+		r.uint32() // output format
+		fields["out_snmp"] = uint64(r.uint32())
+	} else {
+		// The format is in the 2 most significant bits
+		fields["in_snmp"] = uint64(r.uint32() & 0x3fffffff)
+		fields["out_snmp"] = uint64(r.uint32() & 0x3fffffff)
+	}
+
+	count := int(r.uint32())
+	for i := 0; i < count && r.err == nil; i++ {
+		format := r.uint32()
+		record := &reader{b: r.bytes(int(r.uint32()))}
+
+		switch format {
+		case sflowRawPacketHeader:
+			protocol := record.uint32()
+			fields["in_bytes"] = uint64(record.uint32())
+			record.uint32() // stripped
+			header := record.bytes(int(record.uint32()))
+			if record.err == nil && protocol == sflowHeaderEthernet {
+				decodeEthernet(fields, header)
+			}
+		case sflowIPv4Data:
+			decodeSFlowIPData(fields, record, net.IPv4len)
+		case sflowIPv6Data:
+			decodeSFlowIPData(fields, record, net.IPv6len)
+		case sflowExtendedSwitch:
+			fields["src_vlan"] = uint64(record.uint32())
+			record.uint32() // source priority
+			fields["dst_vlan"] = uint64(record.uint32())
+			record.uint32() // destination priority
+		}
+		if record.err != nil {
+			r.err = record.err
+		}
+	}
+	fields["in_packets"] = uint64(1)
+	return fields
+}
+
+// decodeSFlowIPData decodes the IPv4 and IPv6 data records, which are sent
+// by the agents not sampling the packet headers.
+func decodeSFlowIPData(fields map[string]interface{}, r *reader, ipLength int) {
+	fields["in_bytes"] = uint64(r.uint32())
+	fields["protocol"] = protocolName(uint8(r.uint32()))
+	fields["src"] = r.ip(ipLength)
+	fields["dst"] = r.ip(ipLength)
+	fields["src_port"] = uint64(r.uint32())
+	fields["dst_port"] = uint64(r.uint32())
+	fields["tcp_flags"] = uint64(r.uint32())
+	// type of service for IPv4, priority for IPv6
+	fields["src_tos"] = uint64(r.uint32())
+}
+
+// decodeEthernet decodes the sampled header of an Ethernet frame, as far
+// as it was captured by the agent.
+func decodeEthernet(fields map[string]interface{}, header []byte) {
+	if len(header) < 14 {
+		return
+	}
+	fields["dst_mac"] = net.HardwareAddr(header[0:6]).String()
+	fields["src_mac"] = net.HardwareAddr(header[6:12]).String()
+
+	etherType := binary.BigEndian.Uint16(header[12:14])
+	header = header[14:]
+	for (etherType == etherTypeVLAN || etherType == etherTypeQinQ) && len(header) >= 4 {
+		fields["vlan"] = uint64(binary.BigEndian.Uint16(header[0:2]) & 0x0fff)
+		etherType = binary.BigEndian.Uint16(header[2:4])
+		header = header[4:]
+	}
+
+	var protocol uint8
+	switch etherType {
+	case etherTypeIPv4:
+		if len(header) < 20 {
+			return
+		}
+		fields["ip_version"] = uint64(4)
+		fields["src_tos"] = uint64(header[1])
+		protocol = header[9]
+		fields["src"] = net.IP(header[12:16]).String()
+		fields["dst"] = net.IP(header[16:20]).String()
+		// Skip the options
+		ihl := int(header[0]&0x0f) * 4
+		if ihl < 20 || len(header) < ihl {
+			return
+		}
+		header = header[ihl:]
+	case etherTypeIPv6:
+		if len(header) < 40 {
+			return
+		}
+		fields["ip_version"] = uint64(6)
+		fields["src_tos"] = uint64(binary.BigEndian.Uint16(header[0:2]) >> 4 & 0xff)
+		protocol = header[6]
+		fields["src"] = net.IP(header[8:24]).String()
+		fields["dst"] = net.IP(header[24:40]).String()
+		header = header[40:]
+	default:
+		return
+	}
+	fields["protocol"] = protocolName(protocol)
+
+	switch protocol {
+	case 6:
+		if len(header) >= 14 {
+			fields["src_port"] = uint64(binary.BigEndian.Uint16(header[0:2]))
+			fields["dst_port"] = uint64(binary.BigEndian.Uint16(header[2:4]))
+			fields["tcp_flags"] = uint64(header[13])
+		}
+	case 17, 132:
+		if len(header) >= 4 {
+			fields["src_port"] = uint64(binary.BigEndian.Uint16(header[0:2]))
+			fields["dst_port"] = uint64(binary.BigEndian.Uint16(header[2:4]))
+		}
+	}
+}
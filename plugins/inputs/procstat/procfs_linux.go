@@ -0,0 +1,191 @@
+// +build linux
+
+package procstat
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const defaultHostProc = "/proc"
+
+const envProc = "HOST_PROC"
+
+// containerIDRegex matches the 64 hex digits ids of the container runtimes in
+// the cgroup paths, such as /docker/<id>, /system.slice/docker-<id>.scope or
+// /kubepods/burstable/pod<uid>/<id>.
+var containerIDRegex = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// hostProc returns the path of the proc filesystem, which can be changed
+// with the HOST_PROC environment variable.
+func hostProc(elem ...string) string {
+	proc := os.Getenv(envProc)
+	if proc == "" {
+		proc = defaultHostProc
+	}
+	return filepath.Join(append([]string{proc}, elem...)...)
+}
+
+// descendants returns the descendants of each of the processes.
+func descendants(pids []PID) (map[PID][]PID, error) {
+	entries, err := ioutil.ReadDir(hostProc())
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[PID][]PID)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		ppid, err := parentPID(PID(pid))
+		if err != nil {
+			// No problem; process may have ended while reading
+			continue
+		}
+		children[ppid] = append(children[ppid], PID(pid))
+	}
+
+	tree := make(map[PID][]PID, len(pids))
+	for _, pid := range pids {
+		seen := map[PID]bool{pid: true}
+		queue := append([]PID(nil), children[pid]...)
+		for len(queue) > 0 {
+			child := queue[0]
+			queue = queue[1:]
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			tree[pid] = append(tree[pid], child)
+			queue = append(queue, children[child]...)
+		}
+	}
+	return tree, nil
+}
+
+// parentPID reads the parent of a process from /proc/<pid>/stat.
+func parentPID(pid PID) (PID, error) {
+	data, err := ioutil.ReadFile(hostProc(strconv.Itoa(int(pid)), "stat"))
+	if err != nil {
+		return 0, err
+	}
+
+	// The command name may hold spaces and parentheses, the state and the
+	// parent id follow the last parenthesis.
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("invalid stat of pid %d", pid)
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("invalid stat of pid %d", pid)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid stat of pid %d: %v", pid, err)
+	}
+	return PID(ppid), nil
+}
+
+// socketCounts counts the sockets opened by a process, by protocol and for
+// TCP by state.  The sockets are the file descriptors linking to
+// "socket:[<inode>]", looked up in the tables of /proc/<pid>/net.
+func socketCounts(pid PID) (map[string]int, error) {
+	dir := hostProc(strconv.Itoa(int(pid)))
+	fds, err := ioutil.ReadDir(filepath.Join(dir, "fd"))
+	if err != nil {
+		return nil, err
+	}
+
+	inodes := make(map[string]bool)
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(link, "socket:[") && strings.HasSuffix(link, "]") {
+			inodes[link[len("socket:["):len(link)-1]] = true
+		}
+	}
+
+	counts := map[string]int{
+		"sockets":                 len(inodes),
+		"sockets_tcp":             0,
+		"sockets_tcp_established": 0,
+		"sockets_tcp_listen":      0,
+		"sockets_udp":             0,
+		"sockets_unix":            0,
+	}
+	if len(inodes) == 0 {
+		return counts, nil
+	}
+
+	tables := []struct {
+		file     string
+		protocol string
+		inode    int
+	}{
+		{"tcp", "tcp", 9},
+		{"tcp6", "tcp", 9},
+		{"udp", "udp", 9},
+		{"udp6", "udp", 9},
+		{"unix", "unix", 6},
+	}
+	for _, table := range tables {
+		f, err := os.Open(filepath.Join(dir, "net", table.file))
+		if err != nil {
+			// The protocol may be disabled, such as IPv6
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		// Skip the header
+		scanner.Scan()
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) <= table.inode || !inodes[fields[table.inode]] {
+				continue
+			}
+			counts["sockets_"+table.protocol]++
+			if table.protocol == "tcp" {
+				switch fields[3] {
+				case "01":
+					counts["sockets_tcp_established"]++
+				case "0A":
+					counts["sockets_tcp_listen"]++
+				}
+			}
+		}
+		f.Close()
+	}
+	return counts, nil
+}
+
+// containerID returns the id of the container owning a process, from its
+// cgroups.  The id is empty when the process does not run in a container.
+func containerID(pid PID) (string, error) {
+	data, err := ioutil.ReadFile(hostProc(strconv.Itoa(int(pid)), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if id := containerIDRegex.FindString(parts[2]); id != "" {
+			return id, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,229 @@
+// +build linux
+
+package procstat
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/process"
+	"github.com/stretchr/testify/require"
+)
+
+const containerIDFixture = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+const tcpFixture = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0050 0100007F:D2F0 01 00000000:00000000 00:00000000 00000000    33        0 1004 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:0016 0100007F:D2F2 01 00000000:00000000 00:00000000 00000000     0        0 9999 1 0000000000000000 20 4 30 10 -1`
+
+const tcp6Fixture = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000001000000:0050 00000000000000000000000001000000:D2F4 01 00000000:00000000 00:00000000 00000000     0        0 1002 1 0000000000000000 20 4 30 10 -1`
+
+const unixFixture = `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 1003 /run/nginx.sock
+0000000000000000: 00000002 00000000 00010000 0001 01 7777 /run/other.sock`
+
+// procfs is a fixture of the proc filesystem, with nginx (100) running a
+// worker (101) running a shell (102) in a container and sshd (200) on the
+// host.  The entries starting with '@' are symlinks.
+var procfs = map[string]string{
+	"100/stat":   "100 (nginx) S 1 100 100 0 -1 4194560 1 0 0 0 0 0 0 0 20 0 1 0 100 1000 10",
+	"100/cgroup": "12:pids:/docker/" + containerIDFixture + "\n0::/docker/" + containerIDFixture,
+	"@100/fd/0":  "/dev/null",
+	"@100/fd/3":  "socket:[1001]",
+	"@100/fd/4":  "socket:[1002]",
+	"@100/fd/5":  "socket:[1003]",
+
+	"101/stat":   "101 (nginx worker) S 100 100 100 0 -1 4194560 1 0 0 0 0 0 0 0 20 0 1 0 100 1000 10",
+	"101/cgroup": "0::/system.slice/docker-" + containerIDFixture + ".scope",
+	"@101/fd/3":  "socket:[1004]",
+	"@101/fd/4":  "/var/log/nginx/access.log",
+
+	"102/stat":   "102 (sh (x)) S 101 100 100 0 -1 4194560 1 0 0 0 0 0 0 0 20 0 1 0 100 1000 10",
+	"102/cgroup": "0::/system.slice/docker-" + containerIDFixture + ".scope",
+
+	"200/stat":   "200 (sshd) S 1 200 200 0 -1 4194560 1 0 0 0 0 0 0 0 20 0 1 0 100 1000 10",
+	"200/cgroup": "12:pids:/system.slice/ssh.service\n0::/system.slice/ssh.service",
+	"@200/fd/3":  "socket:[9999]",
+}
+
+func writeProcfs(t *testing.T) string {
+	root, err := ioutil.TempDir("", "procstat")
+	require.NoError(t, err)
+
+	files := make(map[string]string, len(procfs))
+	for name, content := range procfs {
+		files[name] = content
+	}
+	for _, pid := range []string{"100", "101", "102", "200"} {
+		files[pid+"/net/tcp"] = tcpFixture
+		files[pid+"/net/tcp6"] = tcp6Fixture
+		files[pid+"/net/unix"] = unixFixture
+		require.NoError(t, os.MkdirAll(filepath.Join(root, pid, "fd"), 0755))
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, strings.TrimPrefix(name, "@"))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		if strings.HasPrefix(name, "@") {
+			require.NoError(t, os.Symlink(content, path))
+			continue
+		}
+		require.NoError(t, ioutil.WriteFile(path, []byte(content+"\n"), 0644))
+	}
+	return root
+}
+
+func TestDescendants(t *testing.T) {
+	root := writeProcfs(t)
+	defer os.RemoveAll(root)
+
+	os.Setenv("HOST_PROC", root)
+	defer os.Unsetenv("HOST_PROC")
+
+	tree, err := descendants([]PID{100, 101, 200})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []PID{101, 102}, tree[100])
+	require.Equal(t, []PID{102}, tree[101])
+	require.Empty(t, tree[200])
+
+	require.Equal(t, []PID{100, 200}, topLevelPids([]PID{100, 101, 200}, tree))
+}
+
+func TestSocketCounts(t *testing.T) {
+	root := writeProcfs(t)
+	defer os.RemoveAll(root)
+
+	os.Setenv("HOST_PROC", root)
+	defer os.Unsetenv("HOST_PROC")
+
+	counts, err := socketCounts(100)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{
+		"sockets":                 3,
+		"sockets_tcp":             2,
+		"sockets_tcp_established": 1,
+		"sockets_tcp_listen":      1,
+		"sockets_udp":             0,
+		"sockets_unix":            1,
+	}, counts)
+
+	counts, err = socketCounts(102)
+	require.NoError(t, err)
+	require.Equal(t, 0, counts["sockets"])
+}
+
+func TestContainerID(t *testing.T) {
+	root := writeProcfs(t)
+	defer os.RemoveAll(root)
+
+	os.Setenv("HOST_PROC", root)
+	defer os.Unsetenv("HOST_PROC")
+
+	for _, pid := range []PID{100, 101} {
+		id, err := containerID(pid)
+		require.NoError(t, err)
+		require.Equal(t, containerIDFixture, id)
+	}
+
+	id, err := containerID(200)
+	require.NoError(t, err)
+	require.Equal(t, "", id)
+}
+
+// usageProc is a process with a fixed resource usage.
+type usageProc struct {
+	testProc
+}
+
+func newUsageProc(pid PID) (Process, error) {
+	return &usageProc{testProc{pid: pid, tags: make(map[string]string)}}, nil
+}
+
+func (p *usageProc) NumThreads() (int32, error) {
+	return 2, nil
+}
+
+func (p *usageProc) MemoryInfo() (*process.MemoryInfoStat, error) {
+	return &process.MemoryInfoStat{RSS: 1000}, nil
+}
+
+func (p *usageProc) Times() (*cpu.TimesStat, error) {
+	return &cpu.TimesStat{User: 1.5}, nil
+}
+
+func (p *usageProc) NumFDs() (int32, error) {
+	return 3, nil
+}
+
+func (p *usageProc) RlimitUsage(gatherUsage bool) ([]process.RlimitStat, error) {
+	return []process.RlimitStat{
+		{Resource: process.RLIMIT_NOFILE, Soft: 1024, Hard: 4096, Used: 3},
+		{Resource: process.RLIMIT_RSS, Soft: 2048, Hard: 2048, Used: 1000},
+		{Resource: process.RLIMIT_SIGPENDING, Soft: 10, Hard: 10, Used: 1},
+	}, nil
+}
+
+func TestGather_IncludeChildren(t *testing.T) {
+	root := writeProcfs(t)
+	defer os.RemoveAll(root)
+
+	os.Setenv("HOST_PROC", root)
+	defer os.Unsetenv("HOST_PROC")
+
+	p := Procstat{
+		Exe:             "nginx",
+		PidTag:          true,
+		IncludeChildren: true,
+		SocketCounts:    true,
+		ContainerIDTag:  true,
+		createPIDFinder: pidFinder([]PID{100, 101, 200}, nil),
+		createProcess:   newUsageProc,
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	require.Len(t, p.procs, 2)
+	require.Len(t, p.children, 2)
+
+	var count int
+	for _, m := range acc.Metrics {
+		if m.Measurement != "procstat" {
+			continue
+		}
+		count++
+		switch m.Tags["pid"] {
+		case "100":
+			require.Equal(t, containerIDFixture, m.Tags["container_id"])
+			require.Equal(t, int32(6), m.Fields["num_threads"])
+			require.Equal(t, uint64(3000), m.Fields["memory_rss"])
+			require.Equal(t, 4.5, m.Fields["cpu_time_user"])
+			require.Equal(t, int32(9), m.Fields["num_fds"])
+			require.Equal(t, int32(1024), m.Fields["rlimit_num_fds_soft"])
+			require.Equal(t, int32(4096), m.Fields["rlimit_num_fds_hard"])
+			require.Equal(t, uint64(1), m.Fields["signals_pending"])
+			require.Equal(t, 2, m.Fields["num_children"])
+			require.Equal(t, 4, m.Fields["sockets"])
+			require.Equal(t, 3, m.Fields["sockets_tcp"])
+			require.Equal(t, 2, m.Fields["sockets_tcp_established"])
+			require.Equal(t, 1, m.Fields["sockets_tcp_listen"])
+			require.Equal(t, 1, m.Fields["sockets_unix"])
+		case "200":
+			require.NotContains(t, m.Tags, "container_id")
+			require.Equal(t, int32(2), m.Fields["num_threads"])
+			require.Equal(t, int32(3), m.Fields["num_fds"])
+			require.Equal(t, 0, m.Fields["num_children"])
+			require.Equal(t, 1, m.Fields["sockets"])
+		default:
+			require.Fail(t, fmt.Sprintf("unexpected process %v", m.Tags))
+		}
+	}
+	require.Equal(t, 2, count)
+}
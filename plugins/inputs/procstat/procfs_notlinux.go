@@ -0,0 +1,21 @@
+// +build !linux
+
+package procstat
+
+import (
+	"errors"
+)
+
+var errProcfsNotSupported = errors.New("only supported on Linux")
+
+func descendants(pids []PID) (map[PID][]PID, error) {
+	return nil, errProcfsNotSupported
+}
+
+func socketCounts(pid PID) (map[string]int, error) {
+	return nil, errProcfsNotSupported
+}
+
+func containerID(pid PID) (string, error) {
+	return "", errProcfsNotSupported
+}
@@ -35,14 +35,21 @@ type Procstat struct {
 	PidTag      bool
 	WinService  string `toml:"win_service"`
 
+	IncludeChildren bool `toml:"include_children"`
+	SocketCounts    bool `toml:"socket_counts"`
+	ContainerIDTag  bool `toml:"container_id_tag"`
+
 	finder PIDFinder
 
 	createPIDFinder func() (PIDFinder, error)
 	procs           map[PID]Process
+	children        map[PID]Process
 	createProcess   func(PID) (Process, error)
 }
 
 var sampleConfig = `
+  ## Process selectors, the processes matching all the selectors set are
+  ## monitored.
   ## PID file to monitor process
   pid_file = "/var/run/nginx.pid"
   ## executable name (ie, pgrep <exe>)
@@ -74,6 +81,20 @@ var sampleConfig = `
   ## of series, use judiciously.
   # pid_tag = false
 
+  ## Include the descendants of the processes and add their CPU, memory, IO,
+  ## file descriptor and socket counts to the processes.  The selected
+  ## processes that are descendants of other selected processes are not
+  ## reported separately.  Linux only.
+  # include_children = false
+
+  ## Count the sockets opened by the processes, by protocol and for TCP by
+  ## state.  Linux only, *telegraf* may need to be ran as root.
+  # socket_counts = false
+
+  ## Add the id of the container running the process as the container_id
+  ## tag.  Linux only.
+  # container_id_tag = false
+
   ## Method to use when finding process IDs.  Can be one of 'pgrep', or
   ## 'native'.  The pgrep finder calls the pgrep executable in the PATH while
   ## the native finder performs the search directly in a manor dependent on the
@@ -121,6 +142,15 @@ func (p *Procstat) Gather(acc telegraf.Accumulator) error {
 		return err
 	}
 
+	var tree map[PID][]PID
+	if p.IncludeChildren {
+		tree, err = descendants(pids)
+		if err != nil {
+			acc.AddError(fmt.Errorf("procstat getting descendants: %v", err))
+		}
+		pids = topLevelPids(pids, tree)
+	}
+
 	procs, err := p.updateProcesses(pids, tags, p.procs)
 	if err != nil {
 		acc.AddError(fmt.Errorf("E! Error: procstat getting process, exe: [%s] pidfile: [%s] pattern: [%s] user: [%s] %s",
@@ -128,8 +158,18 @@ func (p *Procstat) Gather(acc telegraf.Accumulator) error {
 	}
 	p.procs = procs
 
-	for _, proc := range p.procs {
-		p.addMetric(proc, acc)
+	if p.IncludeChildren {
+		p.children = p.updateChildren(procs, tree, p.children)
+	}
+
+	for pid, proc := range p.procs {
+		var children []Process
+		for _, child := range tree[pid] {
+			if c, ok := p.children[child]; ok {
+				children = append(children, c)
+			}
+		}
+		p.addMetric(proc, children, acc)
 	}
 
 	fields := map[string]interface{}{
@@ -144,15 +184,13 @@ func (p *Procstat) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-// Add metrics a single Process
-func (p *Procstat) addMetric(proc Process, acc telegraf.Accumulator) {
+// Add metrics a single Process, with the usage of its children added
+func (p *Procstat) addMetric(proc Process, children []Process, acc telegraf.Accumulator) {
 	var prefix string
 	if p.Prefix != "" {
 		prefix = p.Prefix + "_"
 	}
 
-	fields := map[string]interface{}{}
-
 	//If process_name tag is not already set, set to actual name
 	if _, nameInTags := proc.Tags()["process_name"]; !nameInTags {
 		name, err := proc.Name()
@@ -169,11 +207,6 @@ func (p *Procstat) addMetric(proc Process, acc telegraf.Accumulator) {
 		}
 	}
 
-	//If pid is not present as a tag, include it as a field.
-	if _, pidInTags := proc.Tags()["pid"]; !pidInTags {
-		fields["pid"] = int32(proc.PID())
-	}
-
 	//If cmd_line tag is true and it is not already set add cmdline as a tag
 	if p.CmdLineTag {
 		if _, ok := proc.Tags()["cmdline"]; !ok {
@@ -184,6 +217,88 @@ func (p *Procstat) addMetric(proc Process, acc telegraf.Accumulator) {
 		}
 	}
 
+	//If container_id_tag is true and it is not already set add the container id as a tag
+	if p.ContainerIDTag {
+		if _, ok := proc.Tags()["container_id"]; !ok {
+			id, err := containerID(proc.PID())
+			if err == nil && id != "" {
+				proc.Tags()["container_id"] = id
+			}
+		}
+	}
+
+	fields := p.usageFields(proc, prefix)
+
+	//If pid is not present as a tag, include it as a field.
+	if _, pidInTags := proc.Tags()["pid"]; !pidInTags {
+		fields["pid"] = int32(proc.PID())
+	}
+
+	rlims, err := proc.RlimitUsage(true)
+	if err == nil {
+		for _, rlim := range rlims {
+			var name string
+			switch rlim.Resource {
+			case process.RLIMIT_CPU:
+				name = "cpu_time"
+			case process.RLIMIT_DATA:
+				name = "memory_data"
+			case process.RLIMIT_STACK:
+				name = "memory_stack"
+			case process.RLIMIT_RSS:
+				name = "memory_rss"
+			case process.RLIMIT_NOFILE:
+				name = "num_fds"
+			case process.RLIMIT_MEMLOCK:
+				name = "memory_locked"
+			case process.RLIMIT_AS:
+				name = "memory_vms"
+			case process.RLIMIT_LOCKS:
+				name = "file_locks"
+			case process.RLIMIT_SIGPENDING:
+				name = "signals_pending"
+			case process.RLIMIT_NICE:
+				name = "nice_priority"
+			case process.RLIMIT_RTPRIO:
+				name = "realtime_priority"
+			default:
+				continue
+			}
+
+			fields[prefix+"rlimit_"+name+"_soft"] = rlim.Soft
+			fields[prefix+"rlimit_"+name+"_hard"] = rlim.Hard
+			if name == "file_locks" { // gopsutil doesn't currently track the used file locks count
+				continue
+			}
+			// The usage already gathered is kept, its type matches the
+			// usage of the children it is summed with.
+			if _, ok := fields[prefix+name]; !ok {
+				fields[prefix+name] = rlim.Used
+			}
+		}
+	}
+
+	if p.IncludeChildren {
+		for _, child := range children {
+			for k, v := range p.usageFields(child, prefix) {
+				if total, ok := fields[k]; ok {
+					fields[k] = sum(total, v)
+				} else {
+					fields[k] = v
+				}
+			}
+		}
+		fields[prefix+"num_children"] = len(children)
+	}
+
+	acc.AddFields("procstat", fields, proc.Tags())
+}
+
+// usageFields returns the resource usage of a process, which are summed
+// with the usage of the children with include_children.
+func (p *Procstat) usageFields(proc Process, prefix string) map[string]interface{} {
+	fields := map[string]interface{}{}
+
 	numThreads, err := proc.NumThreads()
 	if err == nil {
 		fields[prefix+"num_threads"] = numThreads
@@ -250,46 +365,47 @@ func (p *Procstat) addMetric(proc Process, acc telegraf.Accumulator) {
 		fields[prefix+"memory_usage"] = mem_perc
 	}
 
-	rlims, err := proc.RlimitUsage(true)
-	if err == nil {
-		for _, rlim := range rlims {
-			var name string
-			switch rlim.Resource {
-			case process.RLIMIT_CPU:
-				name = "cpu_time"
-			case process.RLIMIT_DATA:
-				name = "memory_data"
-			case process.RLIMIT_STACK:
-				name = "memory_stack"
-			case process.RLIMIT_RSS:
-				name = "memory_rss"
-			case process.RLIMIT_NOFILE:
-				name = "num_fds"
-			case process.RLIMIT_MEMLOCK:
-				name = "memory_locked"
-			case process.RLIMIT_AS:
-				name = "memory_vms"
-			case process.RLIMIT_LOCKS:
-				name = "file_locks"
-			case process.RLIMIT_SIGPENDING:
-				name = "signals_pending"
-			case process.RLIMIT_NICE:
-				name = "nice_priority"
-			case process.RLIMIT_RTPRIO:
-				name = "realtime_priority"
-			default:
-				continue
-			}
-
-			fields[prefix+"rlimit_"+name+"_soft"] = rlim.Soft
-			fields[prefix+"rlimit_"+name+"_hard"] = rlim.Hard
-			if name != "file_locks" { // gopsutil doesn't currently track the used file locks count
-				fields[prefix+name] = rlim.Used
+	if p.SocketCounts {
+		counts, err := socketCounts(proc.PID())
+		if err == nil {
+			for k, v := range counts {
+				fields[prefix+k] = v
 			}
 		}
 	}
 
-	acc.AddFields("procstat", fields, proc.Tags())
+	return fields
+}
+
+// sum adds two values of the same type, a is returned if the types differ
+func sum(a, b interface{}) interface{} {
+	switch a := a.(type) {
+	case int:
+		if b, ok := b.(int); ok {
+			return a + b
+		}
+	case int32:
+		if b, ok := b.(int32); ok {
+			return a + b
+		}
+	case int64:
+		if b, ok := b.(int64); ok {
+			return a + b
+		}
+	case uint64:
+		if b, ok := b.(uint64); ok {
+			return a + b
+		}
+	case float32:
+		if b, ok := b.(float32); ok {
+			return a + b
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return a + b
+		}
+	}
+	return a
 }
 
 // Update monitored Processes
@@ -333,6 +449,45 @@ func (p *Procstat) updateProcesses(pids []PID, tags map[string]string, prevInfo
 	return procs, nil
 }
 
+// Update the monitored children of the processes
+func (p *Procstat) updateChildren(procs map[PID]Process, tree map[PID][]PID, prevInfo map[PID]Process) map[PID]Process {
+	children := make(map[PID]Process, len(prevInfo))
+	for pid := range procs {
+		for _, child := range tree[pid] {
+			if info, ok := prevInfo[child]; ok {
+				children[child] = info
+				continue
+			}
+			proc, err := p.createProcess(child)
+			if err != nil {
+				// No problem; process may have ended after we found it
+				continue
+			}
+			children[child] = proc
+		}
+	}
+	return children
+}
+
+// topLevelPids removes the processes which are descendants of other
+// processes, as their usage is added to their ancestor.
+func topLevelPids(pids []PID, tree map[PID][]PID) []PID {
+	descendant := make(map[PID]bool)
+	for _, children := range tree {
+		for _, child := range children {
+			descendant[child] = true
+		}
+	}
+
+	var top []PID
+	for _, pid := range pids {
+		if !descendant[pid] {
+			top = append(top, pid)
+		}
+	}
+	return top
+}
+
 // Create and return PIDGatherer lazily
 func (p *Procstat) getPIDFinder() (PIDFinder, error) {
 	if p.finder == nil {
@@ -356,28 +511,58 @@ func (p *Procstat) findPids(acc telegraf.Accumulator) ([]PID, map[string]string,
 		return nil, nil, err
 	}
 
-	if p.PidFile != "" {
-		pids, err = f.PidFile(p.PidFile)
-		tags = map[string]string{"pidfile": p.PidFile}
-	} else if p.Exe != "" {
-		pids, err = f.Pattern(p.Exe)
-		tags = map[string]string{"exe": p.Exe}
-	} else if p.Pattern != "" {
-		pids, err = f.FullPattern(p.Pattern)
-		tags = map[string]string{"pattern": p.Pattern}
-	} else if p.User != "" {
-		pids, err = f.Uid(p.User)
-		tags = map[string]string{"user": p.User}
-	} else if p.SystemdUnit != "" {
-		pids, err = p.systemdUnitPIDs()
-		tags = map[string]string{"systemd_unit": p.SystemdUnit}
-	} else if p.CGroup != "" {
-		pids, err = p.cgroupPIDs()
-		tags = map[string]string{"cgroup": p.CGroup}
-	} else if p.WinService != "" {
-		pids, err = p.winServicePIDs()
-		tags = map[string]string{"win_service": p.WinService}
-	} else {
+	selectors := []struct {
+		tag   string
+		value string
+		find  func() ([]PID, error)
+	}{
+		{"pidfile", p.PidFile, func() ([]PID, error) { return f.PidFile(p.PidFile) }},
+		{"exe", p.Exe, func() ([]PID, error) { return f.Pattern(p.Exe) }},
+		{"pattern", p.Pattern, func() ([]PID, error) { return f.FullPattern(p.Pattern) }},
+		{"user", p.User, func() ([]PID, error) { return f.Uid(p.User) }},
+		{"systemd_unit", p.SystemdUnit, p.systemdUnitPIDs},
+		{"cgroup", p.CGroup, p.cgroupPIDs},
+		{"win_service", p.WinService, p.winServicePIDs},
+	}
+
+	// The processes matching all the selectors are kept
+	var matched map[PID]bool
+	for _, selector := range selectors {
+		if selector.value == "" {
+			continue
+		}
+		tags[selector.tag] = selector.value
+
+		found, err := selector.find()
+		if err != nil {
+			return nil, tags, err
+		}
+
+		if matched == nil {
+			matched = make(map[PID]bool, len(found))
+			for _, pid := range found {
+				if !matched[pid] {
+					matched[pid] = true
+					pids = append(pids, pid)
+				}
+			}
+			continue
+		}
+
+		selected := make(map[PID]bool, len(found))
+		for _, pid := range found {
+			selected[pid] = true
+		}
+		var kept []PID
+		for _, pid := range pids {
+			if selected[pid] {
+				kept = append(kept, pid)
+			}
+		}
+		pids = kept
+	}
+
+	if matched == nil {
 		err = fmt.Errorf("Either exe, pid_file, user, pattern, systemd_unit, cgroup, or win_service must be specified")
 	}
 
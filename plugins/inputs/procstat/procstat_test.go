@@ -398,3 +398,64 @@ func TestProcstatLookupMetric(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, len(p.procs)+1, len(acc.Metrics))
 }
+
+// selectorFinder finds different processes for each of the selectors.
+type selectorFinder struct {
+	pids map[string][]PID
+}
+
+func (f *selectorFinder) PidFile(path string) ([]PID, error) {
+	return f.pids["pidfile"], nil
+}
+
+func (f *selectorFinder) Pattern(pattern string) ([]PID, error) {
+	return f.pids["exe"], nil
+}
+
+func (f *selectorFinder) Uid(user string) ([]PID, error) {
+	return f.pids["user"], nil
+}
+
+func (f *selectorFinder) FullPattern(pattern string) ([]PID, error) {
+	return f.pids["pattern"], nil
+}
+
+func TestGather_CombinedSelectors(t *testing.T) {
+	p := Procstat{
+		Pattern: "nginx",
+		User:    "www-data",
+		createPIDFinder: func() (PIDFinder, error) {
+			return &selectorFinder{
+				pids: map[string][]PID{
+					"pattern": {100, 101, 102, 200},
+					"user":    {101, 102, 300},
+				},
+			}, nil
+		},
+	}
+	var acc testutil.Accumulator
+	pids, tags, err := p.findPids(&acc)
+	require.NoError(t, err)
+	assert.Equal(t, []PID{101, 102}, pids)
+	assert.Equal(t, map[string]string{"pattern": "nginx", "user": "www-data"}, tags)
+}
+
+func TestGather_CombinedSelectorsNoMatch(t *testing.T) {
+	p := Procstat{
+		Exe:  "nginx",
+		User: "root",
+		createPIDFinder: func() (PIDFinder, error) {
+			return &selectorFinder{
+				pids: map[string][]PID{
+					"exe":  {100, 101},
+					"user": {1, 200},
+				},
+			}, nil
+		},
+		createProcess: newTestProc,
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+	require.Empty(t, p.procs)
+	assert.Equal(t, 0, acc.Metrics[0].Fields["pid_count"])
+}
@@ -0,0 +1,211 @@
+package prometheus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	addressLabel     = "__address__"
+	schemeLabel      = "__scheme__"
+	metricsPathLabel = "__metrics_path__"
+	paramLabelPrefix = "__param_"
+	metaFilepath     = "__meta_filepath"
+	metricNameLabel  = "__name__"
+	instanceLabel    = "instance"
+)
+
+// StaticConfig is a group of targets sharing the same labels.
+type StaticConfig struct {
+	Targets []string          `toml:"targets" json:"targets"`
+	Labels  map[string]string `toml:"labels" json:"labels"`
+}
+
+// FileSDConfig reads the target groups from JSON or YAML files in the format
+// of the Prometheus file_sd_config.  The files are read again when they
+// change.
+type FileSDConfig struct {
+	Files []string `toml:"files"`
+}
+
+// sdFile is a target file along with the state it was read in.
+type sdFile struct {
+	modTime time.Time
+	size    int64
+	groups  []StaticConfig
+}
+
+// refreshFiles reads the target files that are new or changed since the last
+// call.  A file that cannot be read keeps its previous targets.
+func (p *Prometheus) refreshFiles() {
+	if p.sdFiles == nil {
+		p.sdFiles = make(map[string]*sdFile)
+	}
+
+	seen := make(map[string]bool)
+	for _, sd := range p.FileSDConfigs {
+		for _, pattern := range sd.Files {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				p.Log.Errorf("Invalid file_sd pattern %q: %v", pattern, err)
+				continue
+			}
+
+			for _, path := range matches {
+				seen[path] = true
+
+				info, err := os.Stat(path)
+				if err != nil {
+					p.Log.Errorf("Could not read target file %q: %v", path, err)
+					continue
+				}
+
+				previous, ok := p.sdFiles[path]
+				if ok && previous.modTime.Equal(info.ModTime()) && previous.size == info.Size() {
+					continue
+				}
+
+				groups, err := readTargetFile(path)
+				if err != nil {
+					p.Log.Errorf("Could not read target file %q: %v", path, err)
+					continue
+				}
+				p.Log.Debugf("Read %d target groups from %q", len(groups), path)
+
+				p.sdFiles[path] = &sdFile{
+					modTime: info.ModTime(),
+					size:    info.Size(),
+					groups:  groups,
+				}
+			}
+		}
+	}
+
+	for path := range p.sdFiles {
+		if !seen[path] {
+			delete(p.sdFiles, path)
+		}
+	}
+}
+
+func readTargetFile(path string) ([]StaticConfig, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is a superset of JSON, so both formats are parsed the same way.
+	var groups []StaticConfig
+	if err := yaml.Unmarshal(content, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// discoveredURLs returns the targets of the static and file based service
+// discovery, after applying the relabel configs.
+func (p *Prometheus) discoveredURLs() map[string]URLAndAddress {
+	urls := make(map[string]URLAndAddress)
+
+	for _, group := range p.StaticConfigs {
+		p.addTargets(urls, group, nil)
+	}
+
+	if len(p.FileSDConfigs) > 0 {
+		p.refreshFiles()
+
+		paths := make([]string, 0, len(p.sdFiles))
+		for path := range p.sdFiles {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			for _, group := range p.sdFiles[path].groups {
+				p.addTargets(urls, group, map[string]string{metaFilepath: path})
+			}
+		}
+	}
+
+	return urls
+}
+
+func (p *Prometheus) addTargets(urls map[string]URLAndAddress, group StaticConfig, meta map[string]string) {
+	for _, target := range group.Targets {
+		labels := map[string]string{
+			schemeLabel:      "http",
+			metricsPathLabel: "/metrics",
+		}
+		for k, v := range meta {
+			labels[k] = v
+		}
+		for k, v := range group.Labels {
+			labels[k] = v
+		}
+		labels[addressLabel] = target
+
+		if !relabel(labels, p.RelabelConfigs) {
+			continue
+		}
+
+		u, err := targetURL(labels)
+		if err != nil {
+			p.Log.Errorf("Skipping target %q: %v", target, err)
+			continue
+		}
+
+		if _, ok := labels[instanceLabel]; !ok {
+			labels[instanceLabel] = labels[addressLabel]
+		}
+
+		urls[u.String()] = URLAndAddress{
+			URL:         u,
+			OriginalURL: u,
+			Tags:        publicLabels(labels),
+		}
+	}
+}
+
+// targetURL builds the URL to scrape from the labels of a target.
+func targetURL(labels map[string]string) (*url.URL, error) {
+	address := labels[addressLabel]
+	if address == "" {
+		return nil, fmt.Errorf("no address")
+	}
+
+	params := url.Values{}
+	for k, v := range labels {
+		if strings.HasPrefix(k, paramLabelPrefix) {
+			params.Set(strings.TrimPrefix(k, paramLabelPrefix), v)
+		}
+	}
+
+	u, err := url.Parse(labels[schemeLabel] + "://" + address + labels[metricsPathLabel])
+	if err != nil {
+		return nil, err
+	}
+	if len(params) > 0 {
+		u.RawQuery = params.Encode()
+	}
+	return u, nil
+}
+
+// publicLabels returns the labels without the reserved ones starting with
+// two underscores.
+func publicLabels(labels map[string]string) map[string]string {
+	public := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if !strings.HasPrefix(k, "__") {
+			public[k] = v
+		}
+	}
+	return public
+}
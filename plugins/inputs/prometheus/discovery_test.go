@@ -0,0 +1,118 @@
+package prometheus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticConfigs(t *testing.T) {
+	p := &Prometheus{
+		Log: testutil.Logger{},
+		StaticConfigs: []StaticConfig{
+			{
+				Targets: []string{"a:9100", "b:9100"},
+				Labels:  map[string]string{"env": "prod", "__metrics_path__": "/probe", "__param_module": "http_2xx"},
+			},
+			{
+				Targets: []string{"c:9100"},
+			},
+		},
+		RelabelConfigs: []*RelabelConfig{
+			{SourceLabels: []string{"__address__"}, Regex: "b:.*", Action: "drop"},
+			{SourceLabels: []string{"__address__"}, Regex: `([^:]+):\d+`, TargetLabel: "host"},
+		},
+	}
+	require.NoError(t, p.Init())
+
+	urls := p.discoveredURLs()
+	require.Len(t, urls, 2)
+	require.Equal(t, map[string]string{"env": "prod", "host": "a", "instance": "a:9100"}, urls["http://a:9100/probe?module=http_2xx"].Tags)
+	require.Equal(t, map[string]string{"host": "c", "instance": "c:9100"}, urls["http://c:9100/metrics"].Tags)
+}
+
+func TestFileSDConfigs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file_sd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	jsonFile := filepath.Join(dir, "targets.json")
+	require.NoError(t, ioutil.WriteFile(jsonFile, []byte(`[{"targets": ["a:9100"], "labels": {"env": "prod"}}]`), 0644))
+	yamlFile := filepath.Join(dir, "targets.yml")
+	require.NoError(t, ioutil.WriteFile(yamlFile, []byte("- targets:\n  - b:9100\n  labels:\n    env: dev\n"), 0644))
+
+	p := &Prometheus{
+		Log: testutil.Logger{},
+		FileSDConfigs: []FileSDConfig{
+			{Files: []string{filepath.Join(dir, "*.json"), filepath.Join(dir, "*.yml")}},
+		},
+		RelabelConfigs: []*RelabelConfig{
+			{SourceLabels: []string{"__meta_filepath"}, Regex: `.*/(.*)\..*`, TargetLabel: "file"},
+		},
+	}
+	require.NoError(t, p.Init())
+
+	urls := p.discoveredURLs()
+	require.Len(t, urls, 2)
+	require.Equal(t, map[string]string{"env": "prod", "file": "targets", "instance": "a:9100"}, urls["http://a:9100/metrics"].Tags)
+	require.Equal(t, map[string]string{"env": "dev", "file": "targets", "instance": "b:9100"}, urls["http://b:9100/metrics"].Tags)
+
+	// Changed files are read again.
+	require.NoError(t, ioutil.WriteFile(jsonFile, []byte(`[{"targets": ["c:9100", "d:9100"]}]`), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(jsonFile, later, later))
+	urls = p.discoveredURLs()
+	require.Len(t, urls, 3)
+	require.Contains(t, urls, "http://d:9100/metrics")
+
+	// An invalid file keeps the previous targets.
+	require.NoError(t, ioutil.WriteFile(jsonFile, []byte(`[{"targets": `), 0644))
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(jsonFile, later, later))
+	require.Len(t, p.discoveredURLs(), 3)
+
+	// Removed files remove their targets.
+	require.NoError(t, os.Remove(yamlFile))
+	require.Len(t, p.discoveredURLs(), 2)
+}
+
+func TestGatherDiscoveredTargets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, sampleTextFormat)
+	}))
+	defer ts.Close()
+
+	p := &Prometheus{
+		Log:    testutil.Logger{},
+		URLTag: "url",
+		StaticConfigs: []StaticConfig{
+			{
+				Targets: []string{strings.TrimPrefix(ts.URL, "http://")},
+				Labels:  map[string]string{"env": "prod"},
+			},
+		},
+		MetricRelabelConfigs: []*RelabelConfig{
+			{SourceLabels: []string{"__name__"}, Regex: "go_.*", Action: "drop"},
+		},
+	}
+	require.NoError(t, p.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	require.False(t, acc.HasMeasurement("go_goroutines"))
+	require.False(t, acc.HasMeasurement("go_gc_duration_seconds"))
+	require.True(t, acc.HasMeasurement("test_metric"))
+	require.True(t, acc.HasTag("test_metric", "env"))
+	require.Equal(t, "prod", acc.TagValue("test_metric", "env"))
+	require.Equal(t, ts.URL+"/metrics", acc.TagValue("test_metric", "url"))
+}
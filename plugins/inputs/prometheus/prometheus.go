@@ -43,6 +43,14 @@ type Prometheus struct {
 
 	URLTag string `toml:"url_tag"`
 
+	// Targets discovered from static groups and files
+	StaticConfigs []StaticConfig `toml:"static_configs"`
+	FileSDConfigs []FileSDConfig `toml:"file_sd_configs"`
+
+	// Relabeling of the discovered targets and of the scraped series
+	RelabelConfigs       []*RelabelConfig `toml:"relabel_configs"`
+	MetricRelabelConfigs []*RelabelConfig `toml:"metric_relabel_configs"`
+
 	tls.ClientConfig
 
 	Log telegraf.Logger
@@ -54,6 +62,7 @@ type Prometheus struct {
 	PodNamespace   string `toml:"monitor_kubernetes_pods_namespace"`
 	lock           sync.Mutex
 	kubernetesPods map[string]URLAndAddress
+	sdFiles        map[string]*sdFile
 	cancel         context.CancelFunc
 	wg             sync.WaitGroup
 }
@@ -74,6 +83,35 @@ var sampleConfig = `
   ## Url tag name (tag containing scrapped url. optional, default is "url")
   # url_tag = "scrapeUrl"
 
+  ## Static groups of targets, the labels are added as tags to the metrics of
+  ## the targets.
+  # [[inputs.prometheus.static_configs]]
+  #   targets = ["localhost:9100"]
+  #   [inputs.prometheus.static_configs.labels]
+  #     env = "production"
+
+  ## Files containing groups of targets in the JSON or YAML format of the
+  ## Prometheus file_sd_config.  The files are read again when they change.
+  # [[inputs.prometheus.file_sd_configs]]
+  #   files = ["/etc/prometheus/targets/*.json"]
+
+  ## Relabeling of the static and file targets, the keep, drop, replace and
+  ## labelmap actions are supported.  The __address__, __scheme__,
+  ## __metrics_path__ and __param_<name> labels define the URL to scrape.
+  # [[inputs.prometheus.relabel_configs]]
+  #   source_labels = ["__address__"]
+  #   regex = "([^:]+):\d+"
+  #   target_label = "host"
+  #   replacement = "$1"
+  #   action = "replace"
+
+  ## Relabeling of the scraped series, the __name__ label contains the name of
+  ## the metric.  Series dropped by the relabeling are not collected.
+  # [[inputs.prometheus.metric_relabel_configs]]
+  #   source_labels = ["__name__"]
+  #   regex = "go_.*"
+  #   action = "drop"
+
   ## An array of Kubernetes services to scrape metrics from.
   # kubernetes_services = ["http://my-service-dns.my-namespace:9100/metrics"]
 
@@ -124,6 +162,17 @@ func (p *Prometheus) Init() error {
 	if p.MetricVersion != 2 {
 		p.Log.Warnf("Use of deprecated configuration: 'metric_version = 1'; please update to 'metric_version = 2'")
 	}
+
+	for _, c := range p.RelabelConfigs {
+		if err := c.init(); err != nil {
+			return err
+		}
+	}
+	for _, c := range p.MetricRelabelConfigs {
+		if err := c.init(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -166,6 +215,10 @@ func (p *Prometheus) GetAllURLs() (map[string]URLAndAddress, error) {
 		allURLs[URL.String()] = URLAndAddress{URL: URL, OriginalURL: URL}
 	}
 
+	for k, v := range p.discoveredURLs() {
+		allURLs[k] = v
+	}
+
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	// loop through all pods scraped via the prometheus annotation on the pods
@@ -334,17 +387,26 @@ func (p *Prometheus) gatherURL(u URLAndAddress, acc telegraf.Accumulator) error
 			tags[k] = v
 		}
 
+		name, fields := metric.Name(), metric.Fields()
+		if len(p.MetricRelabelConfigs) > 0 {
+			var ok bool
+			name, fields, ok = p.relabelMetric(metric, tags)
+			if !ok {
+				continue
+			}
+		}
+
 		switch metric.Type() {
 		case telegraf.Counter:
-			acc.AddCounter(metric.Name(), metric.Fields(), tags, metric.Time())
+			acc.AddCounter(name, fields, tags, metric.Time())
 		case telegraf.Gauge:
-			acc.AddGauge(metric.Name(), metric.Fields(), tags, metric.Time())
+			acc.AddGauge(name, fields, tags, metric.Time())
 		case telegraf.Summary:
-			acc.AddSummary(metric.Name(), metric.Fields(), tags, metric.Time())
+			acc.AddSummary(name, fields, tags, metric.Time())
 		case telegraf.Histogram:
-			acc.AddHistogram(metric.Name(), metric.Fields(), tags, metric.Time())
+			acc.AddHistogram(name, fields, tags, metric.Time())
 		default:
-			acc.AddFields(metric.Name(), metric.Fields(), tags, metric.Time())
+			acc.AddFields(name, fields, tags, metric.Time())
 		}
 	}
 
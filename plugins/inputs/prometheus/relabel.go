@@ -0,0 +1,169 @@
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// RelabelConfig is a subset of the Prometheus relabel_config, supporting the
+// replace, keep, drop and labelmap actions.
+type RelabelConfig struct {
+	SourceLabels []string `toml:"source_labels"`
+	Separator    string   `toml:"separator"`
+	Regex        string   `toml:"regex"`
+	TargetLabel  string   `toml:"target_label"`
+	Replacement  string   `toml:"replacement"`
+	Action       string   `toml:"action"`
+
+	regex *regexp.Regexp
+}
+
+func (c *RelabelConfig) init() error {
+	if c.Separator == "" {
+		c.Separator = ";"
+	}
+	if c.Regex == "" {
+		c.Regex = "(.*)"
+	}
+	if c.Replacement == "" {
+		c.Replacement = "$1"
+	}
+	if c.Action == "" {
+		c.Action = "replace"
+	}
+
+	switch c.Action {
+	case "replace":
+		if c.TargetLabel == "" {
+			return fmt.Errorf("relabel action %q requires a target_label", c.Action)
+		}
+	case "keep", "drop":
+		if len(c.SourceLabels) == 0 {
+			return fmt.Errorf("relabel action %q requires source_labels", c.Action)
+		}
+	case "labelmap":
+	default:
+		return fmt.Errorf("unknown relabel action %q", c.Action)
+	}
+
+	// Like Prometheus the regular expression must match the whole value.
+	regex, err := regexp.Compile("^(?:" + c.Regex + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid relabel regex %q: %v", c.Regex, err)
+	}
+	c.regex = regex
+	return nil
+}
+
+// apply relabels the labels in place, it returns false if the labels are
+// dropped.
+func (c *RelabelConfig) apply(labels map[string]string) bool {
+	values := make([]string, 0, len(c.SourceLabels))
+	for _, name := range c.SourceLabels {
+		values = append(values, labels[name])
+	}
+	value := strings.Join(values, c.Separator)
+
+	switch c.Action {
+	case "keep":
+		return c.regex.MatchString(value)
+	case "drop":
+		return !c.regex.MatchString(value)
+	case "replace":
+		indexes := c.regex.FindStringSubmatchIndex(value)
+		if indexes == nil {
+			return true
+		}
+		result := string(c.regex.ExpandString(nil, c.Replacement, value, indexes))
+		if result == "" {
+			delete(labels, c.TargetLabel)
+		} else {
+			labels[c.TargetLabel] = result
+		}
+	case "labelmap":
+		mapped := make(map[string]string)
+		for name, v := range labels {
+			indexes := c.regex.FindStringSubmatchIndex(name)
+			if indexes == nil {
+				continue
+			}
+			mapped[string(c.regex.ExpandString(nil, c.Replacement, name, indexes))] = v
+		}
+		for name, v := range mapped {
+			labels[name] = v
+		}
+	}
+	return true
+}
+
+// relabel applies the configs in order, it returns false as soon as the labels
+// are dropped.
+func relabel(labels map[string]string, configs []*RelabelConfig) bool {
+	for _, c := range configs {
+		if !c.apply(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// relabelMetric applies the metric relabel configs to a scraped series, the
+// tags are relabeled in place.  It returns the name and fields of the metric
+// after relabeling, or false if the series is dropped.
+func (p *Prometheus) relabelMetric(m telegraf.Metric, tags map[string]string) (string, map[string]interface{}, bool) {
+	name := p.seriesName(m)
+	tags[metricNameLabel] = name
+	if !relabel(tags, p.MetricRelabelConfigs) {
+		return "", nil, false
+	}
+
+	newName := tags[metricNameLabel]
+	for k := range tags {
+		if strings.HasPrefix(k, "__") {
+			delete(tags, k)
+		}
+	}
+
+	fields := m.Fields()
+	if newName == "" || newName == name {
+		return m.Name(), fields, true
+	}
+
+	// The measurement holds the metric name with metric_version 1, the
+	// field key with metric_version 2.
+	if p.MetricVersion != 2 {
+		return newName, fields, true
+	}
+	if len(fields) == 1 {
+		for k, v := range fields {
+			delete(fields, k)
+			fields[newName] = v
+		}
+	}
+	return m.Name(), fields, true
+}
+
+// seriesName returns the Prometheus name of the series.  With
+// metric_version 2 the sum and count of summaries and histograms share a
+// metric, the name is then the name of the summary or histogram.
+func (p *Prometheus) seriesName(m telegraf.Metric) string {
+	if p.MetricVersion != 2 {
+		return m.Name()
+	}
+
+	fields := m.FieldList()
+	if len(fields) == 1 {
+		return fields[0].Key
+	}
+	for _, field := range fields {
+		for _, suffix := range []string{"_sum", "_count"} {
+			if strings.HasSuffix(field.Key, suffix) {
+				return strings.TrimSuffix(field.Key, suffix)
+			}
+		}
+	}
+	return m.Name()
+}
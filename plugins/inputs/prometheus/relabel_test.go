@@ -0,0 +1,153 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   RelabelConfig
+		labels   map[string]string
+		expected map[string]string
+	}{
+		{
+			name: "replace",
+			config: RelabelConfig{
+				SourceLabels: []string{"__address__"},
+				Regex:        `([^:]+):\d+`,
+				TargetLabel:  "host",
+			},
+			labels:   map[string]string{"__address__": "example.org:9100"},
+			expected: map[string]string{"__address__": "example.org:9100", "host": "example.org"},
+		},
+		{
+			name: "replace joins source labels",
+			config: RelabelConfig{
+				SourceLabels: []string{"env", "dc"},
+				Separator:    "-",
+				TargetLabel:  "zone",
+				Replacement:  "zone-$1",
+			},
+			labels:   map[string]string{"env": "prod", "dc": "eu"},
+			expected: map[string]string{"env": "prod", "dc": "eu", "zone": "zone-prod-eu"},
+		},
+		{
+			name: "replace without match",
+			config: RelabelConfig{
+				SourceLabels: []string{"env"},
+				Regex:        "prod",
+				TargetLabel:  "zone",
+			},
+			labels:   map[string]string{"env": "production"},
+			expected: map[string]string{"env": "production"},
+		},
+		{
+			name: "replace with empty value removes the label",
+			config: RelabelConfig{
+				SourceLabels: []string{"missing"},
+				TargetLabel:  "env",
+			},
+			labels:   map[string]string{"env": "prod"},
+			expected: map[string]string{},
+		},
+		{
+			name: "labelmap",
+			config: RelabelConfig{
+				Regex:  "__meta_(.+)",
+				Action: "labelmap",
+			},
+			labels:   map[string]string{"__meta_rack": "r1", "env": "prod"},
+			expected: map[string]string{"__meta_rack": "r1", "rack": "r1", "env": "prod"},
+		},
+		{
+			name: "keep",
+			config: RelabelConfig{
+				SourceLabels: []string{"env"},
+				Regex:        "prod|staging",
+				Action:       "keep",
+			},
+			labels:   map[string]string{"env": "staging"},
+			expected: map[string]string{"env": "staging"},
+		},
+		{
+			name: "keep drops",
+			config: RelabelConfig{
+				SourceLabels: []string{"env"},
+				Regex:        "prod",
+				Action:       "keep",
+			},
+			labels: map[string]string{"env": "production"},
+		},
+		{
+			name: "drop",
+			config: RelabelConfig{
+				SourceLabels: []string{"env"},
+				Regex:        "dev",
+				Action:       "drop",
+			},
+			labels: map[string]string{"env": "dev"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.config.init())
+			ok := relabel(tt.labels, []*RelabelConfig{&tt.config})
+			if tt.expected == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tt.expected, tt.labels)
+		})
+	}
+}
+
+func TestRelabelInvalid(t *testing.T) {
+	for _, c := range []RelabelConfig{
+		{Action: "hashmod"},
+		{Action: "replace"},
+		{Action: "keep"},
+		{Regex: "(", TargetLabel: "a"},
+	} {
+		require.Error(t, c.init())
+	}
+}
+
+func TestRelabelMetric(t *testing.T) {
+	drop := &RelabelConfig{SourceLabels: []string{"__name__"}, Regex: "go_.*", Action: "drop"}
+	rename := &RelabelConfig{SourceLabels: []string{"__name__"}, Regex: "(.*)_total", TargetLabel: "__name__"}
+	require.NoError(t, drop.init())
+	require.NoError(t, rename.init())
+
+	p := &Prometheus{MetricRelabelConfigs: []*RelabelConfig{drop, rename}}
+	now := time.Unix(0, 0)
+
+	m := testutil.MustMetric("go_goroutines", map[string]string{}, map[string]interface{}{"gauge": 15.0}, now)
+	_, _, ok := p.relabelMetric(m, m.Tags())
+	require.False(t, ok)
+
+	m = testutil.MustMetric("http_requests_total", map[string]string{"code": "200"}, map[string]interface{}{"counter": 3.0}, now)
+	tags := m.Tags()
+	name, fields, ok := p.relabelMetric(m, tags)
+	require.True(t, ok)
+	require.Equal(t, "http_requests", name)
+	require.Equal(t, map[string]interface{}{"counter": 3.0}, fields)
+	require.Equal(t, map[string]string{"code": "200"}, tags)
+
+	// With metric_version 2 the field holds the metric name.
+	p.MetricVersion = 2
+	m = testutil.MustMetric("prometheus", map[string]string{}, map[string]interface{}{"http_requests_total": 3.0}, now)
+	name, fields, ok = p.relabelMetric(m, m.Tags())
+	require.True(t, ok)
+	require.Equal(t, "prometheus", name)
+	require.Equal(t, map[string]interface{}{"http_requests": 3.0}, fields)
+
+	m = testutil.MustMetric("prometheus", map[string]string{}, map[string]interface{}{"go_gc_duration_seconds_sum": 1.0, "go_gc_duration_seconds_count": 7.0}, now)
+	_, _, ok = p.relabelMetric(m, m.Tags())
+	require.False(t, ok)
+}
@@ -5,6 +5,7 @@ package sensors
 import (
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
@@ -23,13 +24,15 @@ var (
 )
 
 type Sensors struct {
-	RemoveNumbers bool              `toml:"remove_numbers"`
-	Timeout       internal.Duration `toml:"timeout"`
-	path          string
+	RemoveNumbers     bool              `toml:"remove_numbers"`
+	UseSensorsCommand bool              `toml:"use_sensors_command"`
+	Timeout           internal.Duration `toml:"timeout"`
+	HostSys           string            `toml:"host_sys"`
+	path              string
 }
 
 func (*Sensors) Description() string {
-	return "Monitor sensors, requires lm-sensors package"
+	return "Monitor hardware sensors from the hwmon and thermal sysfs interfaces"
 }
 
 func (*Sensors) SampleConfig() string {
@@ -38,13 +41,28 @@ func (*Sensors) SampleConfig() string {
   ## If true, a field name like 'temp1_input' will be changed to 'temp_input'.
   # remove_numbers = true
 
+  ## Run the sensors command of the lm-sensors package instead of reading
+  ## sysfs, for instance to apply the computations of its configuration.
+  # use_sensors_command = false
+
   ## Timeout is the maximum amount of time that the sensors command can run.
   # timeout = "5s"
+
+  ## Path of the sysfs mount, defaults to the HOST_SYS environment variable
+  ## or /sys.
+  # host_sys = "/sys"
 `
 
 }
 
 func (s *Sensors) Gather(acc telegraf.Accumulator) error {
+	if !s.UseSensorsCommand {
+		if s.HostSys == "" {
+			s.HostSys = sys(envSys, defaultHostSys)
+		}
+		return s.gatherSysfs(acc)
+	}
+
 	if len(s.path) == 0 {
 		return errors.New("sensors not found: verify that lm-sensors package is installed and that sensors is in your PATH")
 	}
@@ -52,6 +70,14 @@ func (s *Sensors) Gather(acc telegraf.Accumulator) error {
 	return s.parse(acc)
 }
 
+// sys can be used to read file paths from env
+func sys(env, path string) string {
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	return path
+}
+
 // parse forks the command:
 //     sensors -u -A
 // and parses the output to add it to the telegraf.Accumulator.
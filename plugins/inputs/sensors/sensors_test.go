@@ -4,18 +4,25 @@ package sensors
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGatherDefault(t *testing.T) {
 	s := Sensors{
-		RemoveNumbers: true,
-		Timeout:       defaultTimeout,
-		path:          "sensors",
+		RemoveNumbers:     true,
+		UseSensorsCommand: true,
+		Timeout:           defaultTimeout,
+		path:              "sensors",
 	}
 	// overwriting exec commands with mock commands
 	execCommand = fakeExecCommand
@@ -154,9 +161,10 @@ func TestGatherDefault(t *testing.T) {
 
 func TestGatherNotRemoveNumbers(t *testing.T) {
 	s := Sensors{
-		RemoveNumbers: false,
-		Timeout:       defaultTimeout,
-		path:          "sensors",
+		RemoveNumbers:     false,
+		UseSensorsCommand: true,
+		Timeout:           defaultTimeout,
+		path:              "sensors",
 	}
 	// overwriting exec commands with mock commands
 	execCommand = fakeExecCommand
@@ -381,3 +389,183 @@ Vcore Voltage:
 	}
 	os.Exit(0)
 }
+
+// sysfs is a fixture of the hwmon and thermal sysfs interfaces, the entries
+// starting with '@' are symlinks.
+var sysfs = map[string]string{
+	// virtual device
+	"class/hwmon/hwmon0/name":        "acpitz",
+	"class/hwmon/hwmon0/temp1_input": "8300",
+	"class/hwmon/hwmon0/temp1_crit":  "31300",
+
+	// platform device
+	"class/hwmon/hwmon1/name":                "coretemp",
+	"@class/hwmon/hwmon1/device":             "../../../devices/platform/coretemp.0",
+	"@devices/platform/coretemp.0/subsystem": "../../../bus/platform",
+	"class/hwmon/hwmon1/temp1_label":         "Physical id 0",
+	"class/hwmon/hwmon1/temp1_input":         "77000",
+	"class/hwmon/hwmon1/temp1_max":           "82000",
+	"class/hwmon/hwmon1/temp1_crit":          "92000",
+	"class/hwmon/hwmon1/temp1_crit_alarm":    "0",
+	"class/hwmon/hwmon1/temp2_label":         "Core 0",
+	"class/hwmon/hwmon1/temp2_input":         "75000",
+	"class/hwmon/hwmon1/temp2_max":           "82000",
+	"class/hwmon/hwmon1/temp2_crit":          "92000",
+	"class/hwmon/hwmon1/temp2_crit_alarm":    "1",
+
+	// pci device
+	"class/hwmon/hwmon2/name":                    "k10temp",
+	"@class/hwmon/hwmon2/device":                 "../../../devices/pci0000:00/0000:00:18.3",
+	"@devices/pci0000:00/0000:00:18.3/subsystem": "../../../bus/pci",
+	"class/hwmon/hwmon2/temp1_input":             "29125",
+	"class/hwmon/hwmon2/temp1_max":               "70000",
+	"class/hwmon/hwmon2/temp1_crit":              "70000",
+	"class/hwmon/hwmon2/temp1_crit_hyst":         "65000",
+	"devices/pci0000:00/0000:00:18.3/vendor":     "0x1022",
+
+	// acpi device with the attributes on the device
+	"@class/hwmon/hwmon3/device":                              "../../../devices/LNXSYSTM:00/ACPI000D:00",
+	"@devices/LNXSYSTM:00/ACPI000D:00/subsystem":              "../../../bus/acpi",
+	"devices/LNXSYSTM:00/ACPI000D:00/name":                    "power_meter",
+	"devices/LNXSYSTM:00/ACPI000D:00/power1_average":          "0",
+	"devices/LNXSYSTM:00/ACPI000D:00/power1_average_interval": "300000",
+
+	// platform device with an address
+	"class/hwmon/hwmon4/name":                 "nct6775",
+	"@class/hwmon/hwmon4/device":              "../../../devices/platform/nct6775.656",
+	"@devices/platform/nct6775.656/subsystem": "../../../bus/platform",
+	"class/hwmon/hwmon4/in0_input":            "1016",
+	"class/hwmon/hwmon4/in0_min":              "0",
+	"class/hwmon/hwmon4/in0_max":              "1744",
+	"class/hwmon/hwmon4/in0_alarm":            "1",
+	"class/hwmon/hwmon4/fan2_input":           "1200",
+	"class/hwmon/hwmon4/fan2_min":             "300",
+	"class/hwmon/hwmon4/fan2_alarm":           "0",
+	"class/hwmon/hwmon4/fan3_input":           "",
+	"class/hwmon/hwmon4/pwm1":                 "128",
+
+	// i2c device
+	"class/hwmon/hwmon5/name":                                 "jc42",
+	"@class/hwmon/hwmon5/device":                              "../../../devices/pci0000:00/0000:00:1f.3/i2c-0/0-0018",
+	"@devices/pci0000:00/0000:00:1f.3/i2c-0/0-0018/subsystem": "../../../../../bus/i2c",
+	"class/hwmon/hwmon5/temp1_input":                          "33250",
+	"class/hwmon/hwmon5/temp1_max":                            "85000",
+	"class/hwmon/hwmon5/temp1_max_alarm":                      "0",
+
+	// thermal zone registered as hwmon device
+	"class/thermal/thermal_zone0/type":        "acpitz",
+	"class/thermal/thermal_zone0/temp":        "8300",
+	"class/thermal/thermal_zone0/hwmon0/name": "acpitz",
+
+	"class/thermal/thermal_zone1/type":              "x86_pkg_temp",
+	"class/thermal/thermal_zone1/temp":              "45000",
+	"class/thermal/thermal_zone1/trip_point_0_type": "passive",
+	"class/thermal/thermal_zone1/trip_point_0_temp": "0",
+	"class/thermal/thermal_zone1/trip_point_1_type": "critical",
+	"class/thermal/thermal_zone1/trip_point_1_temp": "100000",
+	"class/thermal/thermal_zone1/trip_point_2_type": "hot",
+	"class/thermal/thermal_zone1/trip_point_2_temp": "95000",
+
+	"class/thermal/cooling_device0/type": "Processor",
+}
+
+func writeSysfs(t *testing.T) string {
+	root, err := ioutil.TempDir("", "sensors")
+	require.NoError(t, err)
+
+	for name, content := range sysfs {
+		path := filepath.Join(root, strings.TrimPrefix(name, "@"))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		if strings.HasPrefix(name, "@") {
+			require.NoError(t, os.Symlink(content, path))
+			continue
+		}
+		require.NoError(t, ioutil.WriteFile(path, []byte(content+"\n"), 0644))
+	}
+	return root
+}
+
+func TestGatherSysfs(t *testing.T) {
+	root := writeSysfs(t)
+	defer os.RemoveAll(root)
+
+	s := Sensors{
+		RemoveNumbers: true,
+		HostSys:       root,
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("sensors",
+			map[string]string{"chip": "acpitz-virtual-0", "feature": "temp1"},
+			map[string]interface{}{"temp_input": 8.3, "temp_crit": 31.3},
+			time.Unix(0, 0)),
+		testutil.MustMetric("sensors",
+			map[string]string{"chip": "coretemp-isa-0000", "feature": "physical_id_0"},
+			map[string]interface{}{"temp_input": 77.0, "temp_max": 82.0, "temp_crit": 92.0, "temp_crit_alarm": 0.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("sensors",
+			map[string]string{"chip": "coretemp-isa-0000", "feature": "core_0"},
+			map[string]interface{}{"temp_input": 75.0, "temp_max": 82.0, "temp_crit": 92.0, "temp_crit_alarm": 1.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("sensors",
+			map[string]string{"chip": "k10temp-pci-00c3", "feature": "temp1"},
+			map[string]interface{}{"temp_input": 29.125, "temp_max": 70.0, "temp_crit": 70.0, "temp_crit_hyst": 65.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("sensors",
+			map[string]string{"chip": "power_meter-acpi-0", "feature": "power1"},
+			map[string]interface{}{"power_average": 0.0, "power_average_interval": 300.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("sensors",
+			map[string]string{"chip": "nct6775-isa-0290", "feature": "fan2"},
+			map[string]interface{}{"fan_input": 1200.0, "fan_min": 300.0, "fan_alarm": 0.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("sensors",
+			map[string]string{"chip": "nct6775-isa-0290", "feature": "in0"},
+			map[string]interface{}{"in_input": 1.016, "in_min": 0.0, "in_max": 1.744, "in_alarm": 1.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("sensors",
+			map[string]string{"chip": "jc42-i2c-0-18", "feature": "temp1"},
+			map[string]interface{}{"temp_input": 33.25, "temp_max": 85.0, "temp_max_alarm": 0.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("sensors",
+			map[string]string{"chip": "thermal_zone1", "feature": "x86_pkg_temp"},
+			map[string]interface{}{"temp_input": 45.0, "temp_crit": 100.0, "temp_max": 95.0},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherSysfsNotRemoveNumbers(t *testing.T) {
+	root := writeSysfs(t)
+	defer os.RemoveAll(root)
+
+	s := Sensors{
+		HostSys: root,
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	acc.AssertContainsTaggedFields(t, "sensors",
+		map[string]interface{}{"temp2_input": 75.0, "temp2_max": 82.0, "temp2_crit": 92.0, "temp2_crit_alarm": 1.0},
+		map[string]string{"chip": "coretemp-isa-0000", "feature": "core_0"})
+	acc.AssertContainsTaggedFields(t, "sensors",
+		map[string]interface{}{"temp1_input": 45.0, "temp1_crit": 100.0, "temp1_max": 95.0},
+		map[string]string{"chip": "thermal_zone1", "feature": "x86_pkg_temp"})
+}
+
+func TestGatherSysfsHostSys(t *testing.T) {
+	root := writeSysfs(t)
+	defer os.RemoveAll(root)
+
+	os.Setenv("HOST_SYS", root)
+	defer os.Unsetenv("HOST_SYS")
+
+	s := Sensors{}
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+	require.Equal(t, root, s.HostSys)
+	require.Len(t, acc.Metrics, 9)
+}
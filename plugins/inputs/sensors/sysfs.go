@@ -0,0 +1,257 @@
+// +build linux
+
+package sensors
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+const defaultHostSys = "/sys"
+
+const envSys = "HOST_SYS"
+
+// subfeatureRegex matches the attributes of the hwmon sysfs interface, see
+// https://www.kernel.org/doc/Documentation/hwmon/sysfs-interface
+var subfeatureRegex = regexp.MustCompile(`^(in|fan|temp|power|curr|energy|humidity|intrusion)([0-9]+)_([a-z_]+)$`)
+
+// feature is a sensor of a chip with the values of its attributes.
+type feature struct {
+	name   string
+	fields map[string]interface{}
+}
+
+// gatherSysfs reads the hwmon devices and the thermal zones that are not
+// exposed as hwmon devices.
+func (s *Sensors) gatherSysfs(acc telegraf.Accumulator) error {
+	hwmons, err := filepath.Glob(filepath.Join(s.HostSys, "class", "hwmon", "hwmon*"))
+	if err != nil {
+		return err
+	}
+	for _, dir := range hwmons {
+		if err := s.gatherHwmon(acc, dir); err != nil {
+			acc.AddError(fmt.Errorf("reading %s: %v", dir, err))
+		}
+	}
+
+	zones, err := filepath.Glob(filepath.Join(s.HostSys, "class", "thermal", "thermal_zone*"))
+	if err != nil {
+		return err
+	}
+	for _, dir := range zones {
+		if err := s.gatherThermalZone(acc, dir); err != nil {
+			acc.AddError(fmt.Errorf("reading %s: %v", dir, err))
+		}
+	}
+	return nil
+}
+
+func (s *Sensors) gatherHwmon(acc telegraf.Accumulator, hwmon string) error {
+	dir := hwmon
+	name, err := readString(filepath.Join(dir, "name"))
+	if os.IsNotExist(err) {
+		// Older drivers expose the attributes on the device
+		dir = filepath.Join(hwmon, "device")
+		name, err = readString(filepath.Join(dir, "name"))
+	}
+	if err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	chip := chipName(name, hwmon)
+	features := make(map[string]*feature)
+	var order []string
+	for _, file := range files {
+		m := subfeatureRegex.FindStringSubmatch(file.Name())
+		if m == nil || file.IsDir() {
+			continue
+		}
+		kind, number, attribute := m[1], m[2], m[3]
+		if attribute == "label" {
+			continue
+		}
+
+		value, err := readFloat(filepath.Join(dir, file.Name()))
+		if err != nil {
+			// Unreadable and faulty attributes are skipped, like lm-sensors
+			// does.
+			continue
+		}
+
+		f, ok := features[kind+number]
+		if !ok {
+			f = &feature{name: kind + number, fields: make(map[string]interface{})}
+			if label, err := readString(filepath.Join(dir, kind+number+"_label")); err == nil && label != "" {
+				f.name = snake(label)
+			}
+			features[kind+number] = f
+			order = append(order, kind+number)
+		}
+		f.fields[s.fieldName(file.Name())] = value / scale(kind, attribute)
+	}
+
+	for _, key := range order {
+		tags := map[string]string{
+			"chip":    chip,
+			"feature": features[key].name,
+		}
+		acc.AddFields("sensors", features[key].fields, tags)
+	}
+	return nil
+}
+
+// gatherThermalZone reads a thermal zone, the zones registered as hwmon
+// devices are skipped as they are already reported.
+func (s *Sensors) gatherThermalZone(acc telegraf.Accumulator, dir string) error {
+	if hwmons, _ := filepath.Glob(filepath.Join(dir, "hwmon*")); len(hwmons) > 0 {
+		return nil
+	}
+
+	kind, err := readString(filepath.Join(dir, "type"))
+	if err != nil {
+		return err
+	}
+	temp, err := readFloat(filepath.Join(dir, "temp"))
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		s.fieldName("temp1_input"): temp / 1000,
+	}
+
+	// The critical and hot trip points are the equivalent of the crit and max
+	// thresholds of hwmon.
+	trips, _ := filepath.Glob(filepath.Join(dir, "trip_point_*_type"))
+	for _, trip := range trips {
+		tripType, err := readString(trip)
+		if err != nil {
+			continue
+		}
+		var attribute string
+		switch tripType {
+		case "critical":
+			attribute = "crit"
+		case "hot":
+			attribute = "max"
+		default:
+			continue
+		}
+		value, err := readFloat(strings.TrimSuffix(trip, "_type") + "_temp")
+		if err != nil {
+			continue
+		}
+		fields[s.fieldName("temp1_"+attribute)] = value / 1000
+	}
+
+	tags := map[string]string{
+		"chip":    filepath.Base(dir),
+		"feature": snake(kind),
+	}
+	acc.AddFields("sensors", fields, tags)
+	return nil
+}
+
+func (s *Sensors) fieldName(name string) string {
+	if s.RemoveNumbers {
+		return numberRegp.ReplaceAllString(name, "")
+	}
+	return name
+}
+
+// chipName returns the name given to the chip by libsensors, made of the
+// driver name, the bus and the address of the device.
+func chipName(name, hwmon string) string {
+	device, err := os.Readlink(filepath.Join(hwmon, "device"))
+	if err != nil {
+		// Virtual devices are numbered after the hwmon device
+		number := strings.TrimPrefix(filepath.Base(hwmon), "hwmon")
+		if _, err := strconv.Atoi(number); err != nil {
+			number = "0"
+		}
+		return name + "-virtual-" + number
+	}
+	device = filepath.Base(device)
+
+	subsystem, err := os.Readlink(filepath.Join(hwmon, "device", "subsystem"))
+	if err != nil {
+		return name + "-virtual-0"
+	}
+
+	switch filepath.Base(subsystem) {
+	case "i2c":
+		// <bus>-<address>, such as 0-0018
+		var bus, addr int
+		if _, err := fmt.Sscanf(device, "%d-%x", &bus, &addr); err == nil {
+			return fmt.Sprintf("%s-i2c-%d-%02x", name, bus, addr)
+		}
+	case "pci":
+		// <domain>:<bus>:<slot>.<function>, such as 0000:00:18.3
+		var domain, bus, slot, function int
+		if _, err := fmt.Sscanf(device, "%x:%x:%x.%x", &domain, &bus, &slot, &function); err == nil {
+			return fmt.Sprintf("%s-pci-%04x", name, domain<<16|bus<<8|slot<<3|function)
+		}
+	case "platform", "of_platform":
+		// <driver>.<address>, such as coretemp.0
+		var addr int
+		if i := strings.LastIndex(device, "."); i >= 0 {
+			addr, _ = strconv.Atoi(device[i+1:])
+		}
+		return fmt.Sprintf("%s-isa-%04x", name, addr)
+	case "acpi":
+		return name + "-acpi-0"
+	}
+	return name + "-virtual-0"
+}
+
+// scale returns the divisor converting a raw attribute value to the unit
+// reported by lm-sensors.
+func scale(kind, attribute string) float64 {
+	switch {
+	case strings.HasSuffix(attribute, "alarm"), strings.HasSuffix(attribute, "beep"):
+		return 1
+	case attribute == "fault", attribute == "type", attribute == "enable":
+		return 1
+	case strings.HasSuffix(attribute, "interval"):
+		// milliseconds
+		return 1000
+	}
+
+	switch kind {
+	case "in", "temp", "curr", "humidity":
+		// milli-volts, milli-degrees Celsius, milli-amperes, milli-percents
+		return 1000
+	case "power", "energy":
+		// micro-watts, micro-joules
+		return 1000000
+	}
+	return 1
+}
+
+func readString(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func readFloat(path string) (float64, error) {
+	s, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,119 @@
+package snmp_trap
+
+import (
+	"errors"
+)
+
+// BER tags of the SNMP message fields handled by the plugin itself.  The
+// variable bindings are left to gosnmp.
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagSequence    = 0x30
+
+	tagGetResponse   = 0xa2
+	tagInformRequest = 0xa6
+	tagSNMPv2Trap    = 0xa7
+	tagReport        = 0xa8
+)
+
+var errMalformed = errors.New("malformed message")
+
+// readTLV splits the first BER encoded element off b.  The returned slices
+// share the memory of b.
+func readTLV(b []byte) (tag byte, value []byte, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errMalformed
+	}
+	tag = b[0]
+
+	length := int(b[1])
+	offset := 2
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 || len(b) < 2+n {
+			return 0, nil, nil, errMalformed
+		}
+		length = 0
+		for _, c := range b[2 : 2+n] {
+			length = length<<8 | int(c)
+		}
+		offset += n
+	}
+
+	if length < 0 || len(b)-offset < length {
+		return 0, nil, nil, errMalformed
+	}
+	return tag, b[offset : offset+length], b[offset+length:], nil
+}
+
+// readExpected reads an element which must have the given tag.
+func readExpected(b []byte, tag byte) (value []byte, rest []byte, err error) {
+	t, value, rest, err := readTLV(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t != tag {
+		return nil, nil, errMalformed
+	}
+	return value, rest, nil
+}
+
+// readInteger reads an INTEGER element.
+func readInteger(b []byte) (v int64, rest []byte, err error) {
+	value, rest, err := readExpected(b, tagInteger)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(value) == 0 || len(value) > 8 {
+		return 0, nil, errMalformed
+	}
+	if value[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, c := range value {
+		v = v<<8 | int64(c)
+	}
+	return v, rest, nil
+}
+
+// encodeLength returns the BER encoding of a length.
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// encodeTLV returns the BER encoding of an element made of the values.
+func encodeTLV(tag byte, values ...[]byte) []byte {
+	var length int
+	for _, v := range values {
+		length += len(v)
+	}
+	b := append([]byte{tag}, encodeLength(length)...)
+	for _, v := range values {
+		b = append(b, v...)
+	}
+	return b
+}
+
+// encodeInteger returns the BER encoding of an INTEGER.
+func encodeInteger(v int64) []byte {
+	return encodeTLV(tagInteger, integerBytes(v))
+}
+
+// integerBytes returns the content octets of an integer, in two's complement
+// with the minimum number of octets.
+func integerBytes(v int64) []byte {
+	b := []byte{byte(v)}
+	for v > 127 || v < -128 {
+		v >>= 8
+		b = append([]byte{byte(v)}, b...)
+	}
+	return b
+}
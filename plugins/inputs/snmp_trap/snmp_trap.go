@@ -3,6 +3,8 @@ package snmp_trap
 import (
 	"bufio"
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"os/exec"
@@ -14,6 +16,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/selfstat"
 
 	"github.com/soniah/gosnmp"
 )
@@ -28,17 +31,52 @@ type mibEntry struct {
 	oidText string
 }
 
+// engineBoots is the number of times the SNMP engine of the plugin has
+// rebooted.  The boots are not persisted, the engine time restarts from zero
+// when the plugin starts.
+const engineBoots = 1
+
+// timeWindow is the number of seconds a SNMPv3 message sent to the engine of
+// the plugin is valid.
+const timeWindow = 150
+
+// OIDs of the usmStats counters sent in report PDUs.
+var (
+	oidUnsupportedSecLevels = []byte{0x2b, 6, 1, 6, 3, 15, 1, 1, 1, 0}
+	oidNotInTimeWindows     = []byte{0x2b, 6, 1, 6, 3, 15, 1, 1, 2, 0}
+	oidUnknownUserNames     = []byte{0x2b, 6, 1, 6, 3, 15, 1, 1, 3, 0}
+	oidUnknownEngineIDs     = []byte{0x2b, 6, 1, 6, 3, 15, 1, 1, 4, 0}
+	oidWrongDigests         = []byte{0x2b, 6, 1, 6, 3, 15, 1, 1, 5, 0}
+	oidDecryptionErrors     = []byte{0x2b, 6, 1, 6, 3, 15, 1, 1, 6, 0}
+)
+
 type SnmpTrap struct {
-	ServiceAddress string            `toml:"service_address"`
-	Timeout        internal.Duration `toml:"timeout"`
+	ServiceAddress   string            `toml:"service_address"`
+	Timeout          internal.Duration `toml:"timeout"`
+	EngineID         string            `toml:"engine_id"`
+	MinSecurityLevel string            `toml:"min_security_level"`
+	Users            []*USMUser        `toml:"user"`
 
 	acc      telegraf.Accumulator
-	listener *gosnmp.TrapListener
+	conn     *net.UDPConn
+	wg       sync.WaitGroup
 	timeFunc func() time.Time
-	errCh    chan error
+	handler  handler
 
 	makeHandlerWrapper func(handler) handler
 
+	engineID    []byte
+	engineStart time.Time
+	minLevel    securityLevel
+	users       map[string]*USMUser
+
+	unsupportedSecLevels selfstat.Stat
+	notInTimeWindows     selfstat.Stat
+	unknownUserNames     selfstat.Stat
+	unknownEngineIDs     selfstat.Stat
+	wrongDigests         selfstat.Stat
+	decryptionErrors     selfstat.Stat
+
 	Log telegraf.Logger `toml:"-"`
 
 	cacheLock sync.Mutex
@@ -58,6 +96,28 @@ var sampleConfig = `
   # service_address = "udp://:162"
   ## Timeout running snmptranslate command
   # timeout = "5s"
+
+  ## SNMPv3 engine ID of the plugin as a hex string, it is used to answer
+  ## inform requests.  A random engine ID is generated if unset.
+  # engine_id = "8000000005f3e5c1d2a1b0c9d8"
+
+  ## Lowest security level accepted; one of "noAuthNoPriv", "authNoPriv" or
+  ## "authPriv".  SNMPv1 and SNMPv2c notifications are only accepted with
+  ## "noAuthNoPriv".
+  # min_security_level = "noAuthNoPriv"
+
+  ## SNMPv3 users, repeat the section for each user.  Notifications from
+  ## unknown users, or sent with another security level than the one of the
+  ## user, are dropped.
+  # [[inputs.snmp_trap.user]]
+  #   name = "telegraf"
+  #   ## Authentication protocol; one of "MD5", "SHA", "SHA224", "SHA256",
+  #   ## "SHA384", "SHA512" or "" for no authentication.
+  #   auth_protocol = "SHA256"
+  #   auth_password = "authpassword"
+  #   ## Privacy protocol; one of "DES", "AES" or "" for no privacy.
+  #   priv_protocol = "AES"
+  #   priv_password = "privpassword"
 `
 
 func (s *SnmpTrap) SampleConfig() string {
@@ -96,18 +156,51 @@ func realExecCmd(Timeout internal.Duration, arg0 string, args ...string) ([]byte
 func (s *SnmpTrap) Init() error {
 	s.cache = map[string]mibEntry{}
 	s.execCmd = realExecCmd
+
+	var err error
+	s.minLevel, err = parseSecurityLevel(s.MinSecurityLevel)
+	if err != nil {
+		return err
+	}
+
+	if s.EngineID != "" {
+		s.engineID, err = hex.DecodeString(strings.TrimPrefix(s.EngineID, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid engine_id: %v", err)
+		}
+		if len(s.engineID) < 5 || len(s.engineID) > 32 {
+			return fmt.Errorf("engine_id must be 5 to 32 bytes long")
+		}
+	} else {
+		// Octets format of RFC 3411, without an enterprise number.
+		s.engineID = make([]byte, 13)
+		s.engineID[0] = 0x80
+		s.engineID[4] = 5
+		if _, err := rand.Read(s.engineID[5:]); err != nil {
+			return err
+		}
+	}
+
+	s.users = make(map[string]*USMUser, len(s.Users))
+	for _, u := range s.Users {
+		if err := u.init(); err != nil {
+			return err
+		}
+		if _, ok := s.users[u.Name]; ok {
+			return fmt.Errorf("duplicate user %q", u.Name)
+		}
+		s.users[u.Name] = u
+	}
 	return nil
 }
 
 func (s *SnmpTrap) Start(acc telegraf.Accumulator) error {
 	s.acc = acc
-	s.listener = gosnmp.NewTrapListener()
-	s.listener.OnNewTrap = makeTrapHandler(s)
-	s.listener.Params = gosnmp.Default
+	s.handler = makeTrapHandler(s)
 
 	// wrap the handler, used in unit tests
 	if nil != s.makeHandlerWrapper {
-		s.listener.OnNewTrap = s.makeHandlerWrapper(s.listener.OnNewTrap)
+		s.handler = s.makeHandlerWrapper(s.handler)
 	}
 
 	split := strings.SplitN(s.ServiceAddress, "://", 2)
@@ -118,37 +211,378 @@ func (s *SnmpTrap) Start(acc telegraf.Accumulator) error {
 	protocol := split[0]
 	addr := split[1]
 
-	// gosnmp.TrapListener currently supports udp only.  For forward
-	// compatibility, require udp in the service address
+	// Only udp is supported.  For forward compatibility, require udp in
+	// the service address
 	if protocol != "udp" {
 		return fmt.Errorf("unknown protocol '%s' in '%s'", protocol, s.ServiceAddress)
 	}
 
-	// If (*TrapListener).Listen immediately returns an error we need
-	// to return it from this function.  Use a channel to get it here
-	// from the goroutine.  Buffer one in case Listen returns after
-	// Listening but before our Close is called.
-	s.errCh = make(chan error, 1)
-	go func() {
-		s.errCh <- s.listener.Listen(addr)
-	}()
-
-	select {
-	case <-s.listener.Listening():
-		s.Log.Infof("Listening on %s", s.ServiceAddress)
-	case err := <-s.errCh:
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
 		return err
 	}
+	s.conn, err = net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+	s.Log.Infof("Listening on %s", s.ServiceAddress)
+
+	tags := map[string]string{"address": s.ServiceAddress}
+	s.unsupportedSecLevels = selfstat.Register("snmp_trap", "unsupported_sec_levels", tags)
+	s.notInTimeWindows = selfstat.Register("snmp_trap", "not_in_time_windows", tags)
+	s.unknownUserNames = selfstat.Register("snmp_trap", "unknown_user_names", tags)
+	s.unknownEngineIDs = selfstat.Register("snmp_trap", "unknown_engine_ids", tags)
+	s.wrongDigests = selfstat.Register("snmp_trap", "wrong_digests", tags)
+	s.decryptionErrors = selfstat.Register("snmp_trap", "decryption_errors", tags)
+
+	s.engineStart = time.Now()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.listen()
+	}()
 
 	return nil
 }
 
 func (s *SnmpTrap) Stop() {
-	s.listener.Close()
-	err := <-s.errCh
-	if nil != err {
-		s.Log.Errorf("Error stopping trap listener %v", err)
+	s.conn.Close()
+	s.wg.Wait()
+}
+
+func (s *SnmpTrap) listen() {
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+				s.Log.Errorf("Error reading packet: %v", err)
+				continue
+			}
+			return
+		}
+
+		packet := make([]byte, n)
+		copy(packet, buf[:n])
+		s.handlePacket(packet, addr)
+	}
+}
+
+// engineTime returns the number of seconds since the engine of the plugin
+// started.
+func (s *SnmpTrap) engineTime() int64 {
+	return int64(time.Since(s.engineStart) / time.Second)
+}
+
+func (s *SnmpTrap) handlePacket(packet []byte, addr *net.UDPAddr) {
+	body, _, err := readExpected(packet, tagSequence)
+	if err != nil {
+		s.Log.Debugf("Invalid packet from %s: %v", addr.IP, err)
+		return
+	}
+	version, rest, err := readInteger(body)
+	if err != nil {
+		s.Log.Debugf("Invalid packet from %s: %v", addr.IP, err)
+		return
+	}
+
+	switch gosnmp.SnmpVersion(version) {
+	case gosnmp.Version1, gosnmp.Version2c:
+		s.handleCommunity(packet, rest, addr)
+	case gosnmp.Version3:
+		s.handleV3(packet, addr)
+	default:
+		s.Log.Debugf("Unsupported SNMP version %d from %s", version, addr.IP)
+	}
+}
+
+// handleCommunity handles a SNMPv1 or SNMPv2c message, body is the message
+// after the version.
+func (s *SnmpTrap) handleCommunity(packet []byte, body []byte, addr *net.UDPAddr) {
+	if s.minLevel > noAuthNoPriv {
+		s.unsupportedSecLevels.Incr(1)
+		s.Log.Debugf("Dropping community based notification from %s", addr.IP)
+		return
+	}
+
+	community, pdu, err := readExpected(body, tagOctetString)
+	if err != nil || len(pdu) == 0 {
+		s.Log.Debugf("Invalid packet from %s", addr.IP)
+		return
+	}
+
+	inform := pdu[0] == tagInformRequest
+	if inform {
+		// gosnmp doesn't decode inform requests, they only differ from
+		// SNMPv2 traps by their tag.
+		packet = append([]byte(nil), packet...)
+		packet[len(packet)-len(pdu)] = tagSNMPv2Trap
+	} else if pdu[0] != byte(gosnmp.Trap) && pdu[0] != tagSNMPv2Trap {
+		s.Log.Debugf("Ignoring PDU type %#x from %s", pdu[0], addr.IP)
+		return
+	}
+
+	decoded := gosnmp.Default.UnmarshalTrap(packet)
+	if decoded == nil {
+		s.Log.Debugf("Invalid packet from %s", addr.IP)
+		return
 	}
+
+	if inform {
+		response := encodeTLV(tagSequence,
+			encodeInteger(int64(decoded.Version)),
+			encodeTLV(tagOctetString, community),
+			responsePDU(pdu),
+		)
+		s.send(response, addr)
+	}
+
+	s.handler(decoded, addr)
+}
+
+// handleV3 handles a SNMPv3 message, following the processing of incoming
+// messages of RFC 3414 3.2.
+func (s *SnmpTrap) handleV3(packet []byte, addr *net.UDPAddr) {
+	m, err := parseV3(packet)
+	if err != nil {
+		s.Log.Debugf("Invalid packet from %s: %v", addr.IP, err)
+		return
+	}
+	level, err := m.level()
+	if err != nil {
+		s.Log.Debugf("Invalid packet from %s: %v", addr.IP, err)
+		return
+	}
+
+	// A request with an empty engine ID is a discovery of the engine ID
+	// of the plugin, needed to send inform requests.
+	if len(m.engineID) == 0 {
+		s.unknownEngineIDs.Incr(1)
+		s.report(addr, m, nil, oidUnknownEngineIDs, s.unknownEngineIDs)
+		return
+	}
+
+	user, ok := s.users[string(m.userName)]
+	if !ok {
+		s.unknownUserNames.Incr(1)
+		s.Log.Debugf("Dropping notification from %s: unknown user %q", addr.IP, m.userName)
+		s.report(addr, m, nil, oidUnknownUserNames, s.unknownUserNames)
+		return
+	}
+
+	// The user only supports its own security level, the keys of the
+	// other levels are missing.
+	if level < s.minLevel || level != user.level {
+		s.unsupportedSecLevels.Incr(1)
+		s.Log.Debugf("Dropping notification from %s: unsupported security level", addr.IP)
+		s.report(addr, m, nil, oidUnsupportedSecLevels, s.unsupportedSecLevels)
+		return
+	}
+
+	keys := user.localize(m.engineID)
+	if level >= authNoPriv {
+		if !user.authentic(keys, m) {
+			s.wrongDigests.Incr(1)
+			s.Log.Debugf("Dropping notification from %s: wrong digest for user %q", addr.IP, m.userName)
+			s.report(addr, m, nil, oidWrongDigests, s.wrongDigests)
+			return
+		}
+
+		// The timeliness is only checked when the plugin is the
+		// authoritative engine, for inform requests.
+		if bytes.Equal(m.engineID, s.engineID) {
+			if delta := m.time - s.engineTime(); m.boots != engineBoots || delta > timeWindow || delta < -timeWindow {
+				s.notInTimeWindows.Incr(1)
+				s.Log.Debugf("Dropping notification from %s: not in time window", addr.IP)
+				s.report(addr, m, user, oidNotInTimeWindows, s.notInTimeWindows)
+				return
+			}
+		}
+	}
+
+	scopedPDU := m.data
+	if level == authPriv {
+		scopedPDU, err = user.decrypt(keys, m)
+		if err != nil {
+			s.decryptionErrors.Incr(1)
+			s.Log.Debugf("Dropping notification from %s: %v", addr.IP, err)
+			s.report(addr, m, nil, oidDecryptionErrors, s.decryptionErrors)
+			return
+		}
+	}
+
+	contextEngineID, contextName, pdu, err := parseScopedPDU(scopedPDU)
+	if err != nil {
+		s.Log.Debugf("Invalid scoped PDU from %s: %v", addr.IP, err)
+		return
+	}
+
+	inform := pdu[0] == tagInformRequest
+	if inform && !bytes.Equal(m.engineID, s.engineID) {
+		// The plugin is the authoritative engine of inform requests.
+		s.unknownEngineIDs.Incr(1)
+		s.report(addr, m, nil, oidUnknownEngineIDs, s.unknownEngineIDs)
+		return
+	}
+	if !inform && pdu[0] != tagSNMPv2Trap {
+		s.Log.Debugf("Ignoring PDU type %#x from %s", pdu[0], addr.IP)
+		return
+	}
+
+	// Decode the PDU with gosnmp as part of a SNMPv2c message.
+	trap := append([]byte(nil), pdu...)
+	trap[0] = tagSNMPv2Trap
+	decoded := gosnmp.Default.UnmarshalTrap(encodeTLV(tagSequence,
+		encodeInteger(int64(gosnmp.Version2c)),
+		encodeTLV(tagOctetString),
+		trap,
+	))
+	if decoded == nil {
+		s.Log.Debugf("Invalid PDU from %s", addr.IP)
+		return
+	}
+	decoded.Version = gosnmp.Version3
+	decoded.Community = ""
+	decoded.MsgID = uint32(m.msgID)
+	decoded.MsgFlags = gosnmp.SnmpV3MsgFlags(m.flags)
+	decoded.SecurityModel = gosnmp.UserSecurityModel
+	decoded.SecurityParameters = &gosnmp.UsmSecurityParameters{
+		AuthoritativeEngineID:    string(m.engineID),
+		AuthoritativeEngineBoots: uint32(m.boots),
+		AuthoritativeEngineTime:  uint32(m.time),
+		UserName:                 string(m.userName),
+	}
+	decoded.ContextEngineID = string(contextEngineID)
+	decoded.ContextName = string(contextName)
+
+	if inform {
+		response, err := s.encodeV3(m.msgID, level, user, m.userName, contextEngineID, contextName, responsePDU(pdu))
+		if err != nil {
+			s.Log.Errorf("Error encoding response to %s: %v", addr.IP, err)
+		} else {
+			s.send(response, addr)
+		}
+	}
+
+	s.handler(decoded, addr)
+}
+
+// report answers a reportable message with a report PDU holding the usmStats
+// counter of the error.  The report is authenticated if a user is given.
+func (s *SnmpTrap) report(addr *net.UDPAddr, m *v3Message, user *USMUser, oid []byte, counter selfstat.Stat) {
+	if m.flags&flagReportable == 0 {
+		return
+	}
+
+	// The request-id is only known for plaintext messages.
+	var id int64
+	if m.flags&flagPriv == 0 {
+		if _, _, pdu, err := parseScopedPDU(m.data); err == nil {
+			id, _ = requestID(pdu)
+		}
+	}
+
+	pdu := encodeTLV(tagReport,
+		encodeInteger(id),
+		encodeInteger(0),
+		encodeInteger(0),
+		encodeTLV(tagSequence,
+			encodeTLV(tagSequence,
+				encodeTLV(byte(gosnmp.ObjectIdentifier), oid),
+				encodeTLV(byte(gosnmp.Counter32), integerBytes(counter.Get())),
+			),
+		),
+	)
+
+	level := noAuthNoPriv
+	if user != nil {
+		level = authNoPriv
+	}
+	response, err := s.encodeV3(m.msgID, level, user, m.userName, s.engineID, nil, pdu)
+	if err != nil {
+		s.Log.Errorf("Error encoding report to %s: %v", addr.IP, err)
+		return
+	}
+	s.send(response, addr)
+}
+
+// encodeV3 encodes a SNMPv3 message sent by the engine of the plugin.
+func (s *SnmpTrap) encodeV3(msgID int64, level securityLevel, user *USMUser, userName, contextEngineID, contextName, pdu []byte) ([]byte, error) {
+	boots, now := int64(engineBoots), s.engineTime()
+
+	var keys *localizedKeys
+	if level > noAuthNoPriv {
+		keys = user.localize(s.engineID)
+	}
+
+	data := encodeTLV(tagSequence,
+		encodeTLV(tagOctetString, contextEngineID),
+		encodeTLV(tagOctetString, contextName),
+		pdu,
+	)
+	var privParams []byte
+	if level == authPriv {
+		ciphertext, salt, err := user.encrypt(keys, boots, now, data)
+		if err != nil {
+			return nil, err
+		}
+		data = encodeTLV(tagOctetString, ciphertext)
+		privParams = salt
+	}
+
+	var authParams []byte
+	flags := byte(0)
+	switch level {
+	case authPriv:
+		flags = flagAuth | flagPriv
+		authParams = make([]byte, user.auth.macLength)
+	case authNoPriv:
+		flags = flagAuth
+		authParams = make([]byte, user.auth.macLength)
+	}
+
+	privTLV := encodeTLV(tagOctetString, privParams)
+	msg := encodeTLV(tagSequence,
+		encodeInteger(int64(gosnmp.Version3)),
+		encodeTLV(tagSequence,
+			encodeInteger(msgID),
+			encodeInteger(65507),
+			encodeTLV(tagOctetString, []byte{flags}),
+			encodeInteger(userSecurityModel),
+		),
+		encodeTLV(tagOctetString, encodeTLV(tagSequence,
+			encodeTLV(tagOctetString, s.engineID),
+			encodeInteger(boots),
+			encodeInteger(now),
+			encodeTLV(tagOctetString, userName),
+			encodeTLV(tagOctetString, authParams),
+			privTLV,
+		)),
+		data,
+	)
+
+	// The digest is computed over the message with zeroed authentication
+	// parameters, which end right before the privacy parameters.
+	if level > noAuthNoPriv {
+		offset := len(msg) - len(data) - len(privTLV) - len(authParams)
+		copy(msg[offset:], user.digest(keys, msg))
+	}
+	return msg, nil
+}
+
+func (s *SnmpTrap) send(packet []byte, addr *net.UDPAddr) {
+	if _, err := s.conn.WriteToUDP(packet, addr); err != nil {
+		s.Log.Errorf("Error sending response to %s: %v", addr.IP, err)
+	}
+}
+
+// responsePDU returns the response to an inform request, which echoes its
+// request-id and variable bindings.
+func responsePDU(inform []byte) []byte {
+	response := append([]byte(nil), inform...)
+	response[0] = tagGetResponse
+	return response
 }
 
 func setTrapOid(tags map[string]string, oid string, e mibEntry) {
@@ -166,6 +600,15 @@ func makeTrapHandler(s *SnmpTrap) handler {
 		tags["version"] = packet.Version.String()
 		tags["source"] = addr.IP.String()
 
+		if packet.Version == gosnmp.Version3 {
+			if usm, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok {
+				tags["sec_name"] = usm.UserName
+			}
+			if packet.ContextName != "" {
+				tags["context_name"] = packet.ContextName
+			}
+		}
+
 		if packet.Version == gosnmp.Version1 {
 			// Follow the procedure described in RFC 2576 3.1 to
 			// translate a v1 trap to v2.
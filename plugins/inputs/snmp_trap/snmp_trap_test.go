@@ -332,3 +332,32 @@ func TestReceiveV1Trap(t *testing.T) {
 		testutil.SortMetrics())
 
 }
+
+func TestReceiveV2cInform(t *testing.T) {
+	s := &SnmpTrap{}
+	acc, received := startV3(t, s)
+	defer s.Stop()
+
+	inform, err := (&gosnmp.SnmpPacket{
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		PDUType:   gosnmp.InformRequest,
+		RequestID: 42,
+		Variables: coldStart().Variables,
+	}).MarshalMsg()
+	require.NoError(t, err)
+
+	// The inform request is acknowledged with a response echoing its
+	// request-id and variables.
+	response := gosnmp.Default.UnmarshalTrap(exchange(t, inform))
+	require.NotNil(t, response)
+	require.Equal(t, gosnmp.GetResponse, response.PDUType)
+	require.Equal(t, "public", response.Community)
+	require.Equal(t, uint32(42), response.RequestID)
+	require.Len(t, response.Variables, 2)
+
+	waitReceived(t, received)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{coldStartMetric(map[string]string{"version": "2c"})},
+		acc.GetTelegrafMetrics())
+}
@@ -0,0 +1,436 @@
+package snmp_trap
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// securityLevel is the SNMPv3 security level of a message, in increasing
+// order of protection.
+type securityLevel int
+
+const (
+	noAuthNoPriv securityLevel = iota
+	authNoPriv
+	authPriv
+)
+
+func parseSecurityLevel(s string) (securityLevel, error) {
+	switch strings.ToLower(s) {
+	case "", "noauthnopriv":
+		return noAuthNoPriv, nil
+	case "authnopriv":
+		return authNoPriv, nil
+	case "authpriv":
+		return authPriv, nil
+	}
+	return 0, fmt.Errorf("unknown security level %q", s)
+}
+
+// Bits of the msgFlags field of a SNMPv3 message.
+const (
+	flagAuth       = 0x01
+	flagPriv       = 0x02
+	flagReportable = 0x04
+)
+
+// userSecurityModel is the msgSecurityModel of USM, the only security model
+// supported.
+const userSecurityModel = 3
+
+type authProtocol struct {
+	hash func() hash.Hash
+	// macLength is the length of the truncated HMAC carried in the
+	// authentication parameters.
+	macLength int
+}
+
+// authProtocols are the HMAC-MD5-96 and HMAC-SHA-96 protocols of RFC 3414
+// and the HMAC-SHA-2 protocols of RFC 7860.
+var authProtocols = map[string]authProtocol{
+	"MD5":    {md5.New, 12},
+	"SHA":    {sha1.New, 12},
+	"SHA224": {sha256.New224, 16},
+	"SHA256": {sha256.New, 24},
+	"SHA384": {sha512.New384, 32},
+	"SHA512": {sha512.New, 48},
+}
+
+// USMUser is a user of the SNMPv3 User-based Security Model.
+type USMUser struct {
+	Name         string `toml:"name"`
+	AuthProtocol string `toml:"auth_protocol"`
+	AuthPassword string `toml:"auth_password"`
+	PrivProtocol string `toml:"priv_protocol"`
+	PrivPassword string `toml:"priv_password"`
+
+	level securityLevel
+	auth  authProtocol
+	priv  string
+
+	// authKey and privKey are the keys derived from the passwords, they
+	// are localized to each engine ID on first use.
+	authKey []byte
+	privKey []byte
+	keys    map[string]*localizedKeys
+}
+
+type localizedKeys struct {
+	auth []byte
+	priv []byte
+}
+
+func (u *USMUser) init() error {
+	if u.Name == "" {
+		return errors.New("missing user name")
+	}
+
+	u.AuthProtocol = strings.ToUpper(u.AuthProtocol)
+	u.PrivProtocol = strings.ToUpper(u.PrivProtocol)
+	u.keys = make(map[string]*localizedKeys)
+
+	if u.AuthProtocol == "" {
+		if u.PrivProtocol != "" {
+			return fmt.Errorf("user %q: privacy requires an auth_protocol", u.Name)
+		}
+		u.level = noAuthNoPriv
+		return nil
+	}
+
+	auth, ok := authProtocols[u.AuthProtocol]
+	if !ok {
+		return fmt.Errorf("user %q: unknown auth_protocol %q", u.Name, u.AuthProtocol)
+	}
+	// RFC 3414 requires passwords of at least 8 characters.
+	if len(u.AuthPassword) < 8 {
+		return fmt.Errorf("user %q: auth_password must be at least 8 characters", u.Name)
+	}
+	u.auth = auth
+	u.authKey = passwordToKey(auth.hash, u.AuthPassword)
+	u.level = authNoPriv
+
+	switch u.PrivProtocol {
+	case "":
+		return nil
+	case "DES", "AES":
+	default:
+		return fmt.Errorf("user %q: unknown priv_protocol %q", u.Name, u.PrivProtocol)
+	}
+	if len(u.PrivPassword) < 8 {
+		return fmt.Errorf("user %q: priv_password must be at least 8 characters", u.Name)
+	}
+	u.priv = u.PrivProtocol
+	u.privKey = passwordToKey(auth.hash, u.PrivPassword)
+	u.level = authPriv
+	return nil
+}
+
+// maxLocalizedKeys bounds the number of engines whose keys are cached for a
+// user, as the engine ID of a message is read before it is authenticated.
+const maxLocalizedKeys = 256
+
+// localize returns the keys of the user for the engine ID.
+func (u *USMUser) localize(engineID []byte) *localizedKeys {
+	if keys, ok := u.keys[string(engineID)]; ok {
+		return keys
+	}
+	if len(u.keys) >= maxLocalizedKeys {
+		// Evict any engine, its keys are derived again when needed.
+		for k := range u.keys {
+			delete(u.keys, k)
+			break
+		}
+	}
+
+	keys := &localizedKeys{}
+	if u.authKey != nil {
+		keys.auth = localizeKey(u.auth.hash, u.authKey, engineID)
+	}
+	if u.privKey != nil {
+		keys.priv = localizeKey(u.auth.hash, u.privKey, engineID)
+	}
+	u.keys[string(engineID)] = keys
+	return keys
+}
+
+// passwordToKey is the password to key algorithm of RFC 3414 A.2, hashing
+// one megabyte made of the repeated password.
+func passwordToKey(newHash func() hash.Hash, password string) []byte {
+	h := newHash()
+	buf := make([]byte, 64)
+	for count := 0; count < 1048576; count += len(buf) {
+		for i := range buf {
+			buf[i] = password[(count+i)%len(password)]
+		}
+		h.Write(buf)
+	}
+	return h.Sum(nil)
+}
+
+// localizeKey derives the key used with an engine from the key of the
+// password.
+func localizeKey(newHash func() hash.Hash, key []byte, engineID []byte) []byte {
+	h := newHash()
+	h.Write(key)
+	h.Write(engineID)
+	h.Write(key)
+	return h.Sum(nil)
+}
+
+// digest returns the truncated HMAC of the message.
+func (u *USMUser) digest(keys *localizedKeys, msg []byte) []byte {
+	mac := hmac.New(u.auth.hash, keys.auth)
+	mac.Write(msg)
+	return mac.Sum(nil)[:u.auth.macLength]
+}
+
+// authentic checks the digest of the message.  The authentication
+// parameters are zeroed in the raw message while the digest is computed.
+func (u *USMUser) authentic(keys *localizedKeys, m *v3Message) bool {
+	if u.auth.hash == nil || keys.auth == nil || len(m.authParams) != u.auth.macLength {
+		return false
+	}
+
+	received := append([]byte(nil), m.authParams...)
+	for i := range m.authParams {
+		m.authParams[i] = 0
+	}
+	defer copy(m.authParams, received)
+
+	return hmac.Equal(received, u.digest(keys, m.raw))
+}
+
+// decrypt returns the plaintext scoped PDU of the message.
+func (u *USMUser) decrypt(keys *localizedKeys, m *v3Message) ([]byte, error) {
+	ciphertext, _, err := readExpected(m.data, tagOctetString)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.privParams) != 8 {
+		return nil, errors.New("invalid privacy parameters")
+	}
+	if keys.priv == nil {
+		return nil, errors.New("no privacy key")
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	switch u.priv {
+	case "DES":
+		// DES-CBC of RFC 3414 8.1.1.2, the IV is the pre-IV of the
+		// key salted with the privacy parameters.
+		if len(ciphertext)%des.BlockSize != 0 {
+			return nil, errors.New("ciphertext is not a multiple of the block size")
+		}
+		block, err := des.NewCipher(keys.priv[:8])
+		if err != nil {
+			return nil, err
+		}
+		iv := make([]byte, des.BlockSize)
+		for i := range iv {
+			iv[i] = keys.priv[8+i] ^ m.privParams[i]
+		}
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	case "AES":
+		// AES-CFB128 of RFC 3826 3.1.2.1, the IV is made of the
+		// engine boots and time and of the privacy parameters.
+		block, err := aes.NewCipher(keys.priv[:16])
+		if err != nil {
+			return nil, err
+		}
+		cipher.NewCFBDecrypter(block, aesIV(m.boots, m.time, m.privParams)).XORKeyStream(plaintext, ciphertext)
+	}
+
+	// Drop the padding of DES; a wrong key shows as a garbled scoped PDU.
+	_, _, rest, err := readTLV(plaintext)
+	if err != nil || plaintext[0] != tagSequence {
+		return nil, errors.New("invalid scoped PDU")
+	}
+	return plaintext[:len(plaintext)-len(rest)], nil
+}
+
+// encrypt returns the encrypted scoped PDU and the privacy parameters.
+func (u *USMUser) encrypt(keys *localizedKeys, boots, engineTime int64, scopedPDU []byte) ([]byte, []byte, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+
+	switch u.priv {
+	case "DES":
+		block, err := des.NewCipher(keys.priv[:8])
+		if err != nil {
+			return nil, nil, err
+		}
+		iv := make([]byte, des.BlockSize)
+		for i := range iv {
+			iv[i] = keys.priv[8+i] ^ salt[i]
+		}
+		if pad := len(scopedPDU) % des.BlockSize; pad != 0 {
+			scopedPDU = append(scopedPDU, make([]byte, des.BlockSize-pad)...)
+		}
+		ciphertext := make([]byte, len(scopedPDU))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, scopedPDU)
+		return ciphertext, salt, nil
+	default:
+		block, err := aes.NewCipher(keys.priv[:16])
+		if err != nil {
+			return nil, nil, err
+		}
+		ciphertext := make([]byte, len(scopedPDU))
+		cipher.NewCFBEncrypter(block, aesIV(boots, engineTime, salt)).XORKeyStream(ciphertext, scopedPDU)
+		return ciphertext, salt, nil
+	}
+}
+
+func aesIV(boots, engineTime int64, salt []byte) []byte {
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint32(iv, uint32(boots))
+	binary.BigEndian.PutUint32(iv[4:], uint32(engineTime))
+	copy(iv[8:], salt)
+	return iv
+}
+
+// v3Message is a SNMPv3 message using the User-based Security Model.  The
+// slices share the memory of the raw message.
+type v3Message struct {
+	raw []byte
+
+	msgID    int64
+	flags    byte
+	engineID []byte
+	boots    int64
+	time     int64
+	userName []byte
+
+	authParams []byte
+	privParams []byte
+
+	// data is the scoped PDU, encrypted as an OCTET STRING when the
+	// message uses privacy.
+	data []byte
+}
+
+func (m *v3Message) level() (securityLevel, error) {
+	switch m.flags & (flagAuth | flagPriv) {
+	case 0:
+		return noAuthNoPriv, nil
+	case flagAuth:
+		return authNoPriv, nil
+	case flagAuth | flagPriv:
+		return authPriv, nil
+	}
+	return 0, errors.New("invalid message flags")
+}
+
+// parseV3 parses the header and security parameters of a SNMPv3 message.
+func parseV3(b []byte) (*v3Message, error) {
+	m := &v3Message{}
+
+	body, rest, err := readExpected(b, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+	m.raw = b[:len(b)-len(rest)]
+
+	if _, body, err = readInteger(body); err != nil {
+		return nil, err
+	}
+
+	header, body, err := readExpected(body, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+	if m.msgID, header, err = readInteger(header); err != nil {
+		return nil, err
+	}
+	if _, header, err = readInteger(header); err != nil {
+		return nil, err
+	}
+	flags, header, err := readExpected(header, tagOctetString)
+	if err != nil {
+		return nil, err
+	}
+	if len(flags) != 1 {
+		return nil, errMalformed
+	}
+	m.flags = flags[0]
+	model, _, err := readInteger(header)
+	if err != nil {
+		return nil, err
+	}
+	if model != userSecurityModel {
+		return nil, fmt.Errorf("unsupported security model %d", model)
+	}
+
+	secParams, body, err := readExpected(body, tagOctetString)
+	if err != nil {
+		return nil, err
+	}
+	usm, _, err := readExpected(secParams, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+	if m.engineID, usm, err = readExpected(usm, tagOctetString); err != nil {
+		return nil, err
+	}
+	if m.boots, usm, err = readInteger(usm); err != nil {
+		return nil, err
+	}
+	if m.time, usm, err = readInteger(usm); err != nil {
+		return nil, err
+	}
+	if m.userName, usm, err = readExpected(usm, tagOctetString); err != nil {
+		return nil, err
+	}
+	if m.authParams, usm, err = readExpected(usm, tagOctetString); err != nil {
+		return nil, err
+	}
+	if m.privParams, _, err = readExpected(usm, tagOctetString); err != nil {
+		return nil, err
+	}
+
+	m.data = body
+	return m, nil
+}
+
+// parseScopedPDU splits a plaintext scoped PDU into the context engine ID,
+// the context name and the encoded PDU.
+func parseScopedPDU(b []byte) (contextEngineID, contextName, pdu []byte, err error) {
+	scoped, _, err := readExpected(b, tagSequence)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if contextEngineID, scoped, err = readExpected(scoped, tagOctetString); err != nil {
+		return nil, nil, nil, err
+	}
+	if contextName, scoped, err = readExpected(scoped, tagOctetString); err != nil {
+		return nil, nil, nil, err
+	}
+	_, _, rest, err := readTLV(scoped)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return contextEngineID, contextName, scoped[:len(scoped)-len(rest)], nil
+}
+
+// requestID returns the request-id of an encoded PDU.
+func requestID(pdu []byte) (int64, error) {
+	_, value, _, err := readTLV(pdu)
+	if err != nil {
+		return 0, err
+	}
+	id, _, err := readInteger(value)
+	return id, err
+}
@@ -0,0 +1,626 @@
+package snmp_trap
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordToKey(t *testing.T) {
+	// Test vectors of RFC 3414 A.3
+	engineID, _ := hex.DecodeString("000000000000000000000002")
+
+	key := passwordToKey(md5.New, "maplesyrup")
+	require.Equal(t, "9faf3283884e92834ebc9847d8edd963", hex.EncodeToString(key))
+	require.Equal(t, "526f5eed9fcce26f8964c2930787d82b", hex.EncodeToString(localizeKey(md5.New, key, engineID)))
+
+	key = passwordToKey(sha1.New, "maplesyrup")
+	require.Equal(t, "9fb5cc0381497b3793528939ff788d5d79145211", hex.EncodeToString(key))
+	require.Equal(t, "6695febc9288e36282235fc7151f128497b38f3f", hex.EncodeToString(localizeKey(sha1.New, key, engineID)))
+}
+
+func TestLocalizedKeysBounded(t *testing.T) {
+	u := USMUser{Name: "u", AuthProtocol: "SHA", AuthPassword: "authpassword"}
+	require.NoError(t, u.init())
+
+	engineID := []byte("\x80\x00\x1f\x88\x04engine")
+	keys := u.localize(engineID)
+	for i := 0; i < 2*maxLocalizedKeys; i++ {
+		u.localize([]byte(fmt.Sprintf("\x80\x00\x1f\x88\x04engine%d", i)))
+	}
+	require.Len(t, u.keys, maxLocalizedKeys)
+
+	// Evicted keys are derived again.
+	require.Equal(t, keys, u.localize(engineID))
+}
+
+func TestInitUsers(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin *SnmpTrap
+		err    string
+	}{
+		{
+			name:   "unknown security level",
+			plugin: &SnmpTrap{MinSecurityLevel: "authOnly"},
+			err:    `unknown security level "authOnly"`,
+		},
+		{
+			name:   "invalid engine id",
+			plugin: &SnmpTrap{EngineID: "8000"},
+			err:    "engine_id must be 5 to 32 bytes long",
+		},
+		{
+			name:   "unknown auth protocol",
+			plugin: &SnmpTrap{Users: []*USMUser{{Name: "u", AuthProtocol: "SHA1024", AuthPassword: "password"}}},
+			err:    `user "u": unknown auth_protocol "SHA1024"`,
+		},
+		{
+			name:   "short password",
+			plugin: &SnmpTrap{Users: []*USMUser{{Name: "u", AuthProtocol: "SHA", AuthPassword: "secret"}}},
+			err:    `user "u": auth_password must be at least 8 characters`,
+		},
+		{
+			name:   "privacy without authentication",
+			plugin: &SnmpTrap{Users: []*USMUser{{Name: "u", PrivProtocol: "AES", PrivPassword: "password"}}},
+			err:    `user "u": privacy requires an auth_protocol`,
+		},
+		{
+			name: "duplicate user",
+			plugin: &SnmpTrap{Users: []*USMUser{
+				{Name: "u"},
+				{Name: "u"},
+			}},
+			err: `duplicate user "u"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.EqualError(t, tt.plugin.Init(), tt.err)
+		})
+	}
+}
+
+const v3Port = 12399
+
+var testUsers = []*USMUser{
+	{Name: "noauth"},
+	{Name: "md5", AuthProtocol: "MD5", AuthPassword: "authpassword"},
+	{Name: "md5des", AuthProtocol: "MD5", AuthPassword: "authpassword", PrivProtocol: "DES", PrivPassword: "privpassword"},
+	{Name: "shaaes", AuthProtocol: "SHA", AuthPassword: "authpassword", PrivProtocol: "AES", PrivPassword: "privpassword"},
+}
+
+// startV3 starts the plugin with the test users, the returned channel
+// receives a value for each notification handled.
+func startV3(t *testing.T, s *SnmpTrap) (*testutil.Accumulator, chan int) {
+	received := make(chan int)
+	s.ServiceAddress = "udp://:" + strconv.Itoa(v3Port)
+	s.Users = testUsers
+	s.makeHandlerWrapper = func(f handler) handler {
+		return func(p *gosnmp.SnmpPacket, a *net.UDPAddr) {
+			f(p, a)
+			received <- 0
+		}
+	}
+	s.timeFunc = func() time.Time {
+		return time.Unix(0, 0)
+	}
+	s.Log = testutil.Logger{}
+	require.NoError(t, s.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(acc))
+
+	s.load(".1.3.6.1.6.3.1.1.4.1.0", mibEntry{"SNMPv2-MIB", "snmpTrapOID.0"})
+	s.load(".1.3.6.1.6.3.1.1.5.1", mibEntry{"SNMPv2-MIB", "coldStart"})
+	s.load(".1.3.6.1.2.1.1.3.0", mibEntry{"UNUSED_MIB_NAME", "sysUpTimeInstance"})
+	return acc, received
+}
+
+func waitReceived(t *testing.T, received chan int) {
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for trap to be received")
+	}
+}
+
+func newV3Client(t *testing.T, flags gosnmp.SnmpV3MsgFlags, usm *gosnmp.UsmSecurityParameters) *gosnmp.GoSNMP {
+	c := &gosnmp.GoSNMP{
+		Target:             "127.0.0.1",
+		Port:               v3Port,
+		Version:            gosnmp.Version3,
+		Timeout:            500 * time.Millisecond,
+		SecurityModel:      gosnmp.UserSecurityModel,
+		MsgFlags:           flags,
+		SecurityParameters: usm,
+	}
+	require.NoError(t, c.Connect())
+	return c
+}
+
+// discover makes the client learn the engine ID of the plugin.  The get
+// request sent after the discovery is not answered.
+func discover(t *testing.T, c *gosnmp.GoSNMP, s *SnmpTrap) {
+	_, _ = c.Get([]string{".1.3.6.1.2.1.1.3.0"})
+	usm := c.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	require.Equal(t, string(s.engineID), usm.AuthoritativeEngineID)
+}
+
+// foreignEngine sets the keys of the client for an engine ID of its own, the
+// way agents send their traps.
+func foreignEngine(t *testing.T, usm *gosnmp.UsmSecurityParameters, user *USMUser) {
+	u := *user
+	require.NoError(t, u.init())
+	usm.AuthoritativeEngineID = "\x80\x00\x1f\x88\x04sender"
+	usm.AuthoritativeEngineBoots = 3
+	usm.AuthoritativeEngineTime = 1234
+	keys := u.localize([]byte(usm.AuthoritativeEngineID))
+	usm.SecretKey = keys.auth
+	usm.PrivacyKey = keys.priv
+}
+
+func coldStart() gosnmp.SnmpTrap {
+	return gosnmp.SnmpTrap{
+		Variables: []gosnmp.SnmpPDU{
+			{
+				Name:  ".1.3.6.1.2.1.1.3.0",
+				Type:  gosnmp.TimeTicks,
+				Value: uint32(42),
+			},
+			{
+				Name:  ".1.3.6.1.6.3.1.1.4.1.0",
+				Type:  gosnmp.ObjectIdentifier,
+				Value: ".1.3.6.1.6.3.1.1.5.1",
+			},
+		},
+	}
+}
+
+func coldStartMetric(tags map[string]string) telegraf.Metric {
+	allTags := map[string]string{
+		"oid":    ".1.3.6.1.6.3.1.1.5.1",
+		"name":   "coldStart",
+		"mib":    "SNMPv2-MIB",
+		"source": "127.0.0.1",
+	}
+	for k, v := range tags {
+		allTags[k] = v
+	}
+	return testutil.MustMetric(
+		"snmp_trap",
+		allTags,
+		map[string]interface{}{"sysUpTimeInstance": uint32(42)},
+		time.Unix(0, 0),
+	)
+}
+
+func TestReceiveV3Trap(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags gosnmp.SnmpV3MsgFlags
+		usm   *gosnmp.UsmSecurityParameters
+	}{
+		{
+			name:  "noauth",
+			flags: gosnmp.NoAuthNoPriv,
+			usm:   &gosnmp.UsmSecurityParameters{UserName: "noauth"},
+		},
+		{
+			name:  "md5",
+			flags: gosnmp.AuthNoPriv,
+			usm: &gosnmp.UsmSecurityParameters{
+				UserName:                 "md5",
+				AuthenticationProtocol:   gosnmp.MD5,
+				AuthenticationPassphrase: "authpassword",
+			},
+		},
+		{
+			name:  "md5des",
+			flags: gosnmp.AuthPriv,
+			usm: &gosnmp.UsmSecurityParameters{
+				UserName:                 "md5des",
+				AuthenticationProtocol:   gosnmp.MD5,
+				AuthenticationPassphrase: "authpassword",
+				PrivacyProtocol:          gosnmp.DES,
+				PrivacyPassphrase:        "privpassword",
+			},
+		},
+		{
+			name:  "shaaes",
+			flags: gosnmp.AuthPriv,
+			usm: &gosnmp.UsmSecurityParameters{
+				UserName:                 "shaaes",
+				AuthenticationProtocol:   gosnmp.SHA,
+				AuthenticationPassphrase: "authpassword",
+				PrivacyProtocol:          gosnmp.AES,
+				PrivacyPassphrase:        "privpassword",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SnmpTrap{}
+			acc, received := startV3(t, s)
+			defer s.Stop()
+
+			// The engine ID of the plugin is discovered by the
+			// client and used to send the trap.
+			c := newV3Client(t, tt.flags, tt.usm)
+			defer c.Conn.Close()
+			discover(t, c, s)
+			_, err := c.SendTrap(coldStart())
+			require.NoError(t, err)
+			waitReceived(t, received)
+
+			// The trap is sent with the engine ID of the client.
+			c = newV3Client(t, tt.flags, tt.usm)
+			defer c.Conn.Close()
+			for _, u := range testUsers {
+				if u.Name == tt.name {
+					foreignEngine(t, c.SecurityParameters.(*gosnmp.UsmSecurityParameters), u)
+				}
+			}
+			_, err = c.SendTrap(coldStart())
+			require.NoError(t, err)
+			waitReceived(t, received)
+
+			expected := coldStartMetric(map[string]string{"version": "3", "sec_name": tt.name})
+			testutil.RequireMetricsEqual(t,
+				[]telegraf.Metric{expected, expected}, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestDropV3Trap(t *testing.T) {
+	s := &SnmpTrap{}
+	acc, received := startV3(t, s)
+	defer s.Stop()
+
+	send := func(flags gosnmp.SnmpV3MsgFlags, usm *gosnmp.UsmSecurityParameters, user *USMUser) {
+		c := newV3Client(t, flags, usm)
+		defer c.Conn.Close()
+		foreignEngine(t, c.SecurityParameters.(*gosnmp.UsmSecurityParameters), user)
+		_, err := c.SendTrap(coldStart())
+		require.NoError(t, err)
+	}
+
+	counters := map[string]func() int64{
+		"unknown_user_names":     s.unknownUserNames.Get,
+		"unsupported_sec_levels": s.unsupportedSecLevels.Get,
+		"wrong_digests":          s.wrongDigests.Get,
+		"decryption_errors":      s.decryptionErrors.Get,
+	}
+	before := make(map[string]int64)
+	for name, get := range counters {
+		before[name] = get()
+	}
+
+	// unknown user
+	send(gosnmp.NoAuthNoPriv, &gosnmp.UsmSecurityParameters{UserName: "nobody"}, &USMUser{Name: "nobody"})
+
+	// authPriv user sending without privacy
+	send(gosnmp.AuthNoPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "shaaes",
+		AuthenticationProtocol:   gosnmp.SHA,
+		AuthenticationPassphrase: "authpassword",
+	}, testUsers[3])
+
+	// wrong authentication password
+	send(gosnmp.AuthPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "shaaes",
+		AuthenticationProtocol:   gosnmp.SHA,
+		AuthenticationPassphrase: "wrongpassword",
+		PrivacyProtocol:          gosnmp.AES,
+		PrivacyPassphrase:        "privpassword",
+	}, &USMUser{Name: "shaaes", AuthProtocol: "SHA", AuthPassword: "wrongpassword", PrivProtocol: "AES", PrivPassword: "privpassword"})
+
+	// wrong privacy password
+	send(gosnmp.AuthPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "md5des",
+		AuthenticationProtocol:   gosnmp.MD5,
+		AuthenticationPassphrase: "authpassword",
+		PrivacyProtocol:          gosnmp.DES,
+		PrivacyPassphrase:        "wrongpassword",
+	}, &USMUser{Name: "md5des", AuthProtocol: "MD5", AuthPassword: "authpassword", PrivProtocol: "DES", PrivPassword: "wrongpassword"})
+
+	// The packets are handled in order, once a valid trap is received the
+	// others have been dropped.
+	send(gosnmp.NoAuthNoPriv, &gosnmp.UsmSecurityParameters{UserName: "noauth"}, testUsers[0])
+	waitReceived(t, received)
+
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{coldStartMetric(map[string]string{"version": "3", "sec_name": "noauth"})},
+		acc.GetTelegrafMetrics())
+	for name, get := range counters {
+		require.Equal(t, int64(1), get()-before[name], name)
+	}
+}
+
+func TestMinSecurityLevel(t *testing.T) {
+	s := &SnmpTrap{MinSecurityLevel: "authPriv"}
+	acc, received := startV3(t, s)
+	defer s.Stop()
+
+	before := s.unsupportedSecLevels.Get()
+
+	sendTrap(t, v3Port)
+
+	c := newV3Client(t, gosnmp.AuthNoPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "md5",
+		AuthenticationProtocol:   gosnmp.MD5,
+		AuthenticationPassphrase: "authpassword",
+	})
+	defer c.Conn.Close()
+	foreignEngine(t, c.SecurityParameters.(*gosnmp.UsmSecurityParameters), testUsers[1])
+	_, err := c.SendTrap(coldStart())
+	require.NoError(t, err)
+
+	c = newV3Client(t, gosnmp.AuthPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "shaaes",
+		AuthenticationProtocol:   gosnmp.SHA,
+		AuthenticationPassphrase: "authpassword",
+		PrivacyProtocol:          gosnmp.AES,
+		PrivacyPassphrase:        "privpassword",
+	})
+	defer c.Conn.Close()
+	foreignEngine(t, c.SecurityParameters.(*gosnmp.UsmSecurityParameters), testUsers[3])
+	_, err = c.SendTrap(coldStart())
+	require.NoError(t, err)
+	waitReceived(t, received)
+
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{coldStartMetric(map[string]string{"version": "3", "sec_name": "shaaes"})},
+		acc.GetTelegrafMetrics())
+	require.Equal(t, int64(2), s.unsupportedSecLevels.Get()-before)
+}
+
+func TestSecurityLevelMismatch(t *testing.T) {
+	s := &SnmpTrap{}
+	acc, received := startV3(t, s)
+	defer s.Stop()
+
+	before := s.unsupportedSecLevels.Get()
+
+	// A user without authentication has no digest to check.
+	c := newV3Client(t, gosnmp.AuthNoPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "noauth",
+		AuthenticationProtocol:   gosnmp.MD5,
+		AuthenticationPassphrase: "authpassword",
+	})
+	defer c.Conn.Close()
+	foreignEngine(t, c.SecurityParameters.(*gosnmp.UsmSecurityParameters),
+		&USMUser{Name: "noauth", AuthProtocol: "MD5", AuthPassword: "authpassword"})
+	_, err := c.SendTrap(coldStart())
+	require.NoError(t, err)
+
+	// A user without privacy has no key to decrypt.
+	c = newV3Client(t, gosnmp.AuthPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "md5",
+		AuthenticationProtocol:   gosnmp.MD5,
+		AuthenticationPassphrase: "authpassword",
+		PrivacyProtocol:          gosnmp.DES,
+		PrivacyPassphrase:        "privpassword",
+	})
+	defer c.Conn.Close()
+	foreignEngine(t, c.SecurityParameters.(*gosnmp.UsmSecurityParameters),
+		&USMUser{Name: "md5", AuthProtocol: "MD5", AuthPassword: "authpassword", PrivProtocol: "DES", PrivPassword: "privpassword"})
+	_, err = c.SendTrap(coldStart())
+	require.NoError(t, err)
+
+	c = newV3Client(t, gosnmp.NoAuthNoPriv, &gosnmp.UsmSecurityParameters{UserName: "noauth"})
+	defer c.Conn.Close()
+	foreignEngine(t, c.SecurityParameters.(*gosnmp.UsmSecurityParameters), testUsers[0])
+	_, err = c.SendTrap(coldStart())
+	require.NoError(t, err)
+	waitReceived(t, received)
+
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{coldStartMetric(map[string]string{"version": "3", "sec_name": "noauth"})},
+		acc.GetTelegrafMetrics())
+	require.Equal(t, int64(2), s.unsupportedSecLevels.Get()-before)
+}
+
+func TestMissingKeys(t *testing.T) {
+	noauth := *testUsers[0]
+	require.NoError(t, noauth.init())
+	md5 := *testUsers[1]
+	require.NoError(t, md5.init())
+
+	engineID := []byte("\x80\x00\x1f\x88\x04engine")
+	m := &v3Message{raw: []byte{0}}
+	require.False(t, noauth.authentic(noauth.localize(engineID), m))
+
+	m = &v3Message{data: []byte{tagOctetString, 8, 0, 0, 0, 0, 0, 0, 0, 0}, privParams: make([]byte, 8)}
+	_, err := md5.decrypt(md5.localize(engineID), m)
+	require.Error(t, err)
+}
+
+// exchange sends a packet to the plugin and returns the response.
+func exchange(t *testing.T, packet []byte) []byte {
+	conn, err := net.Dial("udp", "127.0.0.1:"+strconv.Itoa(v3Port))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write(packet)
+	require.NoError(t, err)
+
+	buf := make([]byte, 65536)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return buf[:n]
+}
+
+// decodeV3 decodes a message sent by the plugin, checking its digest.
+func decodeV3(packet []byte, flags gosnmp.SnmpV3MsgFlags, usm *gosnmp.UsmSecurityParameters) *gosnmp.SnmpPacket {
+	usm.Logger = log.New(ioutil.Discard, "", 0)
+	decoder := &gosnmp.GoSNMP{
+		Version:            gosnmp.Version3,
+		SecurityModel:      gosnmp.UserSecurityModel,
+		MsgFlags:           flags,
+		SecurityParameters: usm,
+	}
+	return decoder.UnmarshalTrap(packet)
+}
+
+func TestV3Inform(t *testing.T) {
+	s := &SnmpTrap{}
+	acc, received := startV3(t, s)
+	defer s.Stop()
+
+	usm := &gosnmp.UsmSecurityParameters{
+		UserName:                 "shaaes",
+		AuthenticationProtocol:   gosnmp.SHA,
+		AuthenticationPassphrase: "authpassword",
+		PrivacyProtocol:          gosnmp.AES,
+		PrivacyPassphrase:        "privpassword",
+	}
+	c := newV3Client(t, gosnmp.AuthPriv, usm)
+	defer c.Conn.Close()
+	discover(t, c, s)
+
+	sp := c.SecurityParameters.Copy().(*gosnmp.UsmSecurityParameters)
+	sp.PrivacyParameters = []byte{0, 0, 0, 0, 0, 0, 0, 1}
+	inform := &gosnmp.SnmpPacket{
+		Version:            gosnmp.Version3,
+		MsgFlags:           gosnmp.AuthPriv | gosnmp.Reportable,
+		SecurityModel:      gosnmp.UserSecurityModel,
+		SecurityParameters: sp,
+		ContextEngineID:    string(s.engineID),
+		PDUType:            gosnmp.InformRequest,
+		MsgID:              7,
+		RequestID:          42,
+		Variables:          coldStart().Variables,
+	}
+	packet, err := inform.MarshalMsg()
+	require.NoError(t, err)
+
+	// The response is decoded and authenticated by gosnmp.
+	decoded := decodeV3(exchange(t, packet), gosnmp.AuthPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "shaaes",
+		AuthenticationProtocol:   gosnmp.SHA,
+		AuthenticationPassphrase: "authpassword",
+		PrivacyProtocol:          gosnmp.AES,
+		PrivacyPassphrase:        "privpassword",
+	})
+	require.NotNil(t, decoded)
+	require.Equal(t, gosnmp.GetResponse, decoded.PDUType)
+	require.Equal(t, uint32(7), decoded.MsgID)
+	require.Equal(t, uint32(42), decoded.RequestID)
+	require.Len(t, decoded.Variables, 2)
+
+	waitReceived(t, received)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{coldStartMetric(map[string]string{"version": "3", "sec_name": "shaaes"})},
+		acc.GetTelegrafMetrics())
+}
+
+func TestV3InformNotInTimeWindow(t *testing.T) {
+	s := &SnmpTrap{}
+	_, _ = startV3(t, s)
+	defer s.Stop()
+
+	before := s.notInTimeWindows.Get()
+
+	u := *testUsers[1]
+	require.NoError(t, u.init())
+	keys := u.localize(s.engineID)
+	inform := &gosnmp.SnmpPacket{
+		Version:       gosnmp.Version3,
+		MsgFlags:      gosnmp.AuthNoPriv | gosnmp.Reportable,
+		SecurityModel: gosnmp.UserSecurityModel,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{
+			AuthoritativeEngineID:    string(s.engineID),
+			AuthoritativeEngineBoots: engineBoots,
+			AuthoritativeEngineTime:  1000,
+			UserName:                 "md5",
+			AuthenticationProtocol:   gosnmp.MD5,
+			SecretKey:                keys.auth,
+		},
+		ContextEngineID: string(s.engineID),
+		PDUType:         gosnmp.InformRequest,
+		MsgID:           7,
+		RequestID:       42,
+		Variables:       coldStart().Variables,
+	}
+	packet, err := inform.MarshalMsg()
+	require.NoError(t, err)
+
+	// The report is authenticated.
+	decoded := decodeV3(exchange(t, packet), gosnmp.AuthNoPriv, &gosnmp.UsmSecurityParameters{
+		UserName:                 "md5",
+		AuthenticationProtocol:   gosnmp.MD5,
+		AuthenticationPassphrase: "authpassword",
+	})
+	require.NotNil(t, decoded)
+	require.Equal(t, gosnmp.Report, decoded.PDUType)
+	require.Equal(t, uint32(7), decoded.MsgID)
+	require.Len(t, decoded.Variables, 1)
+	require.Equal(t, ".1.3.6.1.6.3.15.1.1.2.0", decoded.Variables[0].Name)
+	require.Equal(t, int64(1), s.notInTimeWindows.Get()-before)
+}
+
+func TestV3InformSHA2(t *testing.T) {
+	// gosnmp doesn't support the SHA-2 protocols, the inform is encoded
+	// by the plugin itself.
+	s := &SnmpTrap{}
+	testUsers = append(testUsers, &USMUser{Name: "sha512", AuthProtocol: "SHA512", AuthPassword: "authpassword", PrivProtocol: "AES", PrivPassword: "privpassword"})
+	defer func() { testUsers = testUsers[:len(testUsers)-1] }()
+	acc, received := startV3(t, s)
+	defer s.Stop()
+
+	packet, err := (&gosnmp.SnmpPacket{
+		Version:   gosnmp.Version2c,
+		PDUType:   gosnmp.InformRequest,
+		RequestID: 42,
+		Variables: coldStart().Variables,
+	}).MarshalMsg()
+	require.NoError(t, err)
+	body, _, err := readExpected(packet, tagSequence)
+	require.NoError(t, err)
+	_, body, err = readInteger(body)
+	require.NoError(t, err)
+	_, pdu, err := readExpected(body, tagOctetString)
+	require.NoError(t, err)
+
+	// The user of the plugin caches keys while receiving, the test uses
+	// its own copy.
+	user := *testUsers[len(testUsers)-1]
+	require.NoError(t, user.init())
+	inform, err := s.encodeV3(7, authPriv, &user, []byte("sha512"), s.engineID, nil, pdu)
+	require.NoError(t, err)
+
+	response, err := parseV3(exchange(t, inform))
+	require.NoError(t, err)
+	require.Equal(t, int64(7), response.msgID)
+	keys := user.localize(s.engineID)
+	require.True(t, user.authentic(keys, response))
+	scopedPDU, err := user.decrypt(keys, response)
+	require.NoError(t, err)
+	_, _, responsePDU, err := parseScopedPDU(scopedPDU)
+	require.NoError(t, err)
+	require.Equal(t, byte(tagGetResponse), responsePDU[0])
+	id, err := requestID(responsePDU)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), id)
+
+	waitReceived(t, received)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{coldStartMetric(map[string]string{"version": "3", "sec_name": "sha512"})},
+		acc.GetTelegrafMetrics())
+}
@@ -0,0 +1,515 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	_ "github.com/denisenkom/go-mssqldb"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+const sampleConfig = `
+  ## Database driver
+  ## Valid options: mysql (MySQL), pgx (Postgres), sqlserver (MS SQL Server),
+  ##   sqlite3 (SQLite)
+  driver = "mysql"
+
+  ## Data source name for connecting
+  ## The format of the data source name is different for each database driver.
+  ## See the plugin readme for details.
+  dsn = "username:password@tcp(host:port)/dbname"
+
+  ## Timeout for any operation
+  # timeout = "5s"
+
+  ## Connection pool settings
+  ## The maximum number of open and idle connections, 0 means unlimited for
+  ## open connections and the default of the driver for idle connections.
+  # max_open_connections = 0
+  # max_idle_connections = 0
+  ## The maximum amount of time a connection may be reused, 0 means forever.
+  # connection_max_lifetime = "0s"
+
+  ## Queries to perform
+  [[inputs.sql.query]]
+    ## Query to perform on the server
+    query = "SELECT user,state,latency,score FROM Scoreboard WHERE application > 0"
+
+    ## Name of the measurement
+    ## In case both measurement and 'measurement_column' are given, the latter takes precedence.
+    # measurement = "sql"
+
+    ## Column name containing the name of the measurement
+    ## If given, this will take precedence over the 'measurement' setting. In case a query result
+    ## does not contain the specified column, we fall-back to the 'measurement' setting.
+    # measurement_column = ""
+
+    ## Column name containing the time of the measurement
+    ## If omitted, the time of the query will be used.
+    # time_column = ""
+
+    ## Format of the time contained in 'time_column'
+    ## The time must be 'unix', 'unix_ms', 'unix_us', 'unix_ns', or a golang time format.
+    ## Database time types are used as is.
+    # time_format = "unix"
+
+    ## Minimum time between two executions of the query, 0 means every interval.
+    # interval = "0s"
+
+    ## Column names containing tags
+    ## An empty include list will reject all columns and an empty exclude list will not exclude any column.
+    ## I.e. by default no columns will be returned as tag and the tags are empty.
+    # tag_columns_include = []
+    # tag_columns_exclude = []
+
+    ## Column names containing fields (explicit types)
+    ## Convert the given columns to the corresponding type. Explicit type conversions take precedence over
+    ## the automatic (driver-based) conversion below.
+    ## NOTE: Columns should not be specified for multiple types or the resulting type is undefined.
+    # field_columns_float = []
+    # field_columns_int = []
+    # field_columns_uint = []
+    # field_columns_bool = []
+    # field_columns_string = []
+
+    ## Column names containing fields (automatic types)
+    ## An empty include list is equivalent to '[*]' and all returned columns will be accepted. An empty
+    ## exclude list will not exclude any column. I.e. by default all columns will be returned as fields.
+    ## NOTE: We rely on the database driver to perform automatic datatype conversion.
+    # field_columns_include = []
+    # field_columns_exclude = []
+`
+
+// Query is a query and the mapping of its columns to a metric.
+type Query struct {
+	Query               string            `toml:"query"`
+	Measurement         string            `toml:"measurement"`
+	MeasurementColumn   string            `toml:"measurement_column"`
+	TimeColumn          string            `toml:"time_column"`
+	TimeFormat          string            `toml:"time_format"`
+	Interval            internal.Duration `toml:"interval"`
+	TagColumnsInclude   []string          `toml:"tag_columns_include"`
+	TagColumnsExclude   []string          `toml:"tag_columns_exclude"`
+	FieldColumnsInclude []string          `toml:"field_columns_include"`
+	FieldColumnsExclude []string          `toml:"field_columns_exclude"`
+	FieldColumnsFloat   []string          `toml:"field_columns_float"`
+	FieldColumnsInt     []string          `toml:"field_columns_int"`
+	FieldColumnsUint    []string          `toml:"field_columns_uint"`
+	FieldColumnsBool    []string          `toml:"field_columns_bool"`
+	FieldColumnsString  []string          `toml:"field_columns_string"`
+
+	tagFilter        filter.Filter
+	fieldFilter      filter.Filter
+	fieldFilterFloat filter.Filter
+	fieldFilterInt   filter.Filter
+	fieldFilterUint  filter.Filter
+	fieldFilterBool  filter.Filter
+	fieldFilterStr   filter.Filter
+
+	lastRun time.Time
+}
+
+func (q *Query) init() error {
+	var err error
+
+	if q.Query == "" {
+		return errors.New("query is empty")
+	}
+	if q.Measurement == "" {
+		q.Measurement = "sql"
+	}
+	if q.TimeFormat == "" {
+		q.TimeFormat = "unix"
+	}
+
+	// An empty include list rejects all columns for tags.
+	if len(q.TagColumnsInclude) > 0 {
+		q.tagFilter, err = filter.NewIncludeExcludeFilter(q.TagColumnsInclude, q.TagColumnsExclude)
+		if err != nil {
+			return fmt.Errorf("creating tag filter failed: %v", err)
+		}
+	}
+
+	q.fieldFilter, err = filter.NewIncludeExcludeFilter(q.FieldColumnsInclude, q.FieldColumnsExclude)
+	if err != nil {
+		return fmt.Errorf("creating field filter failed: %v", err)
+	}
+
+	for _, f := range []struct {
+		filter  *filter.Filter
+		columns []string
+	}{
+		{&q.fieldFilterFloat, q.FieldColumnsFloat},
+		{&q.fieldFilterInt, q.FieldColumnsInt},
+		{&q.fieldFilterUint, q.FieldColumnsUint},
+		{&q.fieldFilterBool, q.FieldColumnsBool},
+		{&q.fieldFilterStr, q.FieldColumnsString},
+	} {
+		*f.filter, err = filter.Compile(f.columns)
+		if err != nil {
+			return fmt.Errorf("creating field filter failed: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// due reports if the query should be executed at the given time and
+// records the execution.
+func (q *Query) due(now time.Time) bool {
+	if q.Interval.Duration > 0 && !q.lastRun.IsZero() && now.Sub(q.lastRun) < q.Interval.Duration {
+		return false
+	}
+	q.lastRun = now
+	return true
+}
+
+func (q *Query) parse(acc telegraf.Accumulator, rows *sql.Rows, now time.Time) (int, error) {
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	// Prepare the list of datapoints according to the received row
+	columnData := make([]interface{}, len(columnNames))
+	columnDataPtr := make([]interface{}, len(columnNames))
+
+	for i := range columnData {
+		columnDataPtr[i] = &columnData[i]
+	}
+
+	rowCount := 0
+	for rows.Next() {
+		measurement := q.Measurement
+		timestamp := now
+		tags := make(map[string]string)
+		fields := make(map[string]interface{}, len(columnNames))
+
+		// Do the parsing with (hopefully) automatic type conversion
+		if err := rows.Scan(columnDataPtr...); err != nil {
+			return 0, err
+		}
+
+		for i, name := range columnNames {
+			value := columnData[i]
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+
+			if q.MeasurementColumn != "" && name == q.MeasurementColumn {
+				if value != nil {
+					measurement = fmt.Sprint(value)
+				}
+				continue
+			}
+
+			if q.TimeColumn != "" && name == q.TimeColumn {
+				switch v := value.(type) {
+				case time.Time:
+					timestamp = v
+				case nil:
+				default:
+					timestamp, err = internal.ParseTimestamp(q.TimeFormat, v, "")
+					if err != nil {
+						return 0, fmt.Errorf("parsing time in column %q failed: %v", name, err)
+					}
+				}
+				continue
+			}
+
+			if value == nil {
+				continue
+			}
+
+			if q.tagFilter != nil && q.tagFilter.Match(name) {
+				tags[name] = toString(value)
+				continue
+			}
+
+			if !q.fieldFilter.Match(name) {
+				continue
+			}
+
+			var fieldvalue interface{}
+			switch {
+			case q.fieldFilterFloat != nil && q.fieldFilterFloat.Match(name):
+				fieldvalue, err = toFloat(value)
+			case q.fieldFilterInt != nil && q.fieldFilterInt.Match(name):
+				fieldvalue, err = toInt(value)
+			case q.fieldFilterUint != nil && q.fieldFilterUint.Match(name):
+				fieldvalue, err = toUint(value)
+			case q.fieldFilterBool != nil && q.fieldFilterBool.Match(name):
+				fieldvalue, err = toBool(value)
+			case q.fieldFilterStr != nil && q.fieldFilterStr.Match(name):
+				fieldvalue = toString(value)
+			default:
+				fieldvalue, err = fieldValue(value)
+			}
+			if err != nil {
+				return 0, fmt.Errorf("converting field column %q failed: %v", name, err)
+			}
+			fields[name] = fieldvalue
+		}
+
+		acc.AddFields(measurement, fields, tags, timestamp)
+		rowCount++
+	}
+
+	return rowCount, rows.Err()
+}
+
+type SQL struct {
+	Driver                string            `toml:"driver"`
+	Dsn                   string            `toml:"dsn"`
+	Timeout               internal.Duration `toml:"timeout"`
+	MaxOpenConnections    int               `toml:"max_open_connections"`
+	MaxIdleConnections    int               `toml:"max_idle_connections"`
+	ConnectionMaxLifetime internal.Duration `toml:"connection_max_lifetime"`
+	Queries               []*Query          `toml:"query"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	db *sql.DB
+}
+
+func (s *SQL) Description() string {
+	return "Read metrics from SQL queries"
+}
+
+func (s *SQL) SampleConfig() string {
+	return sampleConfig
+}
+
+// sqliteAvailable is set when the SQLite driver is part of the build.
+var sqliteAvailable bool
+
+func (s *SQL) Init() error {
+	switch s.Driver {
+	case "mysql", "pgx", "sqlserver":
+	case "sqlite3":
+		if !sqliteAvailable {
+			return errors.New("the sqlite3 driver requires a build with cgo")
+		}
+	case "":
+		return errors.New("missing driver option")
+	default:
+		return fmt.Errorf("unsupported driver %q", s.Driver)
+	}
+
+	if s.Dsn == "" {
+		return errors.New("missing data source name (DSN) option")
+	}
+
+	if s.Timeout.Duration <= 0 {
+		s.Timeout.Duration = 5 * time.Second
+	}
+
+	if len(s.Queries) == 0 {
+		return errors.New("no query configured")
+	}
+	for i, q := range s.Queries {
+		if err := q.init(); err != nil {
+			return fmt.Errorf("query %d: %v", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Start opens the connection pool, shared by all queries and intervals.
+func (s *SQL) Start(_ telegraf.Accumulator) error {
+	db, err := sql.Open(s.Driver, s.Dsn)
+	if err != nil {
+		return err
+	}
+
+	db.SetMaxOpenConns(s.MaxOpenConnections)
+	db.SetMaxIdleConns(s.MaxIdleConnections)
+	db.SetConnMaxLifetime(s.ConnectionMaxLifetime.Duration)
+
+	// Connection errors are not fatal, the server may become available later.
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout.Duration)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		s.Log.Errorf("Connecting to the database failed: %v", err)
+	}
+
+	s.db = db
+	return nil
+}
+
+func (s *SQL) Stop() {
+	if s.db == nil {
+		return
+	}
+	if err := s.db.Close(); err != nil {
+		s.Log.Errorf("Closing the database failed: %v", err)
+	}
+}
+
+func (s *SQL) Gather(acc telegraf.Accumulator) error {
+	var wg sync.WaitGroup
+	now := time.Now()
+
+	for _, q := range s.Queries {
+		if !q.due(now) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(q *Query) {
+			defer wg.Done()
+			if err := s.executeQuery(acc, q, now); err != nil {
+				acc.AddError(err)
+			}
+		}(q)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (s *SQL) executeQuery(acc telegraf.Accumulator, q *Query, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout.Duration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, q.Query)
+	if err != nil {
+		return fmt.Errorf("query %q failed: %v", q.Query, err)
+	}
+	defer rows.Close()
+
+	rowCount, err := q.parse(acc, rows, now)
+	if err != nil {
+		return fmt.Errorf("query %q failed: %v", q.Query, err)
+	}
+	s.Log.Debugf("Received %d rows for query %q", rowCount, q.Query)
+
+	return nil
+}
+
+// fieldValue converts the value returned by the driver into a field value.
+func fieldValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64, uint64, float64, bool, string:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float32:
+		return float64(v), nil
+	case time.Time:
+		return v.UnixNano(), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", value)
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("unsupported type %T", value)
+}
+
+func toInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case uint64:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case time.Time:
+		return v.UnixNano(), nil
+	}
+	return 0, fmt.Errorf("unsupported type %T", value)
+}
+
+func toUint(value interface{}) (uint64, error) {
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return 0, fmt.Errorf("negative value %d", v)
+		}
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case float64:
+		if v < 0 {
+			return 0, fmt.Errorf("negative value %v", v)
+		}
+		return uint64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	}
+	return 0, fmt.Errorf("unsupported type %T", value)
+}
+
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case int64:
+		return v != 0, nil
+	case uint64:
+		return v != 0, nil
+	case float64:
+		return v != 0, nil
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	}
+	return false, fmt.Errorf("unsupported type %T", value)
+}
+
+func init() {
+	inputs.Add("sql", func() telegraf.Input {
+		return &SQL{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin *SQL
+		err    string
+	}{
+		{
+			name:   "missing driver",
+			plugin: &SQL{Dsn: "dsn", Queries: []*Query{{Query: "SELECT 1"}}},
+			err:    "missing driver option",
+		},
+		{
+			name:   "unsupported driver",
+			plugin: &SQL{Driver: "oracle", Dsn: "dsn", Queries: []*Query{{Query: "SELECT 1"}}},
+			err:    `unsupported driver "oracle"`,
+		},
+		{
+			name:   "missing dsn",
+			plugin: &SQL{Driver: "mysql", Queries: []*Query{{Query: "SELECT 1"}}},
+			err:    "missing data source name (DSN) option",
+		},
+		{
+			name:   "missing query",
+			plugin: &SQL{Driver: "mysql", Dsn: "dsn"},
+			err:    "no query configured",
+		},
+		{
+			name:   "empty query",
+			plugin: &SQL{Driver: "mysql", Dsn: "dsn", Queries: []*Query{{Query: "SELECT 1"}, {}}},
+			err:    "query 2: query is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.EqualError(t, tt.plugin.Init(), tt.err)
+		})
+	}
+}
+
+func TestQueryDue(t *testing.T) {
+	q := &Query{Interval: internal.Duration{Duration: time.Minute}}
+	now := time.Unix(1000, 0)
+	require.True(t, q.due(now))
+	require.False(t, q.due(now.Add(30*time.Second)))
+	require.True(t, q.due(now.Add(time.Minute)))
+
+	// Without an interval the query runs on every gather.
+	q = &Query{}
+	require.True(t, q.due(now))
+	require.True(t, q.due(now))
+}
+
+func TestConversions(t *testing.T) {
+	f, err := toFloat("1.5")
+	require.NoError(t, err)
+	require.Equal(t, 1.5, f)
+
+	i, err := toInt("42")
+	require.NoError(t, err)
+	require.Equal(t, int64(42), i)
+
+	u, err := toUint(int64(42))
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), u)
+	_, err = toUint(int64(-1))
+	require.Error(t, err)
+
+	b, err := toBool("true")
+	require.NoError(t, err)
+	require.True(t, b)
+
+	require.Equal(t, "2020-01-01T00:00:00Z", toString(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.Equal(t, "42", toString(int64(42)))
+
+	v, err := fieldValue(time.Unix(0, 42))
+	require.NoError(t, err)
+	require.Equal(t, int64(42), v)
+	_, err = fieldValue([]int{1})
+	require.Error(t, err)
+}
+
+func TestPostgresIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	dsn := fmt.Sprintf("host=%s user=postgres sslmode=disable", testutil.GetLocalHost())
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	for _, stmt := range []string{
+		`DROP TABLE IF EXISTS scoreboard`,
+		`CREATE TABLE scoreboard(name TEXT, kind TEXT, ts TIMESTAMP, score NUMERIC(10,2), games INT, active BOOLEAN)`,
+		`INSERT INTO scoreboard VALUES('alice', 'player', '2020-01-01 00:00:00', 42.5, 3, true)`,
+		`INSERT INTO scoreboard VALUES('bob', 'player', '2020-01-02 00:00:00', 17, 1, false)`,
+	} {
+		_, err = db.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	plugin := &SQL{
+		Driver: "pgx",
+		Dsn:    dsn,
+		Queries: []*Query{{
+			Query:             "SELECT name, kind, ts, score, games, active FROM scoreboard ORDER BY name",
+			MeasurementColumn: "kind",
+			TimeColumn:        "ts",
+			TagColumnsInclude: []string{"name"},
+			FieldColumnsFloat: []string{"score"},
+		}},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"player",
+			map[string]string{"name": "alice"},
+			map[string]interface{}{"score": 42.5, "games": int64(3), "active": true},
+			time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		),
+		testutil.MustMetric(
+			"player",
+			map[string]string{"name": "bob"},
+			map[string]interface{}{"score": 17.0, "games": int64(1), "active": false},
+			time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
@@ -0,0 +1,11 @@
+// +build cgo
+
+package sql
+
+// The SQLite driver is written in C, it is only available in the builds
+// with cgo.
+import _ "github.com/mattn/go-sqlite3"
+
+func init() {
+	sqliteAvailable = true
+}
@@ -0,0 +1,166 @@
+// +build cgo
+
+package sql
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// sqliteDB creates a SQLite database in a temporary directory with the
+// given statements, and returns the DSN and a function removing it.
+func sqliteDB(t *testing.T, stmts ...string) (string, func()) {
+	dir, err := ioutil.TempDir("", "sql")
+	require.NoError(t, err)
+	dsn := filepath.Join(dir, "test.db")
+
+	db, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	for _, stmt := range stmts {
+		_, err = db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	return dsn, func() { os.RemoveAll(dir) }
+}
+
+func TestSQLiteColumnMapping(t *testing.T) {
+	dsn, cleanup := sqliteDB(t,
+		`CREATE TABLE scoreboard(name TEXT, kind TEXT, ts TIMESTAMP, score REAL, games INTEGER, active BOOLEAN, comment TEXT)`,
+		`INSERT INTO scoreboard VALUES('alice', 'player', '2020-01-01 00:00:00', 42.5, 3, 1, 'first')`,
+		`INSERT INTO scoreboard VALUES('bob', NULL, '2020-01-02 00:00:00', 17, NULL, 0, 'second')`,
+	)
+	defer cleanup()
+
+	plugin := &SQL{
+		Driver: "sqlite3",
+		Dsn:    dsn,
+		Queries: []*Query{{
+			Query:               "SELECT name, kind, ts, score, games, active, comment FROM scoreboard ORDER BY name",
+			Measurement:         "scores",
+			MeasurementColumn:   "kind",
+			TimeColumn:          "ts",
+			TagColumnsInclude:   []string{"name"},
+			FieldColumnsExclude: []string{"comment"},
+		}},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	// The NULL values are skipped, the measurement falls back to the
+	// measurement option.
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"player",
+			map[string]string{"name": "alice"},
+			map[string]interface{}{"score": 42.5, "games": int64(3), "active": true},
+			time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		),
+		testutil.MustMetric(
+			"scores",
+			map[string]string{"name": "bob"},
+			map[string]interface{}{"score": 17.0, "active": false},
+			time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestSQLiteConversions(t *testing.T) {
+	dsn, cleanup := sqliteDB(t,
+		`CREATE TABLE data(ts INTEGER, f TEXT, i TEXT, u INTEGER, b TEXT, s INTEGER)`,
+		`INSERT INTO data VALUES(1577836800, '1.5', '-42', 42, 'true', 7)`,
+	)
+	defer cleanup()
+
+	plugin := &SQL{
+		Driver: "sqlite3",
+		Dsn:    dsn,
+		Queries: []*Query{{
+			Query:              "SELECT ts, f, i, u, b, s FROM data",
+			TimeColumn:         "ts",
+			TimeFormat:         "unix",
+			FieldColumnsFloat:  []string{"f"},
+			FieldColumnsInt:    []string{"i"},
+			FieldColumnsUint:   []string{"u"},
+			FieldColumnsBool:   []string{"b"},
+			FieldColumnsString: []string{"s"},
+		}},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"sql",
+			map[string]string{},
+			map[string]interface{}{
+				"f": 1.5,
+				"i": int64(-42),
+				"u": uint64(42),
+				"b": true,
+				"s": "7",
+			},
+			time.Unix(1577836800, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// Values that cannot be converted are reported as errors
+	plugin.Queries[0].FieldColumnsInt = []string{"f"}
+	plugin.Queries[0].FieldColumnsFloat = nil
+	require.NoError(t, plugin.Queries[0].init())
+	acc.ClearMetrics()
+	require.Error(t, acc.GatherError(plugin.Gather))
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestSQLiteQueryInterval(t *testing.T) {
+	dsn, cleanup := sqliteDB(t,
+		`CREATE TABLE data(value INTEGER)`,
+		`INSERT INTO data VALUES(1)`,
+	)
+	defer cleanup()
+
+	plugin := &SQL{
+		Driver: "sqlite3",
+		Dsn:    dsn,
+		Queries: []*Query{
+			{Query: "SELECT value FROM data", Measurement: "always"},
+			{Query: "SELECT value FROM data", Measurement: "hourly", Interval: internal.Duration{Duration: time.Hour}},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	counts := make(map[string]int)
+	for _, m := range acc.GetTelegrafMetrics() {
+		counts[m.Name()]++
+	}
+	require.Equal(t, map[string]int{"always": 2, "hourly": 1}, counts)
+}
@@ -0,0 +1,274 @@
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Syslog standards accepted by the syslog_standard option.
+const (
+	standardRFC5424 = "RFC5424"
+	standardRFC3164 = "RFC3164"
+	standardAuto    = "auto"
+)
+
+// defaultRFC3164Priority is used in best effort mode when a message has no
+// PRI part, as recommended by RFC3164#section-4.3.3 (user.notice).
+const defaultRFC3164Priority = 13
+
+var severityShortLevels = []string{
+	"emerg",
+	"alert",
+	"crit",
+	"err",
+	"warning",
+	"notice",
+	"info",
+	"debug",
+}
+
+var facilityKeywords = []string{
+	"kern",
+	"user",
+	"mail",
+	"daemon",
+	"auth",
+	"syslog",
+	"lpr",
+	"news",
+	"uucp",
+	"cron",
+	"authpriv",
+	"ftp",
+	"ntp",
+	"security",
+	"console",
+	"solaris-cron",
+	"local0",
+	"local1",
+	"local2",
+	"local3",
+	"local4",
+	"local5",
+	"local6",
+	"local7",
+}
+
+// rfc3164Message is a message in the BSD syslog format.
+type rfc3164Message struct {
+	priority  uint8
+	timestamp *time.Time
+	hostname  string
+	appname   string
+	procid    string
+	message   string
+}
+
+// tags returns the message tags using the same layout as RFC5424 messages.
+func (m *rfc3164Message) tags() map[string]string {
+	ts := map[string]string{
+		"severity": severityShortLevels[m.priority%8],
+		"facility": facilityKeywords[m.priority/8],
+	}
+
+	if m.hostname != "" {
+		ts["hostname"] = m.hostname
+	}
+
+	if m.appname != "" {
+		ts["appname"] = m.appname
+	}
+
+	return ts
+}
+
+// fields returns the message fields using the same layout as RFC5424
+// messages.  RFC3164 has no version, message id or structured data.
+func (m *rfc3164Message) fields() map[string]interface{} {
+	flds := map[string]interface{}{
+		"severity_code": int(m.priority % 8),
+		"facility_code": int(m.priority / 8),
+	}
+
+	if m.timestamp != nil {
+		flds["timestamp"] = m.timestamp.UnixNano()
+	}
+
+	if m.procid != "" {
+		flds["procid"] = m.procid
+	}
+
+	if m.message != "" {
+		flds["message"] = strings.TrimRightFunc(m.message, unicode.IsSpace)
+	}
+
+	return flds
+}
+
+// rfc3164Parser parses messages in the BSD syslog format as described by
+// RFC3164#section-4.1, for example:
+//
+//	<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick
+//
+// The timestamp does not contain a year, it is taken from the current time
+// instead.
+type rfc3164Parser struct {
+	bestEffort bool
+	location   *time.Location
+	now        func() time.Time
+}
+
+func newRFC3164Parser(bestEffort bool) *rfc3164Parser {
+	return &rfc3164Parser{
+		bestEffort: bestEffort,
+		location:   time.Local,
+		now:        time.Now,
+	}
+}
+
+// Parse parses a single message.  In best effort mode a partially parsed
+// message may be returned together with the error.
+func (p *rfc3164Parser) Parse(b []byte) (*rfc3164Message, error) {
+	b = bytes.TrimRight(b, "\r\n\x00")
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty message")
+	}
+
+	m := &rfc3164Message{}
+	rest, err := p.parsePriority(m, b)
+	if err != nil {
+		if !p.bestEffort {
+			return nil, err
+		}
+		m.priority = defaultRFC3164Priority
+		rest = b
+	}
+
+	next, err := p.parseTimestamp(m, rest)
+	if err != nil {
+		if !p.bestEffort {
+			return nil, err
+		}
+		m.message = string(rest)
+		return m, err
+	}
+	rest = next
+
+	// The hostname is optional in practice, many daemons logging to a local
+	// socket omit it; a token that looks like a tag is not a hostname.
+	token, next := nextToken(rest)
+	if !isTag(token) {
+		m.hostname = string(token)
+		rest = next
+	}
+
+	token, next = nextToken(rest)
+	if isTag(token) {
+		p.parseTag(m, token)
+		rest = next
+	}
+
+	m.message = string(rest)
+	return m, nil
+}
+
+func (p *rfc3164Parser) parsePriority(m *rfc3164Message, b []byte) ([]byte, error) {
+	if b[0] != '<' {
+		return nil, fmt.Errorf("expecting a priority value within angle brackets [col 0]")
+	}
+	end := bytes.IndexByte(b, '>')
+	if end < 2 || end > 4 {
+		return nil, fmt.Errorf("expecting a priority value within angle brackets [col 0]")
+	}
+	prio, err := strconv.ParseUint(string(b[1:end]), 10, 8)
+	if err != nil || prio > 191 {
+		return nil, fmt.Errorf("expecting a priority value in the range 0-191 [col 1]")
+	}
+	m.priority = uint8(prio)
+	return b[end+1:], nil
+}
+
+func (p *rfc3164Parser) parseTimestamp(m *rfc3164Message, b []byte) ([]byte, error) {
+	// Some senders, such as rsyslog with high precision timestamps, use
+	// RFC3339 timestamps instead of the BSD format.
+	if len(b) > 0 && b[0] >= '0' && b[0] <= '9' {
+		token, rest := nextToken(b)
+		t, err := time.Parse(time.RFC3339Nano, string(token))
+		if err != nil {
+			return nil, fmt.Errorf("expecting a timestamp: %v", err)
+		}
+		m.timestamp = &t
+		return rest, nil
+	}
+
+	if len(b) < len(time.Stamp) {
+		return nil, fmt.Errorf("expecting a timestamp in the format %q", time.Stamp)
+	}
+	t, err := time.ParseInLocation(time.Stamp, string(b[:len(time.Stamp)]), p.location)
+	if err != nil {
+		return nil, fmt.Errorf("expecting a timestamp in the format %q", time.Stamp)
+	}
+
+	// Assume the message was sent less than a day in the future, this
+	// handles messages sent on December 31 and received on January 1.
+	now := p.now().In(p.location)
+	t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, p.location)
+	if t.Sub(now) > 24*time.Hour {
+		t = t.AddDate(-1, 0, 0)
+	}
+	m.timestamp = &t
+
+	return bytes.TrimLeft(b[len(time.Stamp):], " "), nil
+}
+
+// parseTag extracts the appname and the optional procid from the tag, for
+// example "su[123]:".
+func (p *rfc3164Parser) parseTag(m *rfc3164Message, tag []byte) {
+	tag = bytes.TrimSuffix(tag, []byte(":"))
+	if start := bytes.IndexByte(tag, '['); start >= 0 {
+		if end := bytes.IndexByte(tag[start:], ']'); end > 0 {
+			m.procid = string(tag[start+1 : start+end])
+		}
+		tag = tag[:start]
+	}
+	m.appname = string(tag)
+}
+
+func nextToken(b []byte) ([]byte, []byte) {
+	i := bytes.IndexByte(b, ' ')
+	if i < 0 {
+		return b, nil
+	}
+	return b[:i], bytes.TrimLeft(b[i+1:], " ")
+}
+
+func isTag(token []byte) bool {
+	if len(token) < 2 {
+		return false
+	}
+	return token[len(token)-1] == ':' || bytes.HasSuffix(token, []byte("]"))
+}
+
+// isRFC5424 reports if the message looks like an RFC5424 message; these have
+// a version number following the priority.
+func isRFC5424(b []byte) bool {
+	end := bytes.IndexByte(b, '>')
+	if len(b) == 0 || b[0] != '<' || end < 0 || end > 4 {
+		return false
+	}
+	b = b[end+1:]
+	for i := 0; i < len(b) && i < 4; i++ {
+		switch {
+		case b[i] >= '0' && b[i] <= '9':
+			continue
+		case b[i] == ' ':
+			return i > 0 && b[0] != '0'
+		}
+		return false
+	}
+	return false
+}
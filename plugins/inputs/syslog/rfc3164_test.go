@@ -0,0 +1,252 @@
+package syslog
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	framing "github.com/influxdata/telegraf/internal/syslog"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var rfc3164Now = time.Date(2019, time.October, 12, 0, 0, 0, 0, time.UTC)
+
+func newTestRFC3164Parser(bestEffort bool) *rfc3164Parser {
+	p := newRFC3164Parser(bestEffort)
+	p.location = time.UTC
+	p.now = func() time.Time {
+		return rfc3164Now
+	}
+	return p
+}
+
+func TestRFC3164Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected *rfc3164Message
+	}{
+		{
+			name: "full",
+			data: "<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8\n",
+			expected: &rfc3164Message{
+				priority:  34,
+				timestamp: timePtr(time.Date(2019, time.October, 11, 22, 14, 15, 0, time.UTC)),
+				hostname:  "mymachine",
+				appname:   "su",
+				procid:    "123",
+				message:   "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name: "single digit day",
+			data: "<13>Feb  5 17:32:18 10.0.0.99 Use the BFG!",
+			expected: &rfc3164Message{
+				priority:  13,
+				timestamp: timePtr(time.Date(2019, time.February, 5, 17, 32, 18, 0, time.UTC)),
+				hostname:  "10.0.0.99",
+				message:   "Use the BFG!",
+			},
+		},
+		{
+			name: "no hostname",
+			data: "<30>Oct 11 22:14:15 systemd: Started Session 1 of user root.",
+			expected: &rfc3164Message{
+				priority:  30,
+				timestamp: timePtr(time.Date(2019, time.October, 11, 22, 14, 15, 0, time.UTC)),
+				appname:   "systemd",
+				message:   "Started Session 1 of user root.",
+			},
+		},
+		{
+			name: "previous year",
+			data: "<165>Dec 31 23:59:59 host app: happy new year",
+			expected: &rfc3164Message{
+				priority:  165,
+				timestamp: timePtr(time.Date(2018, time.December, 31, 23, 59, 59, 0, time.UTC)),
+				hostname:  "host",
+				appname:   "app",
+				message:   "happy new year",
+			},
+		},
+		{
+			name: "rfc3339 timestamp",
+			data: "<14>2019-10-11T22:14:15.003Z host app[42]: message",
+			expected: &rfc3164Message{
+				priority:  14,
+				timestamp: timePtr(time.Date(2019, time.October, 11, 22, 14, 15, 3000000, time.UTC)),
+				hostname:  "host",
+				appname:   "app",
+				procid:    "42",
+				message:   "message",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, bestEffort := range []bool{false, true} {
+				p := newTestRFC3164Parser(bestEffort)
+				m, err := p.Parse([]byte(tt.data))
+				require.NoError(t, err)
+				require.Equal(t, tt.expected, m)
+			}
+		})
+	}
+}
+
+func TestRFC3164ParseErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		bestEffort *rfc3164Message
+	}{
+		{
+			name: "missing priority",
+			data: "Oct 11 22:14:15 host app: message",
+			bestEffort: &rfc3164Message{
+				priority:  13,
+				timestamp: timePtr(time.Date(2019, time.October, 11, 22, 14, 15, 0, time.UTC)),
+				hostname:  "host",
+				appname:   "app",
+				message:   "message",
+			},
+		},
+		{
+			name: "invalid priority",
+			data: "<192>Oct 11 22:14:15 host app: message",
+			bestEffort: &rfc3164Message{
+				priority: 13,
+				message:  "<192>Oct 11 22:14:15 host app: message",
+			},
+		},
+		{
+			name: "missing timestamp",
+			data: "<34>host app: message",
+			bestEffort: &rfc3164Message{
+				priority: 34,
+				message:  "host app: message",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newTestRFC3164Parser(false).Parse([]byte(tt.data))
+			require.Error(t, err)
+			require.Nil(t, m)
+
+			m, _ = newTestRFC3164Parser(true).Parse([]byte(tt.data))
+			require.Equal(t, tt.bestEffort, m)
+		})
+	}
+}
+
+func TestIsRFC5424(t *testing.T) {
+	require.True(t, isRFC5424([]byte("<13>1 2018-10-01T12:00:00.0Z example.org root - - - test")))
+	require.True(t, isRFC5424([]byte("<1>12 - - - - - -")))
+	require.False(t, isRFC5424([]byte("<34>Oct 11 22:14:15 mymachine su: test")))
+	require.False(t, isRFC5424([]byte("<14>2019-10-11T22:14:15.003Z host app: message")))
+	require.False(t, isRFC5424([]byte("<13>0 - - - - - -")))
+	require.False(t, isRFC5424([]byte("test")))
+}
+
+func TestRFC3164Tags(t *testing.T) {
+	m := &rfc3164Message{
+		priority:  34,
+		timestamp: timePtr(time.Unix(1570832055, 0)),
+		hostname:  "mymachine",
+		appname:   "su",
+		procid:    "123",
+		message:   "'su root' failed\n",
+	}
+	require.Equal(t, map[string]string{
+		"severity": "crit",
+		"facility": "auth",
+		"hostname": "mymachine",
+		"appname":  "su",
+	}, m.tags())
+	require.Equal(t, map[string]interface{}{
+		"severity_code": 2,
+		"facility_code": 4,
+		"timestamp":     int64(1570832055000000000),
+		"procid":        "123",
+		"message":       "'su root' failed",
+	}, m.fields())
+}
+
+func TestRFC3164_udp(t *testing.T) {
+	receiver := newUDPSyslogReceiver("udp://"+address, false)
+	receiver.SyslogStandard = standardRFC3164
+	acc := &testutil.Accumulator{}
+	require.NoError(t, receiver.Start(acc))
+	defer receiver.Stop()
+
+	conn, err := net.Dial("udp", address)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("<34>Oct 11 22:14:15 mymachine su: 'su root' failed"))
+	require.NoError(t, err)
+	conn.Close()
+
+	acc.Wait(1)
+	m := acc.GetTelegrafMetrics()[0]
+	require.Equal(t, map[string]string{
+		"severity": "crit",
+		"facility": "auth",
+		"hostname": "mymachine",
+		"appname":  "su",
+	}, m.Tags())
+	require.Equal(t, "'su root' failed", m.Fields()["message"])
+}
+
+func TestRFC3164Framing_tcp(t *testing.T) {
+	tests := []struct {
+		name    string
+		framing framing.Framing
+		data    string
+	}{
+		{
+			name:    "octet counting",
+			framing: framing.OctetCounting,
+			data:    "29 <13>Oct 11 22:14:15 host a: 1" + "29 <13>Oct 11 22:14:15 host b: 2",
+		},
+		{
+			name:    "non transparent",
+			framing: framing.NonTransparent,
+			data:    "<13>Oct 11 22:14:15 host a: 1\n<13>Oct 11 22:14:15 host b: 2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := newTCPSyslogReceiver("tcp://"+address, nil, 0, false, tt.framing)
+			receiver.SyslogStandard = standardAuto
+			acc := &testutil.Accumulator{}
+			require.NoError(t, receiver.Start(acc))
+			defer receiver.Stop()
+
+			conn, err := net.Dial("tcp", address)
+			require.NoError(t, err)
+			_, err = conn.Write([]byte(tt.data))
+			require.NoError(t, err)
+			conn.Close()
+
+			acc.Wait(2)
+			require.Empty(t, acc.Errors)
+			var appnames []string
+			for _, m := range acc.GetTelegrafMetrics() {
+				appnames = append(appnames, m.Tags()["appname"])
+			}
+			require.Equal(t, []string{"a", "b"}, appnames)
+		})
+	}
+}
+
+func TestUnknownSyslogStandard(t *testing.T) {
+	receiver := newUDPSyslogReceiver("udp://"+address, false)
+	receiver.SyslogStandard = "RFC1234"
+	err := receiver.Start(&testutil.Accumulator{})
+	require.EqualError(t, err, "unknown syslog standard 'RFC1234'")
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
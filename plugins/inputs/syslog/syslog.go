@@ -1,12 +1,15 @@
 package syslog
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -37,6 +40,7 @@ type Syslog struct {
 	Trailer         nontransparent.TrailerType
 	BestEffort      bool
 	Separator       string `toml:"sdparam_separator"`
+	SyslogStandard  string `toml:"syslog_standard"`
 
 	now      func() time.Time
 	lastTime time.Time
@@ -91,6 +95,12 @@ var sampleConfig = `
   ## Must be one of "LF", or "NUL".
   # trailer = "LF"
 
+  ## The syslog standard of the messages (default = "RFC5424").
+  ## Must be one of "RFC5424", "RFC3164", or "auto".
+  ## With "auto" each message is detected as RFC5424 when a version follows
+  ## the priority, and parsed as RFC3164 otherwise.
+  # syslog_standard = "RFC5424"
+
   ## Whether to parse in best effort mode or not (default = false).
   ## By default best effort parsing is off.
   # best_effort = false
@@ -110,7 +120,7 @@ func (s *Syslog) SampleConfig() string {
 
 // Description returns the plugin description
 func (s *Syslog) Description() string {
-	return "Accepts syslog messages following RFC5424 format with transports as per RFC5426, RFC5425, or RFC6587"
+	return "Accepts syslog messages following RFC5424 or RFC3164 format with transports as per RFC5426, RFC5425, or RFC6587"
 }
 
 // Gather ...
@@ -129,6 +139,14 @@ func (s *Syslog) Start(acc telegraf.Accumulator) error {
 	}
 	s.Address = host
 
+	switch s.SyslogStandard {
+	case "":
+		s.SyslogStandard = standardRFC5424
+	case standardRFC5424, standardRFC3164, standardAuto:
+	default:
+		return fmt.Errorf("unknown syslog standard '%s'", s.SyslogStandard)
+	}
+
 	switch scheme {
 	case "tcp", "tcp4", "tcp6", "unix", "unixpacket":
 		s.isStream = true
@@ -218,12 +236,7 @@ func getAddressParts(a string) (string, string, error) {
 func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
 	defer s.wg.Done()
 	b := make([]byte, ipMaxPacketSize)
-	var p syslog.Machine
-	if s.BestEffort {
-		p = rfc5424.NewParser(rfc5424.WithBestEffort())
-	} else {
-		p = rfc5424.NewParser()
-	}
+	p := s.newMessageParser()
 	for {
 		n, _, err := s.udpListener.ReadFrom(b)
 		if err != nil {
@@ -233,13 +246,7 @@ func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
 			break
 		}
 
-		message, err := p.Parse(b[:n])
-		if message != nil {
-			acc.AddFields("syslog", fields(message, s), tags(message), s.time())
-		}
-		if err != nil {
-			acc.AddError(err)
-		}
+		p.parse(b[:n], acc)
 	}
 }
 
@@ -296,6 +303,11 @@ func (s *Syslog) handle(conn net.Conn, acc telegraf.Accumulator) {
 		conn.Close()
 	}()
 
+	if s.SyslogStandard != standardRFC5424 {
+		s.handleFramed(conn, acc)
+		return
+	}
+
 	var p syslog.Parser
 
 	emit := func(r *syslog.Result) {
@@ -330,6 +342,73 @@ func (s *Syslog) handle(conn net.Conn, acc telegraf.Accumulator) {
 	}
 }
 
+// handleFramed splits the stream into messages according to the framing and
+// parses each of them as RFC5424 or RFC3164 message.
+func (s *Syslog) handleFramed(conn net.Conn, acc telegraf.Accumulator) {
+	r := bufio.NewReader(conn)
+	p := s.newMessageParser()
+
+	trailer := byte('\n')
+	if s.Trailer == nontransparent.NUL {
+		trailer = 0
+	}
+
+	for {
+		var b []byte
+		var err error
+		if s.Framing == framing.OctetCounting {
+			b, err = readOctetCounted(r)
+		} else {
+			b, err = r.ReadBytes(trailer)
+			b = bytes.TrimSuffix(b, []byte{trailer})
+		}
+
+		if len(bytes.TrimSpace(b)) > 0 {
+			p.parse(b, acc)
+		}
+		if err != nil {
+			if err != io.EOF && !isClosedOrTimeout(err) {
+				acc.AddError(err)
+			}
+			return
+		}
+
+		if s.ReadTimeout != nil && s.ReadTimeout.Duration > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.ReadTimeout.Duration))
+		}
+	}
+}
+
+// readOctetCounted reads a single message framed as per RFC6587#section-3.4.1,
+// for example "11 <13>Oct 1 a".
+func readOctetCounted(r *bufio.Reader) ([]byte, error) {
+	prefix, err := r.ReadString(' ')
+	if err != nil {
+		if err == io.EOF && strings.TrimSpace(prefix) != "" {
+			return nil, fmt.Errorf("found EOF after %q, expecting a message length followed by a space", prefix)
+		}
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(strings.TrimLeft(prefix[:len(prefix)-1], "\r\n"))
+	if err != nil || length < 1 || length > ipMaxPacketSize {
+		return nil, fmt.Errorf("expecting a message length in the range 1-%d, found %q", ipMaxPacketSize, prefix)
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func isClosedOrTimeout(err error) bool {
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return true
+	}
+	return strings.HasSuffix(err.Error(), ": use of closed network connection")
+}
+
 func (s *Syslog) setKeepAlive(c *net.TCPConn) error {
 	if s.KeepAlivePeriod == nil {
 		return nil
@@ -353,6 +432,55 @@ func (s *Syslog) store(res syslog.Result, acc telegraf.Accumulator) {
 	}
 }
 
+// messageParser parses single messages according to the syslog standard.
+type messageParser struct {
+	s       *Syslog
+	rfc5424 syslog.Machine
+	rfc3164 *rfc3164Parser
+}
+
+func (s *Syslog) newMessageParser() *messageParser {
+	p := &messageParser{
+		s:       s,
+		rfc3164: newRFC3164Parser(s.BestEffort),
+	}
+	if s.BestEffort {
+		p.rfc5424 = rfc5424.NewParser(rfc5424.WithBestEffort())
+	} else {
+		p.rfc5424 = rfc5424.NewParser()
+	}
+	return p
+}
+
+func (p *messageParser) parse(b []byte, acc telegraf.Accumulator) {
+	standard := p.s.SyslogStandard
+	if standard == standardAuto {
+		standard = standardRFC3164
+		if isRFC5424(b) {
+			standard = standardRFC5424
+		}
+	}
+
+	if standard == standardRFC3164 {
+		message, err := p.rfc3164.Parse(b)
+		if message != nil {
+			acc.AddFields("syslog", message.fields(), message.tags(), p.s.time())
+		}
+		if err != nil {
+			acc.AddError(err)
+		}
+		return
+	}
+
+	message, err := p.rfc5424.Parse(b)
+	if message != nil {
+		acc.AddFields("syslog", fields(message, p.s), tags(message), p.s.time())
+	}
+	if err != nil {
+		acc.AddError(err)
+	}
+}
+
 func tags(msg syslog.Message) map[string]string {
 	ts := map[string]string{}
 
@@ -446,9 +574,10 @@ func init() {
 			ReadTimeout: &internal.Duration{
 				Duration: defaultReadTimeout,
 			},
-			Framing:   framing.OctetCounting,
-			Trailer:   nontransparent.LF,
-			Separator: "_",
+			Framing:        framing.OctetCounting,
+			Trailer:        nontransparent.LF,
+			Separator:      "_",
+			SyslogStandard: standardRFC5424,
 		}
 	})
 }
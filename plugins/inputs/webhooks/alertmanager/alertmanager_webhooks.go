@@ -0,0 +1,56 @@
+package alertmanager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs/webhooks/auth"
+)
+
+type AlertmanagerWebhook struct {
+	Path string
+	auth.Verifier
+
+	acc telegraf.Accumulator
+}
+
+func (am *AlertmanagerWebhook) Init() error {
+	return am.Verifier.Init("Authorization", "bearer")
+}
+
+func (am *AlertmanagerWebhook) Register(router *mux.Router, acc telegraf.Accumulator) {
+	router.HandleFunc(am.Path, am.eventHandler).Methods("POST")
+	log.Printf("I! Started the webhooks_alertmanager on %s\n", am.Path)
+	am.acc = acc
+}
+
+func (am *AlertmanagerWebhook) eventHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !am.Verify(r, data) {
+		log.Printf("E! Fail to check the alertmanager webhook signature\n")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for _, alert := range msg.Alerts {
+		am.acc.AddFields("alertmanager_webhooks", alert.Fields(), alert.Tags(&msg))
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
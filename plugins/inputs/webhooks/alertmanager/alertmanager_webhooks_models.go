@@ -0,0 +1,68 @@
+package alertmanager
+
+import (
+	"time"
+)
+
+// Message is the notification posted by Alertmanager for a group of
+// alerts, see https://prometheus.io/docs/alerting/configuration/#webhook_config
+type Message struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Tags returns the labels of the alert, which identify it, with the
+// receiver and the status of the alert.
+func (a Alert) Tags(msg *Message) map[string]string {
+	t := make(map[string]string, len(a.Labels)+2)
+	for k, v := range a.Labels {
+		t[k] = v
+	}
+	t["receiver"] = msg.Receiver
+	t["status"] = a.Status
+	return t
+}
+
+// Fields returns the state and the annotations of the alert.  The
+// annotations are not added over the other fields.
+func (a Alert) Fields() map[string]interface{} {
+	var firing int
+	if a.Status == "firing" {
+		firing = 1
+	}
+	f := map[string]interface{}{
+		"firing":        firing,
+		"fingerprint":   a.Fingerprint,
+		"generator_url": a.GeneratorURL,
+	}
+	if !a.StartsAt.IsZero() {
+		f["starts_at"] = a.StartsAt.Unix()
+	}
+	// Firing alerts have no end time
+	if !a.EndsAt.IsZero() {
+		f["ends_at"] = a.EndsAt.Unix()
+	}
+	for k, v := range a.Annotations {
+		if _, ok := f[k]; !ok {
+			f[k] = v
+		}
+	}
+	return f
+}
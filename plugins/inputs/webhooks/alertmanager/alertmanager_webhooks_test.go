@@ -0,0 +1,132 @@
+package alertmanager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const notificationJSON = `
+{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"HighLatency\"}",
+  "status": "firing",
+  "receiver": "telegraf",
+  "groupLabels": {"alertname": "HighLatency"},
+  "commonLabels": {"alertname": "HighLatency", "severity": "page"},
+  "commonAnnotations": {"summary": "High request latency"},
+  "externalURL": "http://alertmanager:9093",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighLatency", "severity": "page", "instance": "web-1"},
+      "annotations": {"summary": "High request latency", "firing": "collides"},
+      "startsAt": "2020-01-02T03:04:05Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "generatorURL": "http://prometheus:9090/graph",
+      "fingerprint": "0b7e1a2c3d4e5f60"
+    },
+    {
+      "status": "resolved",
+      "labels": {"alertname": "HighLatency", "severity": "page", "instance": "web-2"},
+      "annotations": {"summary": "High request latency"},
+      "startsAt": "2020-01-02T03:00:00Z",
+      "endsAt": "2020-01-02T03:10:00Z",
+      "generatorURL": "http://prometheus:9090/graph",
+      "fingerprint": "1c8f2b3d4e5f6071"
+    }
+  ]
+}`
+
+func postWebhooks(am *AlertmanagerWebhook, body string, header http.Header) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	w.Code = 500
+
+	am.eventHandler(w, req)
+
+	return w
+}
+
+func TestNotification(t *testing.T) {
+	var acc testutil.Accumulator
+	am := &AlertmanagerWebhook{Path: "/alertmanager", acc: &acc}
+	require.NoError(t, am.Init())
+
+	resp := postWebhooks(am, notificationJSON, nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"alertmanager_webhooks",
+			map[string]string{
+				"alertname": "HighLatency",
+				"severity":  "page",
+				"instance":  "web-1",
+				"receiver":  "telegraf",
+				"status":    "firing",
+			},
+			map[string]interface{}{
+				"firing":        1,
+				"fingerprint":   "0b7e1a2c3d4e5f60",
+				"generator_url": "http://prometheus:9090/graph",
+				"starts_at":     int64(1577934245),
+				"summary":       "High request latency",
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"alertmanager_webhooks",
+			map[string]string{
+				"alertname": "HighLatency",
+				"severity":  "page",
+				"instance":  "web-2",
+				"receiver":  "telegraf",
+				"status":    "resolved",
+			},
+			map[string]interface{}{
+				"firing":        0,
+				"fingerprint":   "1c8f2b3d4e5f6071",
+				"generator_url": "http://prometheus:9090/graph",
+				"starts_at":     int64(1577934000),
+				"ends_at":       int64(1577934600),
+				"summary":       "High request latency",
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestBadRequest(t *testing.T) {
+	var acc testutil.Accumulator
+	am := &AlertmanagerWebhook{Path: "/alertmanager", acc: &acc}
+	require.NoError(t, am.Init())
+
+	resp := postWebhooks(am, `{"alerts": `, nil)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestBearerToken(t *testing.T) {
+	var acc testutil.Accumulator
+	am := &AlertmanagerWebhook{Path: "/alertmanager", acc: &acc}
+	am.Secret = "secret"
+	require.NoError(t, am.Init())
+
+	resp := postWebhooks(am, notificationJSON, http.Header{"Authorization": {"Bearer wrong"}})
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+	require.Equal(t, 0, len(acc.Metrics))
+
+	resp = postWebhooks(am, notificationJSON, http.Header{"Authorization": {"Bearer secret"}})
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, 2, len(acc.Metrics))
+}
@@ -0,0 +1,91 @@
+// Package auth verifies that the webhook requests are sent by the
+// configured senders, with a signature of the body or a shared secret.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// Verifier checks the signature header of the requests.  With the hmac-sha1,
+// hmac-sha256 and hmac-sha512 methods the header holds the HMAC of the body
+// keyed with the secret, hex encoded and optionally prefixed with the name of
+// the hash such as "sha256=".  With the token method the header holds the
+// secret, and with the bearer method the secret as a bearer token.  The
+// requests are not verified when the secret is empty.
+type Verifier struct {
+	Secret          string `toml:"secret"`
+	SignatureHeader string `toml:"signature_header"`
+	SignatureMethod string `toml:"signature_method"`
+}
+
+var hashes = map[string]func() hash.Hash{
+	"hmac-sha1":   sha1.New,
+	"hmac-sha256": sha256.New,
+	"hmac-sha512": sha512.New,
+}
+
+// Init sets the defaults of the webhook for the header and the method, and
+// checks the method.
+func (v *Verifier) Init(header, method string) error {
+	if v.SignatureHeader == "" {
+		v.SignatureHeader = header
+	}
+	if v.SignatureMethod == "" {
+		v.SignatureMethod = method
+	}
+
+	switch v.SignatureMethod {
+	case "hmac-sha1", "hmac-sha256", "hmac-sha512", "token", "bearer":
+	default:
+		return fmt.Errorf("unknown signature method %q", v.SignatureMethod)
+	}
+	if v.Secret != "" && v.SignatureHeader == "" {
+		return fmt.Errorf("signature_header must be set with secret")
+	}
+	return nil
+}
+
+// Verify returns whether the request with the body is authentic.
+func (v *Verifier) Verify(r *http.Request, body []byte) bool {
+	if v.Secret == "" {
+		return true
+	}
+
+	value := r.Header.Get(v.SignatureHeader)
+	switch v.SignatureMethod {
+	case "token":
+		return subtle.ConstantTimeCompare([]byte(value), []byte(v.Secret)) == 1
+	case "bearer":
+		return subtle.ConstantTimeCompare([]byte(value), []byte("Bearer "+v.Secret)) == 1
+	}
+
+	newHash, ok := hashes[v.SignatureMethod]
+	if !ok {
+		return false
+	}
+	// Strip the name of the hash, such as sha256=
+	if i := strings.IndexByte(value, '='); i >= 0 {
+		value = value[i+1:]
+	}
+	signature, err := hex.DecodeString(value)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(signature, sign(newHash, v.Secret, body))
+}
+
+// sign returns the HMAC of the body keyed with the secret.
+func sign(newHash func() hash.Hash, secret string, body []byte) []byte {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
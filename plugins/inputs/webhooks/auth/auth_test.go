@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"value": 42}`)
+	sha256Signature := hex.EncodeToString(sign(sha256.New, "secret", body))
+	sha1Signature := hex.EncodeToString(sign(sha1.New, "secret", body))
+
+	tests := []struct {
+		name     string
+		verifier Verifier
+		header   string
+		value    string
+		valid    bool
+	}{
+		{
+			name:     "no secret",
+			verifier: Verifier{},
+			valid:    true,
+		},
+		{
+			name:     "hmac-sha256",
+			verifier: Verifier{Secret: "secret", SignatureHeader: "X-Signature", SignatureMethod: "hmac-sha256"},
+			header:   "X-Signature",
+			value:    sha256Signature,
+			valid:    true,
+		},
+		{
+			name:     "hmac-sha256 with prefix",
+			verifier: Verifier{Secret: "secret", SignatureHeader: "X-Hub-Signature-256", SignatureMethod: "hmac-sha256"},
+			header:   "X-Hub-Signature-256",
+			value:    "sha256=" + sha256Signature,
+			valid:    true,
+		},
+		{
+			name:     "hmac-sha1",
+			verifier: Verifier{Secret: "secret", SignatureHeader: "X-Signature", SignatureMethod: "hmac-sha1"},
+			header:   "X-Signature",
+			value:    "sha1=" + sha1Signature,
+			valid:    true,
+		},
+		{
+			name:     "wrong hmac",
+			verifier: Verifier{Secret: "other", SignatureHeader: "X-Signature", SignatureMethod: "hmac-sha256"},
+			header:   "X-Signature",
+			value:    sha256Signature,
+			valid:    false,
+		},
+		{
+			name:     "invalid hmac",
+			verifier: Verifier{Secret: "secret", SignatureHeader: "X-Signature", SignatureMethod: "hmac-sha256"},
+			header:   "X-Signature",
+			value:    "not hex",
+			valid:    false,
+		},
+		{
+			name:     "missing signature",
+			verifier: Verifier{Secret: "secret", SignatureHeader: "X-Signature", SignatureMethod: "hmac-sha256"},
+			valid:    false,
+		},
+		{
+			name:     "token",
+			verifier: Verifier{Secret: "secret", SignatureHeader: "X-Gitlab-Token", SignatureMethod: "token"},
+			header:   "X-Gitlab-Token",
+			value:    "secret",
+			valid:    true,
+		},
+		{
+			name:     "wrong token",
+			verifier: Verifier{Secret: "secret", SignatureHeader: "X-Gitlab-Token", SignatureMethod: "token"},
+			header:   "X-Gitlab-Token",
+			value:    "secrets",
+			valid:    false,
+		},
+		{
+			name:     "bearer",
+			verifier: Verifier{Secret: "secret", SignatureHeader: "Authorization", SignatureMethod: "bearer"},
+			header:   "Authorization",
+			value:    "Bearer secret",
+			valid:    true,
+		},
+		{
+			name:     "bearer without scheme",
+			verifier: Verifier{Secret: "secret", SignatureHeader: "Authorization", SignatureMethod: "bearer"},
+			header:   "Authorization",
+			value:    "secret",
+			valid:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequest("POST", "/", nil)
+			require.NoError(t, err)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			require.Equal(t, tt.valid, tt.verifier.Verify(r, body))
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	v := Verifier{Secret: "secret"}
+	require.NoError(t, v.Init("X-Gitlab-Token", "token"))
+	require.Equal(t, "X-Gitlab-Token", v.SignatureHeader)
+	require.Equal(t, "token", v.SignatureMethod)
+
+	v = Verifier{Secret: "secret", SignatureHeader: "X-Signature", SignatureMethod: "hmac-sha256"}
+	require.NoError(t, v.Init("X-Gitlab-Token", "token"))
+	require.Equal(t, "X-Signature", v.SignatureHeader)
+	require.Equal(t, "hmac-sha256", v.SignatureMethod)
+
+	v = Verifier{SignatureMethod: "md5"}
+	require.Error(t, v.Init("", "hmac-sha256"))
+
+	v = Verifier{Secret: "secret"}
+	require.Error(t, v.Init("", "hmac-sha256"))
+}
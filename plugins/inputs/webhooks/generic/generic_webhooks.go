@@ -0,0 +1,74 @@
+package generic
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs/webhooks/auth"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// GenericWebhook parses the payloads posted to its path with the configured
+// data format.
+type GenericWebhook struct {
+	Path string
+	auth.Verifier
+	parsers.Config
+
+	parser parsers.Parser
+	acc    telegraf.Accumulator
+}
+
+func (gw *GenericWebhook) Init() error {
+	if err := gw.Verifier.Init("X-Signature", "hmac-sha256"); err != nil {
+		return err
+	}
+
+	if gw.DataFormat == "" {
+		gw.DataFormat = "json"
+	}
+	if gw.MetricName == "" {
+		gw.MetricName = "generic_webhooks"
+	}
+	parser, err := parsers.NewParser(&gw.Config)
+	if err != nil {
+		return err
+	}
+	gw.parser = parser
+	return nil
+}
+
+func (gw *GenericWebhook) Register(router *mux.Router, acc telegraf.Accumulator) {
+	router.HandleFunc(gw.Path, gw.eventHandler).Methods("POST")
+	log.Printf("I! Started the webhooks_generic on %s\n", gw.Path)
+	gw.acc = acc
+}
+
+func (gw *GenericWebhook) eventHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !gw.Verify(r, data) {
+		log.Printf("E! Fail to check the generic webhook signature on %s\n", gw.Path)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	metrics, err := gw.parser.Parse(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, m := range metrics {
+		gw.acc.AddMetric(m)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,102 @@
+package generic
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func postWebhooks(gw *GenericWebhook, body string, header http.Header) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	w.Code = 500
+
+	gw.eventHandler(w, req)
+
+	return w
+}
+
+func TestJSON(t *testing.T) {
+	var acc testutil.Accumulator
+	gw := &GenericWebhook{Path: "/generic", acc: &acc}
+	gw.TagKeys = []string{"host"}
+	require.NoError(t, gw.Init())
+
+	resp := postWebhooks(gw, `{"host": "a", "value": 42, "ok": "yes"}`, nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"generic_webhooks",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestInflux(t *testing.T) {
+	var acc testutil.Accumulator
+	gw := &GenericWebhook{Path: "/generic", acc: &acc}
+	gw.DataFormat = "influx"
+	require.NoError(t, gw.Init())
+
+	resp := postWebhooks(gw, "cpu,host=a usage=1.5 1500000000000000000\n", nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 1.5},
+			time.Unix(1500000000, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestParseError(t *testing.T) {
+	var acc testutil.Accumulator
+	gw := &GenericWebhook{Path: "/generic", acc: &acc}
+	require.NoError(t, gw.Init())
+
+	resp := postWebhooks(gw, `{"value": `, nil)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	require.Equal(t, 0, len(acc.Metrics))
+}
+
+func TestSignature(t *testing.T) {
+	body := `{"value": 42}`
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	var acc testutil.Accumulator
+	gw := &GenericWebhook{Path: "/generic", acc: &acc}
+	gw.Secret = "secret"
+	gw.SignatureHeader = "X-Hub-Signature-256"
+	require.NoError(t, gw.Init())
+
+	resp := postWebhooks(gw, body, http.Header{"X-Hub-Signature-256": {"sha256=00"}})
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+	resp = postWebhooks(gw, body, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+	require.Equal(t, 0, len(acc.Metrics))
+
+	resp = postWebhooks(gw, body, http.Header{"X-Hub-Signature-256": {signature}})
+	require.Equal(t, http.StatusOK, resp.Code)
+	acc.AssertContainsFields(t, "generic_webhooks", map[string]interface{}{"value": 42.0})
+}
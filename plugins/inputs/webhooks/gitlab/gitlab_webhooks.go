@@ -0,0 +1,89 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs/webhooks/auth"
+)
+
+type GitlabWebhook struct {
+	Path string
+	auth.Verifier
+
+	acc telegraf.Accumulator
+}
+
+func (gl *GitlabWebhook) Init() error {
+	return gl.Verifier.Init("X-Gitlab-Token", "token")
+}
+
+func (gl *GitlabWebhook) Register(router *mux.Router, acc telegraf.Accumulator) {
+	router.HandleFunc(gl.Path, gl.eventHandler).Methods("POST")
+	log.Printf("I! Started the webhooks_gitlab on %s\n", gl.Path)
+	gl.acc = acc
+}
+
+func (gl *GitlabWebhook) eventHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !gl.Verify(r, data) {
+		log.Printf("E! Fail to check the gitlab webhook token\n")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	e, err := NewEvent(data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if e != nil {
+		gl.acc.AddFields("gitlab_webhooks", e.Fields(), e.Tags())
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// NewEvent decodes an event from its object kind, the unsupported events
+// are ignored.
+func NewEvent(data []byte) (Event, error) {
+	var kind struct {
+		ObjectKind string `json:"object_kind"`
+	}
+	if err := json.Unmarshal(data, &kind); err != nil {
+		return nil, err
+	}
+	log.Printf("D! New gitlab %v event received", kind.ObjectKind)
+
+	var e Event
+	switch kind.ObjectKind {
+	case "push", "tag_push":
+		e = &PushEvent{}
+	case "issue":
+		e = &IssueEvent{}
+	case "merge_request":
+		e = &MergeRequestEvent{}
+	case "pipeline":
+		e = &PipelineEvent{}
+	case "build":
+		e = &JobEvent{}
+	case "note":
+		e = &NoteEvent{}
+	default:
+		return nil, nil
+	}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
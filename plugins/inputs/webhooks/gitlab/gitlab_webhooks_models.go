@@ -0,0 +1,227 @@
+package gitlab
+
+// Event is a GitLab event converted to the tags and fields of a metric, see
+// https://docs.gitlab.com/ee/user/project/integrations/webhooks.html
+type Event interface {
+	Tags() map[string]string
+	Fields() map[string]interface{}
+}
+
+type Project struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+type User struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+type PushEvent struct {
+	ObjectKind        string  `json:"object_kind"`
+	Ref               string  `json:"ref"`
+	Before            string  `json:"before"`
+	After             string  `json:"after"`
+	UserUsername      string  `json:"user_username"`
+	TotalCommitsCount int     `json:"total_commits_count"`
+	Project           Project `json:"project"`
+}
+
+// Tags of the push and tag push events
+func (e PushEvent) Tags() map[string]string {
+	return map[string]string{
+		"event":   e.ObjectKind,
+		"project": e.Project.PathWithNamespace,
+		"ref":     e.Ref,
+	}
+}
+
+func (e PushEvent) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"user":          e.UserUsername,
+		"before":        e.Before,
+		"after":         e.After,
+		"total_commits": e.TotalCommitsCount,
+	}
+}
+
+type IssueEvent struct {
+	User             User    `json:"user"`
+	Project          Project `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		Action string `json:"action"`
+		URL    string `json:"url"`
+	} `json:"object_attributes"`
+}
+
+func (e IssueEvent) Tags() map[string]string {
+	return map[string]string{
+		"event":   "issue",
+		"project": e.Project.PathWithNamespace,
+		"action":  e.ObjectAttributes.Action,
+		"state":   e.ObjectAttributes.State,
+	}
+}
+
+func (e IssueEvent) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"user":  e.User.Username,
+		"iid":   e.ObjectAttributes.IID,
+		"title": e.ObjectAttributes.Title,
+		"url":   e.ObjectAttributes.URL,
+	}
+}
+
+type MergeRequestEvent struct {
+	User             User    `json:"user"`
+	Project          Project `json:"project"`
+	ObjectAttributes struct {
+		IID          int    `json:"iid"`
+		Title        string `json:"title"`
+		State        string `json:"state"`
+		Action       string `json:"action"`
+		MergeStatus  string `json:"merge_status"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		URL          string `json:"url"`
+	} `json:"object_attributes"`
+}
+
+func (e MergeRequestEvent) Tags() map[string]string {
+	return map[string]string{
+		"event":         "merge_request",
+		"project":       e.Project.PathWithNamespace,
+		"action":        e.ObjectAttributes.Action,
+		"state":         e.ObjectAttributes.State,
+		"target_branch": e.ObjectAttributes.TargetBranch,
+	}
+}
+
+func (e MergeRequestEvent) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"user":          e.User.Username,
+		"iid":           e.ObjectAttributes.IID,
+		"title":         e.ObjectAttributes.Title,
+		"source_branch": e.ObjectAttributes.SourceBranch,
+		"merge_status":  e.ObjectAttributes.MergeStatus,
+		"url":           e.ObjectAttributes.URL,
+	}
+}
+
+type PipelineEvent struct {
+	User             User    `json:"user"`
+	Project          Project `json:"project"`
+	ObjectAttributes struct {
+		ID       int      `json:"id"`
+		Ref      string   `json:"ref"`
+		Tag      bool     `json:"tag"`
+		SHA      string   `json:"sha"`
+		Status   string   `json:"status"`
+		Source   string   `json:"source"`
+		Duration *float64 `json:"duration"`
+	} `json:"object_attributes"`
+	Builds []struct {
+		Status string `json:"status"`
+	} `json:"builds"`
+}
+
+func (e PipelineEvent) Tags() map[string]string {
+	return map[string]string{
+		"event":   "pipeline",
+		"project": e.Project.PathWithNamespace,
+		"ref":     e.ObjectAttributes.Ref,
+		"status":  e.ObjectAttributes.Status,
+		"source":  e.ObjectAttributes.Source,
+	}
+}
+
+func (e PipelineEvent) Fields() map[string]interface{} {
+	f := map[string]interface{}{
+		"user": e.User.Username,
+		"id":   e.ObjectAttributes.ID,
+		"sha":  e.ObjectAttributes.SHA,
+		"tag":  e.ObjectAttributes.Tag,
+		"jobs": len(e.Builds),
+	}
+	// The duration is only known once the pipeline is finished
+	if e.ObjectAttributes.Duration != nil {
+		f["duration"] = *e.ObjectAttributes.Duration
+	}
+	return f
+}
+
+type JobEvent struct {
+	Ref           string   `json:"ref"`
+	Tag           bool     `json:"tag"`
+	SHA           string   `json:"sha"`
+	BuildID       int      `json:"build_id"`
+	BuildName     string   `json:"build_name"`
+	BuildStage    string   `json:"build_stage"`
+	BuildStatus   string   `json:"build_status"`
+	BuildDuration *float64 `json:"build_duration"`
+	PipelineID    int      `json:"pipeline_id"`
+	ProjectName   string   `json:"project_name"`
+	User          User     `json:"user"`
+	Project       *Project `json:"project"`
+}
+
+func (e JobEvent) Tags() map[string]string {
+	// Older GitLab versions only send the name of the project
+	project := e.ProjectName
+	if e.Project != nil && e.Project.PathWithNamespace != "" {
+		project = e.Project.PathWithNamespace
+	}
+	return map[string]string{
+		"event":   "job",
+		"project": project,
+		"ref":     e.Ref,
+		"stage":   e.BuildStage,
+		"name":    e.BuildName,
+		"status":  e.BuildStatus,
+	}
+}
+
+func (e JobEvent) Fields() map[string]interface{} {
+	f := map[string]interface{}{
+		"user":        e.User.Username,
+		"id":          e.BuildID,
+		"pipeline_id": e.PipelineID,
+		"sha":         e.SHA,
+		"tag":         e.Tag,
+	}
+	if e.BuildDuration != nil {
+		f["duration"] = *e.BuildDuration
+	}
+	return f
+}
+
+type NoteEvent struct {
+	User             User    `json:"user"`
+	Project          Project `json:"project"`
+	ObjectAttributes struct {
+		Note         string `json:"note"`
+		NoteableType string `json:"noteable_type"`
+		URL          string `json:"url"`
+	} `json:"object_attributes"`
+}
+
+func (e NoteEvent) Tags() map[string]string {
+	return map[string]string{
+		"event":         "note",
+		"project":       e.Project.PathWithNamespace,
+		"noteable_type": e.ObjectAttributes.NoteableType,
+	}
+}
+
+func (e NoteEvent) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"user": e.User.Username,
+		"note": e.ObjectAttributes.Note,
+		"url":  e.ObjectAttributes.URL,
+	}
+}
@@ -0,0 +1,215 @@
+package gitlab
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func postWebhooks(gl *GitlabWebhook, body string, header http.Header) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	w.Code = 500
+
+	gl.eventHandler(w, req)
+
+	return w
+}
+
+func TestEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected telegraf.Metric
+	}{
+		{
+			name: "push",
+			body: `{
+  "object_kind": "push",
+  "before": "95790bf891e76fee5e1747ab589903a6a1f80f22",
+  "after": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+  "ref": "refs/heads/master",
+  "user_username": "jsmith",
+  "project": {"id": 15, "name": "Diaspora", "path_with_namespace": "mike/diaspora"},
+  "commits": [{"id": "b6568db1bc1dcd7f8b4d5a946b0b91f9dacd7327"}],
+  "total_commits_count": 4
+}`,
+			expected: testutil.MustMetric(
+				"gitlab_webhooks",
+				map[string]string{
+					"event":   "push",
+					"project": "mike/diaspora",
+					"ref":     "refs/heads/master",
+				},
+				map[string]interface{}{
+					"user":          "jsmith",
+					"before":        "95790bf891e76fee5e1747ab589903a6a1f80f22",
+					"after":         "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+					"total_commits": 4,
+				},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "merge request",
+			body: `{
+  "object_kind": "merge_request",
+  "user": {"name": "Administrator", "username": "root"},
+  "project": {"id": 1, "name": "Gitlab Test", "path_with_namespace": "gitlabhq/gitlab-test"},
+  "object_attributes": {
+    "iid": 1,
+    "title": "MS-Viewport",
+    "state": "opened",
+    "action": "open",
+    "merge_status": "unchecked",
+    "source_branch": "ms-viewport",
+    "target_branch": "master",
+    "url": "http://example.com/diaspora/merge_requests/1"
+  }
+}`,
+			expected: testutil.MustMetric(
+				"gitlab_webhooks",
+				map[string]string{
+					"event":         "merge_request",
+					"project":       "gitlabhq/gitlab-test",
+					"action":        "open",
+					"state":         "opened",
+					"target_branch": "master",
+				},
+				map[string]interface{}{
+					"user":          "root",
+					"iid":           1,
+					"title":         "MS-Viewport",
+					"source_branch": "ms-viewport",
+					"merge_status":  "unchecked",
+					"url":           "http://example.com/diaspora/merge_requests/1",
+				},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "pipeline",
+			body: `{
+  "object_kind": "pipeline",
+  "object_attributes": {
+    "id": 31,
+    "ref": "master",
+    "tag": false,
+    "sha": "bcbb5ec396a2c0f828686f14fac9b80b780504f2",
+    "status": "success",
+    "source": "push",
+    "duration": 63
+  },
+  "user": {"name": "Administrator", "username": "root"},
+  "project": {"id": 1, "name": "Gitlab Test", "path_with_namespace": "gitlab-org/gitlab-test"},
+  "builds": [{"id": 380, "status": "success"}, {"id": 377, "status": "success"}]
+}`,
+			expected: testutil.MustMetric(
+				"gitlab_webhooks",
+				map[string]string{
+					"event":   "pipeline",
+					"project": "gitlab-org/gitlab-test",
+					"ref":     "master",
+					"status":  "success",
+					"source":  "push",
+				},
+				map[string]interface{}{
+					"user":     "root",
+					"id":       31,
+					"sha":      "bcbb5ec396a2c0f828686f14fac9b80b780504f2",
+					"tag":      false,
+					"jobs":     2,
+					"duration": 63.0,
+				},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "job",
+			body: `{
+  "object_kind": "build",
+  "ref": "gitlab-script-trigger",
+  "tag": false,
+  "sha": "2293ada6b400935a1378653304eaf6221e0fdb8f",
+  "build_id": 1977,
+  "build_name": "test",
+  "build_stage": "test",
+  "build_status": "created",
+  "build_duration": null,
+  "pipeline_id": 2366,
+  "project_name": "gitlab-org/gitlab-test",
+  "user": {"name": "User", "username": "user"}
+}`,
+			expected: testutil.MustMetric(
+				"gitlab_webhooks",
+				map[string]string{
+					"event":   "job",
+					"project": "gitlab-org/gitlab-test",
+					"ref":     "gitlab-script-trigger",
+					"stage":   "test",
+					"name":    "test",
+					"status":  "created",
+				},
+				map[string]interface{}{
+					"user":        "user",
+					"id":          1977,
+					"pipeline_id": 2366,
+					"sha":         "2293ada6b400935a1378653304eaf6221e0fdb8f",
+					"tag":         false,
+				},
+				time.Unix(0, 0),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var acc testutil.Accumulator
+			gl := &GitlabWebhook{Path: "/gitlab", acc: &acc}
+			require.NoError(t, gl.Init())
+
+			resp := postWebhooks(gl, tt.body, nil)
+			require.Equal(t, http.StatusOK, resp.Code)
+			testutil.RequireMetricsEqual(t,
+				[]telegraf.Metric{tt.expected}, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestUnknownEvent(t *testing.T) {
+	var acc testutil.Accumulator
+	gl := &GitlabWebhook{Path: "/gitlab", acc: &acc}
+	require.NoError(t, gl.Init())
+
+	resp := postWebhooks(gl, `{"object_kind": "wiki_page"}`, nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, 0, len(acc.Metrics))
+
+	resp = postWebhooks(gl, `{"object_kind": `, nil)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestToken(t *testing.T) {
+	var acc testutil.Accumulator
+	gl := &GitlabWebhook{Path: "/gitlab", acc: &acc}
+	gl.Secret = "secret"
+	require.NoError(t, gl.Init())
+
+	body := `{"object_kind": "push", "ref": "refs/heads/master"}`
+	resp := postWebhooks(gl, body, http.Header{"X-Gitlab-Token": {"wrong"}})
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+	require.Equal(t, 0, len(acc.Metrics))
+
+	resp = postWebhooks(gl, body, http.Header{"X-Gitlab-Token": {"secret"}})
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, 1, len(acc.Metrics))
+}
@@ -11,8 +11,11 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/inputs"
 
+	"github.com/influxdata/telegraf/plugins/inputs/webhooks/alertmanager"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/filestack"
+	"github.com/influxdata/telegraf/plugins/inputs/webhooks/generic"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/github"
+	"github.com/influxdata/telegraf/plugins/inputs/webhooks/gitlab"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/mandrill"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/papertrail"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/particle"
@@ -37,6 +40,10 @@ type Webhooks struct {
 	Papertrail *papertrail.PapertrailWebhook
 	Particle   *particle.ParticleWebhook
 
+	Alertmanager *alertmanager.AlertmanagerWebhook
+	Gitlab       *gitlab.GitlabWebhook
+	Generic      []*generic.GenericWebhook
+
 	srv *http.Server
 }
 
@@ -67,6 +74,43 @@ func (wb *Webhooks) SampleConfig() string {
 
   [inputs.webhooks.particle]
     path = "/particle"
+
+  [inputs.webhooks.alertmanager]
+    path = "/alertmanager"
+    ## Bearer token configured in the http_config of the Alertmanager
+    ## receiver.
+    # secret = ""
+
+  [inputs.webhooks.gitlab]
+    path = "/gitlab"
+    ## Secret token configured on the GitLab webhook, sent in the
+    ## X-Gitlab-Token header.
+    # secret = ""
+
+  ## Generic handlers parse the payloads posted to their path with the
+  ## configured data format, this section can be repeated.
+  [[inputs.webhooks.generic]]
+    path = "/generic"
+
+    ## Shared secret used to verify the requests, the signature is read from
+    ## the signature_header and verified according to the signature_method:
+    ##   hmac-sha1, hmac-sha256, hmac-sha512 - hex encoded HMAC of the body,
+    ##     optionally prefixed with the name of the method as in "sha256=..."
+    ##   token  - the header contains the secret
+    ##   bearer - the header contains "Bearer <secret>"
+    # secret = ""
+    # signature_header = "X-Signature"
+    # signature_method = "hmac-sha256"
+
+    ## Data format to consume.
+    ## Each data format has its own unique set of configuration options, read
+    ## more about them here:
+    ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+    data_format = "json"
+
+    ## Name of the measurement, used by the data formats that do not provide
+    ## one.
+    # metric_name = "generic_webhooks"
 `
 }
 
@@ -78,7 +122,19 @@ func (wb *Webhooks) Gather(_ telegraf.Accumulator) error {
 	return nil
 }
 
-// Looks for fields which implement Webhook interface
+func (wb *Webhooks) Init() error {
+	for _, webhook := range wb.AvailableWebhooks() {
+		if initializer, ok := webhook.(telegraf.Initializer); ok {
+			if err := initializer.Init(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Looks for fields, or elements of slice fields, which implement Webhook
+// interface
 func (wb *Webhooks) AvailableWebhooks() []Webhook {
 	webhooks := make([]Webhook, 0)
 	s := reflect.ValueOf(wb).Elem()
@@ -89,6 +145,17 @@ func (wb *Webhooks) AvailableWebhooks() []Webhook {
 			continue
 		}
 
+		if f.Kind() == reflect.Slice {
+			for j := 0; j < f.Len(); j++ {
+				if wbPlugin, ok := f.Index(j).Interface().(Webhook); ok {
+					if !reflect.ValueOf(wbPlugin).IsNil() {
+						webhooks = append(webhooks, wbPlugin)
+					}
+				}
+			}
+			continue
+		}
+
 		if wbPlugin, ok := f.Interface().(Webhook); ok {
 			if !reflect.ValueOf(wbPlugin).IsNil() {
 				webhooks = append(webhooks, wbPlugin)
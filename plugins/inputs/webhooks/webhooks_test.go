@@ -4,7 +4,10 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/influxdata/telegraf/plugins/inputs/webhooks/alertmanager"
+	"github.com/influxdata/telegraf/plugins/inputs/webhooks/generic"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/github"
+	"github.com/influxdata/telegraf/plugins/inputs/webhooks/gitlab"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/papertrail"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/particle"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/rollbar"
@@ -40,4 +43,39 @@ func TestAvailableWebhooks(t *testing.T) {
 	if !reflect.DeepEqual(wb.AvailableWebhooks(), expected) {
 		t.Errorf("expected to be %v.\nGot %v", expected, wb.AvailableWebhooks())
 	}
+
+	wb.Alertmanager = &alertmanager.AlertmanagerWebhook{Path: "/alertmanager"}
+	expected = append(expected, wb.Alertmanager)
+	if !reflect.DeepEqual(wb.AvailableWebhooks(), expected) {
+		t.Errorf("expected to be %v.\nGot %v", expected, wb.AvailableWebhooks())
+	}
+
+	wb.Gitlab = &gitlab.GitlabWebhook{Path: "/gitlab"}
+	expected = append(expected, wb.Gitlab)
+	if !reflect.DeepEqual(wb.AvailableWebhooks(), expected) {
+		t.Errorf("expected to be %v.\nGot %v", expected, wb.AvailableWebhooks())
+	}
+
+	wb.Generic = []*generic.GenericWebhook{{Path: "/a"}, {Path: "/b"}}
+	expected = append(expected, wb.Generic[0], wb.Generic[1])
+	if !reflect.DeepEqual(wb.AvailableWebhooks(), expected) {
+		t.Errorf("expected to be %v.\nGot %v", expected, wb.AvailableWebhooks())
+	}
+}
+
+func TestInit(t *testing.T) {
+	wb := NewWebhooks()
+	wb.Generic = []*generic.GenericWebhook{{Path: "/generic"}}
+	if err := wb.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if wb.Generic[0].DataFormat != "json" {
+		t.Errorf("expected data format json.\nGot %v", wb.Generic[0].DataFormat)
+	}
+
+	wb.Gitlab = &gitlab.GitlabWebhook{Path: "/gitlab"}
+	wb.Gitlab.SignatureMethod = "md5"
+	if err := wb.Init(); err == nil {
+		t.Errorf("expected an error for an unknown signature method")
+	}
 }
@@ -2,6 +2,7 @@
 package x509_cert
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/tls"
 	"crypto/x509"
@@ -9,24 +10,35 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/smtp"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/globpath"
 	_tls "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
 const sampleConfig = `
   ## List certificate sources
+  ##   Local files accept glob patterns, see the globpath documentation:
+  ##     https://github.com/influxdata/telegraf/tree/master/internal/globpath
+  ##   The smtp:// and imap:// schemes negotiate TLS with STARTTLS.
   sources = ["/etc/ssl/certs/ssl-cert-snakeoil.pem", "tcp://example.org:443"]
 
   ## Timeout for SSL connection
   # timeout = "5s"
 
-  ## Optional TLS Config
+  ## Server name to send with SNI and to verify the certificate against,
+  ## defaults to the host of the source.
+  # server_name = ""
+
+  ## Optional TLS Config, the certificates are verified against tls_ca or the
+  ## system roots when unset.
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
   # tls_key = "/etc/telegraf/key.pem"
@@ -35,12 +47,20 @@ const description = "Reads metrics from a SSL certificate"
 
 // X509Cert holds the configuration of the plugin.
 type X509Cert struct {
-	Sources []string          `toml:"sources"`
-	Timeout internal.Duration `toml:"timeout"`
-	tlsCfg  *tls.Config
+	Sources    []string          `toml:"sources"`
+	Timeout    internal.Duration `toml:"timeout"`
+	ServerName string            `toml:"server_name"`
+	tlsCfg     *tls.Config
 	_tls.ClientConfig
 }
 
+// source is a certificate location, with the files of glob patterns
+// expanded.
+type source struct {
+	name string
+	url  *url.URL
+}
+
 // Description returns description of the plugin.
 func (c *X509Cert) Description() string {
 	return description
@@ -64,6 +84,42 @@ func (c *X509Cert) locationToURL(location string) (*url.URL, error) {
 	return u, nil
 }
 
+// expandLocation returns the sources of a location, a local glob pattern
+// yields a source per matching file.
+func (c *X509Cert) expandLocation(location string) ([]source, error) {
+	u, err := c.locationToURL(location)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "file" || !strings.ContainsAny(u.Path, "*?[") {
+		return []source{{name: location, url: u}}, nil
+	}
+
+	g, err := globpath.Compile(u.Path)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile glob %q: %v", u.Path, err)
+	}
+
+	var sources []source
+	for _, path := range g.Match() {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		sources = append(sources, source{name: path, url: &url.URL{Scheme: "file", Path: path}})
+	}
+	return sources, nil
+}
+
+// serverName returns the name used for SNI and for the verification of the
+// leaf certificate, the leaf of a file has no server name.
+func (c *X509Cert) serverName(u *url.URL) string {
+	if c.ServerName != "" && u.Scheme != "file" {
+		return c.ServerName
+	}
+	return u.Hostname()
+}
+
 func (c *X509Cert) getCert(u *url.URL, timeout time.Duration) ([]*x509.Certificate, error) {
 	switch u.Scheme {
 	case "https":
@@ -78,9 +134,7 @@ func (c *X509Cert) getCert(u *url.URL, timeout time.Duration) ([]*x509.Certifica
 		}
 		defer ipConn.Close()
 
-		c.tlsCfg.ServerName = u.Hostname()
-		c.tlsCfg.InsecureSkipVerify = true
-		conn := tls.Client(ipConn, c.tlsCfg)
+		conn := tls.Client(ipConn, c.clientTLSConfig(u))
 		defer conn.Close()
 
 		hsErr := conn.Handshake()
@@ -91,6 +145,46 @@ func (c *X509Cert) getCert(u *url.URL, timeout time.Duration) ([]*x509.Certifica
 		certs := conn.ConnectionState().PeerCertificates
 
 		return certs, nil
+	case "smtp":
+		ipConn, err := net.DialTimeout("tcp", hostWithPort(u, "25"), timeout)
+		if err != nil {
+			return nil, err
+		}
+		defer ipConn.Close()
+		ipConn.SetDeadline(time.Now().Add(timeout))
+
+		client, err := smtp.NewClient(ipConn, u.Hostname())
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+
+		if err := client.StartTLS(c.clientTLSConfig(u)); err != nil {
+			return nil, err
+		}
+		state, _ := client.TLSConnectionState()
+
+		return state.PeerCertificates, nil
+	case "imap":
+		ipConn, err := net.DialTimeout("tcp", hostWithPort(u, "143"), timeout)
+		if err != nil {
+			return nil, err
+		}
+		defer ipConn.Close()
+		ipConn.SetDeadline(time.Now().Add(timeout))
+
+		if err := startTLSIMAP(ipConn); err != nil {
+			return nil, err
+		}
+
+		conn := tls.Client(ipConn, c.clientTLSConfig(u))
+		defer conn.Close()
+
+		if err := conn.Handshake(); err != nil {
+			return nil, err
+		}
+
+		return conn.ConnectionState().PeerCertificates, nil
 	case "file":
 		content, err := ioutil.ReadFile(u.Path)
 		if err != nil {
@@ -119,6 +213,56 @@ func (c *X509Cert) getCert(u *url.URL, timeout time.Duration) ([]*x509.Certifica
 	}
 }
 
+// clientTLSConfig returns the TLS configuration of a connection to the
+// source.  The certificates are verified after the handshake, so that
+// invalid ones are reported as well.
+func (c *X509Cert) clientTLSConfig(u *url.URL) *tls.Config {
+	cfg := c.tlsCfg.Clone()
+	cfg.ServerName = c.serverName(u)
+	cfg.InsecureSkipVerify = true
+	return cfg
+}
+
+// hostWithPort returns the address of the source, with the default port of
+// the protocol when none is given.
+func hostWithPort(u *url.URL, port string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// startTLSIMAP asks an IMAP server to start the TLS negotiation, as described
+// in RFC 3501.
+func startTLSIMAP(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "* OK") {
+		return fmt.Errorf("unexpected IMAP greeting: %q", strings.TrimSpace(line))
+	}
+
+	if _, err := conn.Write([]byte("a001 STARTTLS\r\n")); err != nil {
+		return err
+	}
+	for {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		// Skip the untagged responses
+		if !strings.HasPrefix(line, "a001 ") {
+			continue
+		}
+		if !strings.HasPrefix(line, "a001 OK") {
+			return fmt.Errorf("STARTTLS refused: %q", strings.TrimSpace(line))
+		}
+		return nil
+	}
+}
+
 func getFields(cert *x509.Certificate, now time.Time) map[string]interface{} {
 	age := int(now.Sub(cert.NotBefore).Seconds())
 	expiry := int(cert.NotAfter.Sub(now).Seconds())
@@ -180,53 +324,60 @@ func (c *X509Cert) Gather(acc telegraf.Accumulator) error {
 	now := time.Now()
 
 	for _, location := range c.Sources {
-		u, err := c.locationToURL(location)
+		sources, err := c.expandLocation(location)
 		if err != nil {
 			acc.AddError(err)
 			return nil
 		}
 
-		certs, err := c.getCert(u, c.Timeout.Duration*time.Second)
-		if err != nil {
-			acc.AddError(fmt.Errorf("cannot get SSL cert '%s': %s", location, err.Error()))
+		for _, src := range sources {
+			c.gatherSource(acc, src, now)
 		}
+	}
 
-		for i, cert := range certs {
-			fields := getFields(cert, now)
-			tags := getTags(cert, location)
+	return nil
+}
 
-			// The first certificate is the leaf/end-entity certificate which needs DNS
-			// name validation against the URL hostname.
-			opts := x509.VerifyOptions{
-				Intermediates: x509.NewCertPool(),
-			}
-			if i == 0 {
-				opts.DNSName = u.Hostname()
-				for j, cert := range certs {
-					if j != 0 {
-						opts.Intermediates.AddCert(cert)
-					}
-				}
-			}
-			if c.tlsCfg.RootCAs != nil {
-				opts.Roots = c.tlsCfg.RootCAs
-			}
+func (c *X509Cert) gatherSource(acc telegraf.Accumulator, src source, now time.Time) {
+	u := src.url
+	certs, err := c.getCert(u, c.Timeout.Duration*time.Second)
+	if err != nil {
+		acc.AddError(fmt.Errorf("cannot get SSL cert '%s': %s", src.name, err.Error()))
+	}
+
+	for i, cert := range certs {
+		fields := getFields(cert, now)
+		tags := getTags(cert, src.name)
 
-			_, err = cert.Verify(opts)
-			if err == nil {
-				tags["verification"] = "valid"
-				fields["verification_code"] = 0
-			} else {
-				tags["verification"] = "invalid"
-				fields["verification_code"] = 1
-				fields["verification_error"] = err.Error()
+		// The first certificate is the leaf/end-entity certificate which needs DNS
+		// name validation against the server name.
+		opts := x509.VerifyOptions{
+			Intermediates: x509.NewCertPool(),
+		}
+		if i == 0 {
+			opts.DNSName = c.serverName(u)
+			for j, cert := range certs {
+				if j != 0 {
+					opts.Intermediates.AddCert(cert)
+				}
 			}
+		}
+		if c.tlsCfg.RootCAs != nil {
+			opts.Roots = c.tlsCfg.RootCAs
+		}
 
-			acc.AddFields("x509_cert", fields, tags)
+		_, err = cert.Verify(opts)
+		if err == nil {
+			tags["verification"] = "valid"
+			fields["verification_code"] = 0
+		} else {
+			tags["verification"] = "invalid"
+			fields["verification_code"] = 1
+			fields["verification_error"] = err.Error()
 		}
-	}
 
-	return nil
+		acc.AddFields("x509_cert", fields, tags)
+	}
 }
 
 func (c *X509Cert) Init() error {
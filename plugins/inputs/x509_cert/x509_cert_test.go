@@ -1,12 +1,16 @@
 package x509_cert
 
 import (
+	"bufio"
 	"crypto/tls"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"math/big"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -341,3 +345,224 @@ func TestGatherCert(t *testing.T) {
 
 	assert.True(t, acc.HasMeasurement("x509_cert"))
 }
+
+func TestGatherGlob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "x509_cert")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"server.pem", "ca.pem"} {
+		content := pki.ReadServerCert()
+		if name == "ca.pem" {
+			content = pki.ReadCACert()
+		}
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub.pem"), 0755))
+
+	sc := X509Cert{
+		Sources: []string{filepath.Join(dir, "*.pem")},
+	}
+	require.NoError(t, sc.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, sc.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	sources := map[string]string{}
+	for _, m := range acc.Metrics {
+		sources[m.Tags["source"]] = m.Tags["common_name"]
+	}
+	require.Equal(t, map[string]string{
+		filepath.Join(dir, "server.pem"): "server.localdomain",
+		filepath.Join(dir, "ca.pem"):     "Telegraf Test CA",
+	}, sources)
+}
+
+func TestVerification(t *testing.T) {
+	f, err := ioutil.TempFile("", "x509_cert")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.Write([]byte(pki.ReadServerCert()))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	tests := []struct {
+		name     string
+		ca       string
+		expected string
+		reason   string
+	}{
+		{name: "trusted", ca: pki.CACertPath(), expected: "valid"},
+		{name: "unknown authority", expected: "invalid", reason: "x509: certificate signed by unknown authority"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sc := X509Cert{
+				Sources: []string{f.Name()},
+			}
+			sc.TLSCA = test.ca
+			require.NoError(t, sc.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, sc.Gather(&acc))
+			require.Len(t, acc.Metrics, 1)
+
+			m := acc.Metrics[0]
+			require.Equal(t, test.expected, m.Tags["verification"])
+			if test.reason == "" {
+				require.NotContains(t, m.Fields, "verification_error")
+			} else {
+				require.Contains(t, m.Fields["verification_error"], test.reason)
+			}
+		})
+	}
+}
+
+func TestServerName(t *testing.T) {
+	pair, err := tls.X509KeyPair([]byte(pki.ReadServerCert()), []byte(pki.ReadServerKey()))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		serverName string
+		expected   string
+		valid      bool
+	}{
+		{name: "host of the source", expected: "127.0.0.1", valid: true},
+		{name: "configured", serverName: "localhost", expected: "localhost", valid: true},
+		{name: "mismatch", serverName: "example.org", expected: "example.org"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hello := make(chan string, 1)
+			config := &tls.Config{
+				GetCertificate: func(info *tls.ClientHelloInfo) (*tls.Certificate, error) {
+					hello <- info.ServerName
+					return &pair, nil
+				},
+			}
+
+			ln, err := tls.Listen("tcp", "127.0.0.1:0", config)
+			require.NoError(t, err)
+			defer ln.Close()
+
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+			}()
+
+			sc := X509Cert{
+				Sources:    []string{"tcp://" + ln.Addr().String()},
+				Timeout:    internal.Duration{Duration: 5},
+				ServerName: test.serverName,
+			}
+			sc.TLSCA = pki.CACertPath()
+			require.NoError(t, sc.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, sc.Gather(&acc))
+			require.Empty(t, acc.Errors)
+
+			// SNI is not sent for IP addresses
+			if net.ParseIP(test.expected) == nil {
+				require.Equal(t, test.expected, <-hello)
+			}
+
+			require.Len(t, acc.Metrics, 1)
+			require.Equal(t, test.valid, acc.Metrics[0].Tags["verification"] == "valid")
+		})
+	}
+}
+
+func TestGatherSTARTTLS(t *testing.T) {
+	pair, err := tls.X509KeyPair([]byte(pki.ReadServerCert()), []byte(pki.ReadServerKey()))
+	require.NoError(t, err)
+	config := &tls.Config{Certificates: []tls.Certificate{pair}}
+
+	tests := []struct {
+		name   string
+		scheme string
+		serve  func(conn net.Conn, r *bufio.Reader) bool
+	}{
+		{
+			name:   "smtp",
+			scheme: "smtp",
+			serve: func(conn net.Conn, r *bufio.Reader) bool {
+				fmt.Fprintf(conn, "220 localhost ESMTP\r\n")
+				if line, _ := r.ReadString('\n'); !strings.HasPrefix(line, "EHLO") {
+					return false
+				}
+				fmt.Fprintf(conn, "250-localhost\r\n250 STARTTLS\r\n")
+				if line, _ := r.ReadString('\n'); line != "STARTTLS\r\n" {
+					return false
+				}
+				fmt.Fprintf(conn, "220 Ready to start TLS\r\n")
+				return true
+			},
+		},
+		{
+			name:   "imap",
+			scheme: "imap",
+			serve: func(conn net.Conn, r *bufio.Reader) bool {
+				fmt.Fprintf(conn, "* OK IMAP4rev1 Service Ready\r\n")
+				if line, _ := r.ReadString('\n'); line != "a001 STARTTLS\r\n" {
+					return false
+				}
+				fmt.Fprintf(conn, "a001 OK Begin TLS negotiation now\r\n")
+				return true
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer ln.Close()
+
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				if !test.serve(conn, bufio.NewReader(conn)) {
+					return
+				}
+				srv := tls.Server(conn, config)
+				if srv.Handshake() != nil {
+					return
+				}
+				// The SMTP client greets the server again over TLS
+				if test.scheme == "smtp" {
+					r := bufio.NewReader(srv)
+					if line, _ := r.ReadString('\n'); strings.HasPrefix(line, "EHLO") {
+						fmt.Fprintf(srv, "250 localhost\r\n")
+					}
+				}
+			}()
+
+			sc := X509Cert{
+				Sources: []string{test.scheme + "://" + ln.Addr().String()},
+				Timeout: internal.Duration{Duration: 5},
+			}
+			sc.TLSCA = pki.CACertPath()
+			require.NoError(t, sc.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, sc.Gather(&acc))
+			require.Empty(t, acc.Errors)
+			require.Len(t, acc.Metrics, 1)
+			require.Equal(t, "server.localdomain", acc.Metrics[0].Tags["common_name"])
+			require.Equal(t, "valid", acc.Metrics[0].Tags["verification"])
+		})
+	}
+}
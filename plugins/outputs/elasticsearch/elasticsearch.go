@@ -32,6 +32,9 @@ type Elasticsearch struct {
 	TemplateName        string
 	OverwriteTemplate   bool
 	MajorReleaseNumber  int
+	IndexTag            string
+	OpType              string
+	Pipeline            string
 	tls.ClientConfig
 
 	Client *elastic.Client
@@ -71,6 +74,17 @@ var sampleConfig = `
   # default_tag_value = "none"
   index_name = "telegraf-%Y.%m.%d" # required.
 
+  ## Tag containing the target index of the metric, if the tag does not exist
+  ## the index_name is used.  The tag is not included in the document.
+  # index_tag = ""
+
+  ## The operation type of the bulk requests, one of "index" or "create".
+  ## Writing to data streams requires "create".
+  # op_type = "index"
+
+  ## Ingest pipeline to process the documents with.
+  # pipeline = ""
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -163,11 +177,27 @@ type templatePart struct {
 	Version         int
 }
 
+// maxBulkAttempts is the number of times the documents that failed with a
+// temporary error are sent within a single write.
+const maxBulkAttempts = 3
+
+// retryBackoff is the time waited before sending the documents again, it is
+// multiplied by the number of the attempt.
+var retryBackoff = time.Second
+
 func (a *Elasticsearch) Connect() error {
 	if a.URLs == nil || a.IndexName == "" {
 		return fmt.Errorf("Elasticsearch urls or index_name is not defined")
 	}
 
+	switch a.OpType {
+	case "":
+		a.OpType = "index"
+	case "index", "create":
+	default:
+		return fmt.Errorf("Elasticsearch op_type %q is not supported", a.OpType)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), a.Timeout.Duration)
 	defer cancel()
 
@@ -247,50 +277,130 @@ func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 		return nil
 	}
 
-	bulkRequest := a.Client.Bulk()
-
+	requests := make([]elastic.BulkableRequest, 0, len(metrics))
 	for _, metric := range metrics {
-		var name = metric.Name()
+		requests = append(requests, a.bulkRequest(metric))
+	}
 
-		// index name has to be re-evaluated each time for telegraf
-		// to send the metric to the correct time-based index
-		indexName := a.GetIndexName(a.IndexName, metric.Time(), a.TagKeys, metric.Tags())
+	var failed int
+	for attempt := 1; ; attempt++ {
+		var rejected int
+		var err error
+		requests, rejected, err = a.bulk(requests)
+		if err != nil {
+			return fmt.Errorf("Error sending bulk request to Elasticsearch: %s", err)
+		}
+		failed += rejected
+		if len(requests) == 0 || attempt == maxBulkAttempts {
+			break
+		}
+		time.Sleep(time.Duration(attempt) * retryBackoff)
+	}
 
-		m := make(map[string]interface{})
+	if failed > 0 {
+		log.Printf("E! Elasticsearch rejected %d metrics", failed)
+	}
+	if len(requests) > 0 {
+		// The write can only be retried on the next flush if no document
+		// was indexed, the whole batch is sent again.
+		if failed+len(requests) == len(metrics) {
+			return fmt.Errorf("Elasticsearch failed to index %d metrics", len(requests))
+		}
+		log.Printf("E! Elasticsearch failed to index %d metrics after %d attempts, dropping them",
+			len(requests), maxBulkAttempts)
+	}
 
-		m["@timestamp"] = metric.Time()
-		m["measurement_name"] = name
-		m["tag"] = metric.Tags()
-		m[name] = metric.Fields()
+	return nil
+}
 
-		br := elastic.NewBulkIndexRequest().Index(indexName).Doc(m)
+func (a *Elasticsearch) bulkRequest(metric telegraf.Metric) *elastic.BulkIndexRequest {
+	var name = metric.Name()
+	tags := metric.Tags()
 
-		if a.MajorReleaseNumber <= 6 {
-			br.Type("metrics")
-		}
+	// index name has to be re-evaluated each time for telegraf
+	// to send the metric to the correct time-based index
+	var indexName string
+	if index, ok := tags[a.IndexTag]; ok && a.IndexTag != "" {
+		indexName = index
+		delete(tags, a.IndexTag)
+	} else {
+		indexName = a.GetIndexName(a.IndexName, metric.Time(), a.TagKeys, tags)
+	}
 
-		bulkRequest.Add(br)
+	m := make(map[string]interface{})
 
+	m["@timestamp"] = metric.Time()
+	m["measurement_name"] = name
+	m["tag"] = tags
+	m[name] = metric.Fields()
+
+	br := elastic.NewBulkIndexRequest().Index(indexName).Doc(m)
+
+	if a.OpType != "" && a.OpType != "index" {
+		br.OpType(a.OpType)
+	}
+
+	if a.Pipeline != "" {
+		br.Pipeline(a.Pipeline)
+	}
+
+	if a.MajorReleaseNumber <= 6 {
+		br.Type("metrics")
 	}
 
+	return br
+}
+
+// bulk sends the requests and inspects the result of each document.  It
+// returns the requests that failed with a temporary error and can be
+// retried, and the number of documents that were rejected.
+func (a *Elasticsearch) bulk(requests []elastic.BulkableRequest) ([]elastic.BulkableRequest, int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), a.Timeout.Duration)
 	defer cancel()
 
-	res, err := bulkRequest.Do(ctx)
-
+	res, err := a.Client.Bulk().Add(requests...).Do(ctx)
 	if err != nil {
-		return fmt.Errorf("Error sending bulk request to Elasticsearch: %s", err)
+		return nil, 0, err
 	}
 
-	if res.Errors {
-		for id, err := range res.Failed() {
-			log.Printf("E! Elasticsearch indexing failure, id: %d, error: %s, caused by: %s, %s", id, err.Error.Reason, err.Error.CausedBy["reason"], err.Error.CausedBy["type"])
-		}
-		return fmt.Errorf("W! Elasticsearch failed to index %d metrics", len(res.Failed()))
+	if !res.Errors {
+		return nil, 0, nil
 	}
 
-	return nil
+	if len(res.Items) != len(requests) {
+		return nil, 0, fmt.Errorf("received %d results for %d documents", len(res.Items), len(requests))
+	}
+
+	var retry []elastic.BulkableRequest
+	var rejected int
+	for i, item := range res.Items {
+		for _, result := range item {
+			if result.Error == nil && result.Status < 300 {
+				continue
+			}
+
+			var reason, causedBy string
+			if result.Error != nil {
+				reason = result.Error.Type + ": " + result.Error.Reason
+				if result.Error.CausedBy != nil {
+					causedBy = fmt.Sprintf(", caused by: %v, %v", result.Error.CausedBy["reason"], result.Error.CausedBy["type"])
+				}
+			}
+
+			// Documents rejected because the cluster is overloaded or failing
+			// can be retried, other errors such as mapping conflicts will
+			// fail again.
+			if result.Status == http.StatusTooManyRequests || result.Status >= 500 {
+				log.Printf("D! Elasticsearch indexing failure, status: %d, error: %s%s", result.Status, reason, causedBy)
+				retry = append(retry, requests[i])
+			} else {
+				log.Printf("E! Elasticsearch indexing failure, status: %d, error: %s%s", result.Status, reason, causedBy)
+				rejected++
+			}
+		}
+	}
 
+	return retry, rejected, nil
 }
 
 func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
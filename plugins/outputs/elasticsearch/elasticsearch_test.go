@@ -1,11 +1,19 @@
 package elasticsearch
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
@@ -257,3 +265,211 @@ func TestGetIndexName(t *testing.T) {
 		}
 	}
 }
+
+// bulkAction is a single action of a bulk request received by the mock
+// server.
+type bulkAction map[string]struct {
+	Index    string `json:"_index"`
+	Pipeline string `json:"pipeline"`
+}
+
+// mockBulkServer mimics the bulk API of Elasticsearch, the status of each
+// document is chosen by the status function based on the attempt and the
+// position of the document within the request.
+type mockBulkServer struct {
+	sync.Mutex
+	*httptest.Server
+	status   func(attempt, i int) int
+	attempts int
+	actions  [][]bulkAction
+}
+
+func newMockBulkServer(t *testing.T, status func(attempt, i int) int) *mockBulkServer {
+	s := &mockBulkServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `{"version":{"number":"7.10.0"}}`)
+			return
+		}
+		require.Equal(t, "/_bulk", r.URL.Path)
+
+		s.Lock()
+		defer s.Unlock()
+
+		var actions []bulkAction
+		var items []map[string]interface{}
+		var errors bool
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action bulkAction
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &action))
+			require.True(t, scanner.Scan(), "missing document")
+
+			status := s.status(s.attempts, len(actions))
+			item := map[string]interface{}{"status": status}
+			if status >= 300 {
+				errors = true
+				item["error"] = map[string]interface{}{
+					"type":   http.StatusText(status),
+					"reason": "mock failure",
+				}
+			}
+			for op := range action {
+				items = append(items, map[string]interface{}{op: item})
+			}
+			actions = append(actions, action)
+		}
+		s.attempts++
+		s.actions = append(s.actions, actions)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"took":   1,
+			"errors": errors,
+			"items":  items,
+		})
+	}))
+	return s
+}
+
+func newTestElasticsearch(url string) *Elasticsearch {
+	return &Elasticsearch{
+		URLs:      []string{url},
+		IndexName: "test-%Y.%m.%d",
+		Timeout:   internal.Duration{Duration: time.Second * 5},
+	}
+}
+
+func getTestMetrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"index": "metrics-cpu"},
+			map[string]interface{}{"value": 42.0},
+			time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		),
+		testutil.MustMetric(
+			"mem",
+			map[string]string{},
+			map[string]interface{}{"value": 42.0},
+			time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		),
+	}
+}
+
+func TestWriteBulkOptions(t *testing.T) {
+	server := newMockBulkServer(t, func(attempt, i int) int { return http.StatusCreated })
+	defer server.Close()
+
+	e := newTestElasticsearch(server.URL)
+	e.IndexTag = "index"
+	e.OpType = "create"
+	e.Pipeline = "telegraf"
+	require.NoError(t, e.Connect())
+	require.NoError(t, e.Write(getTestMetrics()))
+
+	require.Len(t, server.actions, 1)
+	actions := server.actions[0]
+	require.Len(t, actions, 2)
+	require.Equal(t, "metrics-cpu", actions[0]["create"].Index)
+	require.Equal(t, "telegraf", actions[0]["create"].Pipeline)
+	require.Equal(t, "test-2020.01.02", actions[1]["create"].Index)
+}
+
+func TestWriteBulkErrors(t *testing.T) {
+	retryBackoff = 0
+
+	tests := []struct {
+		name     string
+		status   func(attempt, i int) int
+		attempts int
+		err      bool
+	}{
+		{
+			name: "mapping conflict is dropped",
+			status: func(attempt, i int) int {
+				if i == 0 {
+					return http.StatusBadRequest
+				}
+				return http.StatusCreated
+			},
+			attempts: 1,
+		},
+		{
+			name: "server errors fail the write",
+			status: func(attempt, i int) int {
+				return http.StatusServiceUnavailable
+			},
+			attempts: maxBulkAttempts,
+			err:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMockBulkServer(t, tt.status)
+			defer server.Close()
+
+			e := newTestElasticsearch(server.URL)
+			require.NoError(t, e.Connect())
+			err := e.Write(getTestMetrics())
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.attempts, server.attempts)
+		})
+	}
+
+	// Only the documents that failed are sent again.
+	server := newMockBulkServer(t, func(attempt, i int) int {
+		if attempt == 0 && i == 1 {
+			return http.StatusTooManyRequests
+		}
+		return http.StatusCreated
+	})
+	defer server.Close()
+
+	e := newTestElasticsearch(server.URL)
+	require.NoError(t, e.Connect())
+	require.NoError(t, e.Write(getTestMetrics()))
+	require.Len(t, server.actions, 2)
+	require.Len(t, server.actions[1], 1)
+	require.True(t, strings.HasPrefix(server.actions[1][0]["index"].Index, "test-"))
+}
+
+func TestWriteBulkPartialFailure(t *testing.T) {
+	retryBackoff = 0
+
+	// The second document is always rejected because of the load.
+	server := newMockBulkServer(t, func(attempt, i int) int {
+		if attempt == 0 && i == 0 {
+			return http.StatusCreated
+		}
+		return http.StatusTooManyRequests
+	})
+	defer server.Close()
+
+	e := newTestElasticsearch(server.URL)
+	e.IndexTag = "index"
+	require.NoError(t, e.Connect())
+
+	// The indexed document would be duplicated if the write was retried, the
+	// remaining one is dropped.
+	require.NoError(t, e.Write(getTestMetrics()))
+	require.Equal(t, maxBulkAttempts, server.attempts)
+
+	indexed := make(map[string]int)
+	for _, actions := range server.actions {
+		for _, action := range actions {
+			indexed[action["index"].Index]++
+		}
+	}
+	require.Equal(t, map[string]int{"metrics-cpu": 1, "test-2020.01.02": maxBulkAttempts}, indexed)
+}
+
+func TestInvalidOpType(t *testing.T) {
+	e := newTestElasticsearch("http://localhost:9200")
+	e.OpType = "update"
+	require.Error(t, e.Connect())
+}
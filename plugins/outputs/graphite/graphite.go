@@ -5,12 +5,12 @@ import (
 	"errors"
 	"io"
 	"log"
-	"math/rand"
 	"net"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/balancer"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
@@ -24,13 +24,31 @@ type Graphite struct {
 	Timeout  int
 	conns    []net.Conn
 	tlsint.ClientConfig
+	balancer.Config
+
+	balancer *balancer.Balancer
 }
 
 var sampleConfig = `
   ## TCP endpoint for your graphite instance.
   ## If multiple endpoints are configured, output will be load balanced.
-  ## Only one of the endpoints will be written to with each iteration.
+  ## Only one of the endpoints will be written to with each iteration unless
+  ## the consistent_hash balance_strategy is used.
   servers = ["localhost:2003"]
+
+  ## Strategy used to choose the endpoint each batch is written to, one of
+  ## "random", "failover", "round_robin" or "consistent_hash".  With
+  ## "consistent_hash" the batch is split between the endpoints so that each
+  ## series is always written to the same endpoint.
+  # balance_strategy = "random"
+
+  ## Tags whose values select the endpoint with the consistent_hash strategy,
+  ## by default the measurement name and all tags are used.
+  # balance_hash_tags = []
+
+  ## Time an endpoint is avoided after a failed write.
+  # balance_retry_interval = "30s"
+
   ## Prefix metrics name
   prefix = ""
   ## Graphite output template
@@ -66,9 +84,17 @@ func (g *Graphite) Connect() error {
 		return err
 	}
 
-	// Get Connections
-	var conns []net.Conn
-	for _, server := range g.Servers {
+	if g.balancer == nil {
+		g.balancer, err = g.Config.NewBalancer("graphite", g.Servers)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Get Connections, a server that could not be reached keeps a nil
+	// connection so the connections match the servers of the balancer.
+	conns := make([]net.Conn, len(g.Servers))
+	for i, server := range g.Servers {
 		// Dialer with timeout
 		d := net.Dialer{Timeout: time.Duration(g.Timeout) * time.Second}
 
@@ -81,7 +107,7 @@ func (g *Graphite) Connect() error {
 		}
 
 		if err == nil {
-			conns = append(conns, conn)
+			conns[i] = conn
 		}
 	}
 	g.conns = conns
@@ -91,7 +117,9 @@ func (g *Graphite) Connect() error {
 func (g *Graphite) Close() error {
 	// Closing all connections
 	for _, conn := range g.conns {
-		conn.Close()
+		if conn != nil {
+			conn.Close()
+		}
 	}
 	return nil
 }
@@ -129,61 +157,67 @@ func checkEOF(conn net.Conn) {
 	}
 }
 
-// Choose a random server in the cluster to write to until a successful write
-// occurs, logging each unsuccessful. If all servers fail, return error.
+// Write the metrics to the servers chosen by the balance strategy until a
+// successful write occurs, logging each unsuccessful. If all servers fail,
+// return error.
 func (g *Graphite) Write(metrics []telegraf.Metric) error {
-	// Prepare data
-	var batch []byte
 	s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.GraphiteTagSupport)
 	if err != nil {
 		return err
 	}
 
-	for _, metric := range metrics {
-		buf, err := s.Serialize(metric)
-		if err != nil {
-			log.Printf("E! Error serializing some metrics to graphite: %s", err.Error())
-		}
-		batch = append(batch, buf...)
-	}
-
-	err = g.send(batch)
+	err = g.send(s, metrics)
 
 	// try to reconnect and retry to send
 	if err != nil {
 		log.Println("E! Graphite: Reconnecting and retrying: ")
 		g.Connect()
-		err = g.send(batch)
+		err = g.send(s, metrics)
 	}
 
 	return err
 }
 
-func (g *Graphite) send(batch []byte) error {
-	// This will get set to nil if a successful write occurs
-	err := errors.New("Could not write to any Graphite server in cluster\n")
-
-	// Send data to a random server
-	p := rand.Perm(len(g.conns))
-	for _, n := range p {
-		if g.Timeout > 0 {
-			g.conns[n].SetWriteDeadline(time.Now().Add(time.Duration(g.Timeout) * time.Second))
-		}
-		checkEOF(g.conns[n])
-		if _, e := g.conns[n].Write(batch); e != nil {
-			// Error
-			log.Println("E! Graphite Error: " + e.Error())
-			// Close explicitly
-			g.conns[n].Close()
-			// Let's try the next one
-		} else {
-			// Success
-			err = nil
-			break
+func (g *Graphite) send(s serializers.Serializer, metrics []telegraf.Metric) error {
+	err := g.balancer.Write(metrics, func(n int, metrics []telegraf.Metric) error {
+		// Prepare data
+		var batch []byte
+		for _, metric := range metrics {
+			buf, err := s.Serialize(metric)
+			if err != nil {
+				log.Printf("E! Error serializing some metrics to graphite: %s", err.Error())
+			}
+			batch = append(batch, buf...)
 		}
+
+		return g.write(n, batch)
+	})
+	if err != nil {
+		return errors.New("Could not write to any Graphite server in cluster\n")
 	}
+	return nil
+}
 
-	return err
+func (g *Graphite) write(n int, batch []byte) error {
+	conn := g.conns[n]
+	if conn == nil {
+		return errors.New("not connected")
+	}
+
+	if g.Timeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(time.Duration(g.Timeout) * time.Second))
+	}
+	checkEOF(conn)
+	if _, err := conn.Write(batch); err != nil {
+		// Error
+		log.Println("E! Graphite Error: " + err.Error())
+		// Close explicitly
+		conn.Close()
+		// Let's try the next one
+		return err
+	}
+
+	return nil
 }
 
 func init() {
@@ -4,13 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math/rand"
 	"net/url"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/balancer"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
@@ -49,10 +49,12 @@ type InfluxDB struct {
 	SkipDatabaseCreation bool              `toml:"skip_database_creation"`
 	InfluxUintSupport    bool              `toml:"influx_uint_support"`
 	tls.ClientConfig
+	balancer.Config
 
 	Precision string // precision deprecated in 1.0; value is ignored
 
-	clients []Client
+	clients  []Client
+	balancer *balancer.Balancer
 
 	CreateHTTPClientF func(config *HTTPConfig) (Client, error)
 	CreateUDPClientF  func(config *UDPConfig) (Client, error)
@@ -64,11 +66,25 @@ var sampleConfig = `
   ## The full HTTP or UDP URL for your InfluxDB instance.
   ##
   ## Multiple URLs can be specified for a single cluster, only ONE of the
-  ## urls will be written to each interval.
+  ## urls will be written to each interval unless the consistent_hash
+  ## balance_strategy is used.
   # urls = ["unix:///var/run/influxdb.sock"]
   # urls = ["udp://127.0.0.1:8089"]
   # urls = ["http://127.0.0.1:8086"]
 
+  ## Strategy used to choose the URL each batch is written to, one of
+  ## "random", "failover", "round_robin" or "consistent_hash".  With
+  ## "consistent_hash" the batch is split between the URLs so that each
+  ## series is always written to the same URL.
+  # balance_strategy = "random"
+
+  ## Tags whose values select the URL with the consistent_hash strategy,
+  ## by default the measurement name and all tags are used.
+  # balance_hash_tags = []
+
+  ## Time a URL is avoided after a failed write.
+  # balance_retry_interval = "30s"
+
   ## The target database for metrics; will be created as needed.
   ## For UDP url endpoint database needs to be configured on server side.
   # database = "telegraf"
@@ -178,6 +194,12 @@ func (i *InfluxDB) Connect() error {
 		}
 	}
 
+	b, err := i.Config.NewBalancer("influxdb", urls)
+	if err != nil {
+		return err
+	}
+	i.balancer = b
+
 	return nil
 }
 
@@ -196,35 +218,38 @@ func (i *InfluxDB) SampleConfig() string {
 	return sampleConfig
 }
 
-// Write sends metrics to one of the configured servers, logging each
-// unsuccessful. If all servers fail, return an error.
+// Write sends metrics to the servers chosen by the balance strategy, logging
+// each unsuccessful. If all servers fail, return an error.
 func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
+	err := i.balancer.Write(metrics, i.write)
+	if err != nil {
+		return errors.New("could not write any address")
+	}
+	return nil
+}
+
+func (i *InfluxDB) write(n int, metrics []telegraf.Metric) error {
 	ctx := context.Background()
 
-	var err error
-	p := rand.Perm(len(i.clients))
-	for _, n := range p {
-		client := i.clients[n]
-		err = client.Write(ctx, metrics)
-		if err == nil {
-			return nil
-		}
+	client := i.clients[n]
+	err := client.Write(ctx, metrics)
+	if err == nil {
+		return nil
+	}
 
-		switch apiError := err.(type) {
-		case *DatabaseNotFoundError:
-			if !i.SkipDatabaseCreation {
-				err := client.CreateDatabase(ctx, apiError.Database)
-				if err != nil {
-					i.Log.Errorf("When writing to [%s]: database %q not found and failed to recreate",
-						client.URL(), apiError.Database)
-				}
+	switch apiError := err.(type) {
+	case *DatabaseNotFoundError:
+		if !i.SkipDatabaseCreation {
+			err := client.CreateDatabase(ctx, apiError.Database)
+			if err != nil {
+				i.Log.Errorf("When writing to [%s]: database %q not found and failed to recreate",
+					client.URL(), apiError.Database)
 			}
 		}
-
-		i.Log.Errorf("When writing to [%s]: %v", client.URL(), err)
 	}
 
-	return errors.New("could not write any address")
+	i.Log.Errorf("When writing to [%s]: %v", client.URL(), err)
+	return err
 }
 
 func (i *InfluxDB) udpClient(url *url.URL) (Client, error) {
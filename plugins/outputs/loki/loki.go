@@ -0,0 +1,317 @@
+package loki
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logfmt/logfmt"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	defaultURL           = "http://127.0.0.1:3100/loki/api/v1/push"
+	defaultClientTimeout = 5 * time.Second
+	defaultMessageField  = "message"
+
+	// nameLabel is the label containing the metric name.
+	nameLabel = "__name"
+)
+
+var sampleConfig = `
+  ## URL of the Loki push API.
+  # url = "http://127.0.0.1:3100/loki/api/v1/push"
+
+  ## Timeout for HTTP requests.
+  # timeout = "5s"
+
+  ## Tenant ID sent in the X-Scope-OrgID header when Loki runs in
+  ## multi-tenant mode.
+  # tenant_id = ""
+
+  ## HTTP Basic Auth credentials
+  # username = "username"
+  # password = "pa$$word"
+
+  ## Field used as the log line.  Metrics without this field are sent with
+  ## all fields in logfmt format as the log line.
+  # message_field = "message"
+
+  ## HTTP Content-Encoding for write request body, can be set to "gzip" to
+  ## compress body or "identity" to apply no encoding.
+  # content_encoding = "gzip"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Additional HTTP headers
+  # [outputs.loki.headers]
+  #   X-Special-Header = "Special-Value"
+`
+
+type Loki struct {
+	URL             string            `toml:"url"`
+	Timeout         internal.Duration `toml:"timeout"`
+	TenantID        string            `toml:"tenant_id"`
+	Username        string            `toml:"username"`
+	Password        string            `toml:"password"`
+	MessageField    string            `toml:"message_field"`
+	ContentEncoding string            `toml:"content_encoding"`
+	Headers         map[string]string `toml:"headers"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	client *http.Client
+}
+
+// pushRequest is the body of a request to the Loki push API.
+type pushRequest struct {
+	Streams []*stream `json:"streams"`
+}
+
+// stream holds the log entries of a single label set.  Each entry is a pair
+// of the timestamp in nanoseconds, as string, and the log line.
+type stream struct {
+	Labels map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+
+	times []int64
+}
+
+func (s *stream) Len() int {
+	return len(s.Values)
+}
+
+func (s *stream) Less(i, j int) bool {
+	return s.times[i] < s.times[j]
+}
+
+func (s *stream) Swap(i, j int) {
+	s.Values[i], s.Values[j] = s.Values[j], s.Values[i]
+	s.times[i], s.times[j] = s.times[j], s.times[i]
+}
+
+func (l *Loki) Description() string {
+	return "Send logs to Loki"
+}
+
+func (l *Loki) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *Loki) Connect() error {
+	switch l.ContentEncoding {
+	case "", "identity", "gzip":
+	default:
+		return fmt.Errorf("invalid content_encoding %q", l.ContentEncoding)
+	}
+
+	if l.Timeout.Duration == 0 {
+		l.Timeout.Duration = defaultClientTimeout
+	}
+
+	tlsCfg, err := l.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	l.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: l.Timeout.Duration,
+	}
+
+	return nil
+}
+
+func (l *Loki) Close() error {
+	return nil
+}
+
+func (l *Loki) Write(metrics []telegraf.Metric) error {
+	reqBody, err := json.Marshal(l.makeRequest(metrics))
+	if err != nil {
+		return err
+	}
+
+	return l.write(reqBody)
+}
+
+// makeRequest groups the metrics into streams by their tags and name.  The
+// entries of each stream are sorted by time, since Loki rejects entries that
+// are older than the latest entry of the stream.
+func (l *Loki) makeRequest(metrics []telegraf.Metric) *pushRequest {
+	streams := make(map[string]*stream)
+	req := &pushRequest{}
+	for _, m := range metrics {
+		ls := labels(m)
+		key := streamKey(ls)
+		s, ok := streams[key]
+		if !ok {
+			s = &stream{Labels: ls}
+			streams[key] = s
+			req.Streams = append(req.Streams, s)
+		}
+
+		ts := m.Time().UnixNano()
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(ts, 10), l.line(m)})
+		s.times = append(s.times, ts)
+	}
+
+	for _, s := range req.Streams {
+		sort.Stable(s)
+	}
+	return req
+}
+
+// line returns the log line of the metric: the message field if it exists,
+// otherwise all fields sorted by key in logfmt format.
+func (l *Loki) line(m telegraf.Metric) string {
+	if v, ok := m.GetField(l.MessageField); ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+		return fmt.Sprint(v)
+	}
+
+	fields := append([]*telegraf.Field(nil), m.FieldList()...)
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+
+	var buf bytes.Buffer
+	enc := logfmt.NewEncoder(&buf)
+	for _, field := range fields {
+		// Values that cannot be encoded are skipped, the line is still useful.
+		enc.EncodeKeyval(field.Key, field.Value)
+	}
+	return buf.String()
+}
+
+func labels(m telegraf.Metric) map[string]string {
+	labels := map[string]string{
+		nameLabel: m.Name(),
+	}
+	for _, tag := range m.TagList() {
+		labels[sanitizeLabelName(tag.Key)] = tag.Value
+	}
+	return labels
+}
+
+func streamKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[k]))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// sanitizeLabelName replaces the characters not allowed in label names with
+// underscores.
+func sanitizeLabelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func (l *Loki) write(reqBody []byte) error {
+	var reqBodyBuffer io.Reader = bytes.NewBuffer(reqBody)
+
+	if l.ContentEncoding == "gzip" {
+		rc, err := internal.CompressWithGzip(reqBodyBuffer)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		reqBodyBuffer = rc
+	}
+
+	req, err := http.NewRequest(http.MethodPost, l.URL, reqBodyBuffer)
+	if err != nil {
+		return err
+	}
+
+	if l.Username != "" || l.Password != "" {
+		req.SetBasicAuth(l.Username, l.Password)
+	}
+
+	req.Header.Set("User-Agent", "Telegraf/"+internal.Version())
+	req.Header.Set("Content-Type", "application/json")
+	if l.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if l.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", l.TenantID)
+	}
+	for k, v := range l.Headers {
+		if strings.ToLower(k) == "host" {
+			req.Host = v
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Loki rejects entries older than the latest entry of their stream.
+		// Retrying would be rejected again, so the entries are dropped.
+		if resp.StatusCode == http.StatusBadRequest && isOutOfOrder(string(body)) {
+			l.Log.Warnf("Dropped entries rejected by Loki: %s", strings.TrimSpace(string(body)))
+			return nil
+		}
+		return fmt.Errorf("when writing to [%s] received status code: %d: %s",
+			l.URL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+func isOutOfOrder(body string) bool {
+	return strings.Contains(body, "out of order") || strings.Contains(body, "too far behind")
+}
+
+func init() {
+	outputs.Add("loki", func() telegraf.Output {
+		return &Loki{
+			URL:             defaultURL,
+			Timeout:         internal.Duration{Duration: defaultClientTimeout},
+			MessageField:    defaultMessageField,
+			ContentEncoding: "gzip",
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package loki
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func getMetrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		testutil.MustMetric(
+			"syslog",
+			map[string]string{"hostname": "a", "app.name": "su"},
+			map[string]interface{}{"message": "second", "severity_code": 2},
+			time.Unix(0, 2),
+		),
+		testutil.MustMetric(
+			"syslog",
+			map[string]string{"hostname": "b"},
+			map[string]interface{}{"message": "other"},
+			time.Unix(0, 3),
+		),
+		testutil.MustMetric(
+			"syslog",
+			map[string]string{"hostname": "a", "app.name": "su"},
+			map[string]interface{}{"message": "first"},
+			time.Unix(0, 1),
+		),
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{"usage_idle": 42.0, "state": "idle"},
+			time.Unix(0, 4),
+		),
+	}
+}
+
+func newTestLoki(url string) *Loki {
+	return &Loki{
+		URL:          url,
+		MessageField: defaultMessageField,
+		Log:          testutil.Logger{},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var got pushRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		require.Equal(t, "tenant1", r.Header.Get("X-Scope-OrgID"))
+
+		body, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.NewDecoder(body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	plugin := newTestLoki(ts.URL + "/loki/api/v1/push")
+	plugin.ContentEncoding = "gzip"
+	plugin.TenantID = "tenant1"
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(getMetrics()))
+
+	require.Len(t, got.Streams, 3)
+	require.Equal(t, map[string]string{"__name": "syslog", "hostname": "a", "app_name": "su"}, got.Streams[0].Labels)
+	require.Equal(t, [][2]string{{"1", "first"}, {"2", "second"}}, got.Streams[0].Values)
+	require.Equal(t, map[string]string{"__name": "syslog", "hostname": "b"}, got.Streams[1].Labels)
+	require.Equal(t, [][2]string{{"3", "other"}}, got.Streams[1].Values)
+	require.Equal(t, map[string]string{"__name": "cpu"}, got.Streams[2].Labels)
+	require.Equal(t, [][2]string{{"4", "state=idle usage_idle=42"}}, got.Streams[2].Values)
+}
+
+func TestWriteOutOfOrder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "entry with timestamp 1970-01-01 00:00:00.000000001 +0000 UTC ignored, reason: 'entry out of order' for stream: {__name=\"syslog\"}\n")
+	}))
+	defer ts.Close()
+
+	plugin := newTestLoki(ts.URL)
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(getMetrics()))
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{
+			name:   "server error",
+			status: http.StatusInternalServerError,
+			body:   "internal error",
+		},
+		{
+			name:   "bad request",
+			status: http.StatusBadRequest,
+			body:   "error parsing labels",
+		},
+		{
+			name:   "rate limited",
+			status: http.StatusTooManyRequests,
+			body:   "ingestion rate limit exceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer ts.Close()
+
+			plugin := newTestLoki(ts.URL)
+			require.NoError(t, plugin.Connect())
+			err := plugin.Write(getMetrics())
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.body)
+		})
+	}
+}
+
+func TestInvalidContentEncoding(t *testing.T) {
+	plugin := newTestLoki(defaultURL)
+	plugin.ContentEncoding = "br"
+	require.Error(t, plugin.Connect())
+}
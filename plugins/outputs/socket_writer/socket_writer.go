@@ -10,18 +10,22 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/balancer"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 type SocketWriter struct {
 	Address         string
+	Addresses       []string
 	KeepAlivePeriod *internal.Duration
 	tlsint.ClientConfig
+	balancer.Config
 
 	serializers.Serializer
 
-	net.Conn
+	conns    []net.Conn
+	balancer *balancer.Balancer
 }
 
 func (sw *SocketWriter) Description() string {
@@ -42,6 +46,24 @@ func (sw *SocketWriter) SampleConfig() string {
   # address = "unix:///tmp/telegraf.sock"
   # address = "unixgram:///tmp/telegraf.sock"
 
+  ## Multiple URLs can be used instead of address to spread the load, only
+  ## ONE of the addresses will be written to each interval unless the
+  ## consistent_hash balance_strategy is used.
+  # addresses = ["tcp://10.0.0.1:8094", "tcp://10.0.0.2:8094"]
+
+  ## Strategy used to choose the address each batch is written to, one of
+  ## "random", "failover", "round_robin" or "consistent_hash".  With
+  ## "consistent_hash" the batch is split between the addresses so that each
+  ## series is always written to the same address.
+  # balance_strategy = "random"
+
+  ## Tags whose values select the address with the consistent_hash strategy,
+  ## by default the measurement name and all tags are used.
+  # balance_hash_tags = []
+
+  ## Time an address is avoided after a failed write.
+  # balance_retry_interval = "30s"
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -68,9 +90,61 @@ func (sw *SocketWriter) SetSerializer(s serializers.Serializer) {
 }
 
 func (sw *SocketWriter) Connect() error {
-	spl := strings.SplitN(sw.Address, "://", 2)
+	addresses := sw.addresses()
+	if len(addresses) == 0 {
+		return fmt.Errorf("no address configured")
+	}
+
+	for _, address := range addresses {
+		if len(strings.SplitN(address, "://", 2)) != 2 {
+			return fmt.Errorf("invalid address: %s", address)
+		}
+	}
+
+	if sw.balancer == nil {
+		b, err := sw.Config.NewBalancer("socket_writer", addresses)
+		if err != nil {
+			return err
+		}
+		sw.balancer = b
+		sw.conns = make([]net.Conn, len(addresses))
+	}
+
+	// Addresses that cannot be reached yet are connected on write, the
+	// connection fails only if none of them is available.
+	var err error
+	var connected bool
+	for i := range addresses {
+		if sw.conns[i] != nil {
+			connected = true
+			continue
+		}
+		if e := sw.connect(i); e != nil {
+			err = e
+			continue
+		}
+		connected = true
+	}
+	if !connected {
+		return err
+	}
+	return nil
+}
+
+// addresses returns the configured addresses, address is written to first.
+func (sw *SocketWriter) addresses() []string {
+	var addresses []string
+	if sw.Address != "" {
+		addresses = append(addresses, sw.Address)
+	}
+	return append(addresses, sw.Addresses...)
+}
+
+func (sw *SocketWriter) connect(i int) error {
+	address := sw.addresses()[i]
+	spl := strings.SplitN(address, "://", 2)
 	if len(spl) != 2 {
-		return fmt.Errorf("invalid address: %s", sw.Address)
+		return fmt.Errorf("invalid address: %s", address)
 	}
 
 	tlsCfg, err := sw.ClientConfig.TLSConfig()
@@ -88,21 +162,21 @@ func (sw *SocketWriter) Connect() error {
 		return err
 	}
 
-	if err := sw.setKeepAlive(c); err != nil {
-		log.Printf("unable to configure keep alive (%s): %s", sw.Address, err)
+	if err := sw.setKeepAlive(c, spl[0]); err != nil {
+		log.Printf("unable to configure keep alive (%s): %s", address, err)
 	}
 
-	sw.Conn = c
+	sw.conns[i] = c
 	return nil
 }
 
-func (sw *SocketWriter) setKeepAlive(c net.Conn) error {
+func (sw *SocketWriter) setKeepAlive(c net.Conn, network string) error {
 	if sw.KeepAlivePeriod == nil {
 		return nil
 	}
 	tcpc, ok := c.(*net.TCPConn)
 	if !ok {
-		return fmt.Errorf("cannot set keep alive on a %s socket", strings.SplitN(sw.Address, "://", 2)[0])
+		return fmt.Errorf("cannot set keep alive on a %s socket", network)
 	}
 	if sw.KeepAlivePeriod.Duration == 0 {
 		return tcpc.SetKeepAlive(false)
@@ -113,29 +187,51 @@ func (sw *SocketWriter) setKeepAlive(c net.Conn) error {
 	return tcpc.SetKeepAlivePeriod(sw.KeepAlivePeriod.Duration)
 }
 
-// Write writes the given metrics to the destination.
+// Write writes the given metrics to the addresses chosen by the balance
+// strategy.
 // If an error is encountered, it is up to the caller to retry the same write again later.
 // Not parallel safe.
 func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
-	if sw.Conn == nil {
-		// previous write failed with permanent error and socket was closed.
+	if sw.balancer == nil {
 		if err := sw.Connect(); err != nil {
 			return err
 		}
 	}
 
+	var lastErr error
+	err := sw.balancer.Write(metrics, func(i int, metrics []telegraf.Metric) error {
+		lastErr = sw.write(i, metrics)
+		if lastErr != nil && len(sw.conns) > 1 {
+			log.Printf("E! [outputs.socket_writer] When writing to [%s]: %v", sw.addresses()[i], lastErr)
+		}
+		return lastErr
+	})
+	if err != nil && len(sw.conns) == 1 {
+		return lastErr
+	}
+	return err
+}
+
+func (sw *SocketWriter) write(i int, metrics []telegraf.Metric) error {
+	if sw.conns[i] == nil {
+		// previous write failed with permanent error and socket was closed.
+		if err := sw.connect(i); err != nil {
+			return err
+		}
+	}
+
 	for _, m := range metrics {
 		bs, err := sw.Serialize(m)
 		if err != nil {
 			log.Printf("D! [outputs.socket_writer] Could not serialize metric: %v", err)
 			continue
 		}
-		if _, err := sw.Conn.Write(bs); err != nil {
+		if _, err := sw.conns[i].Write(bs); err != nil {
 			//TODO log & keep going with remaining strings
 			if err, ok := err.(net.Error); !ok || !err.Temporary() {
 				// permanent error. close the connection
-				sw.Close()
-				sw.Conn = nil
+				sw.conns[i].Close()
+				sw.conns[i] = nil
 				return fmt.Errorf("closing connection: %v", err)
 			}
 			return err
@@ -145,13 +241,18 @@ func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
-// Close closes the connection. Noop if already closed.
+// Close closes the connections. Noop if already closed.
 func (sw *SocketWriter) Close() error {
-	if sw.Conn == nil {
-		return nil
+	var err error
+	for i, conn := range sw.conns {
+		if conn == nil {
+			continue
+		}
+		if e := conn.Close(); e != nil {
+			err = e
+		}
+		sw.conns[i] = nil
 	}
-	err := sw.Conn.Close()
-	sw.Conn = nil
 	return err
 }
 
@@ -141,7 +141,7 @@ func TestSocketWriter_Write_err(t *testing.T) {
 
 	err = sw.Connect()
 	require.NoError(t, err)
-	sw.Conn.(*net.TCPConn).SetReadBuffer(256)
+	sw.conns[0].(*net.TCPConn).SetReadBuffer(256)
 
 	lconn, err := listener.Accept()
 	require.NoError(t, err)
@@ -151,10 +151,10 @@ func TestSocketWriter_Write_err(t *testing.T) {
 
 	// close the socket to generate an error
 	lconn.Close()
-	sw.Conn.Close()
+	sw.conns[0].Close()
 	err = sw.Write(metrics)
 	require.Error(t, err)
-	assert.Nil(t, sw.Conn)
+	assert.Nil(t, sw.conns[0])
 }
 
 func TestSocketWriter_Write_reconnect(t *testing.T) {
@@ -166,13 +166,13 @@ func TestSocketWriter_Write_reconnect(t *testing.T) {
 
 	err = sw.Connect()
 	require.NoError(t, err)
-	sw.Conn.(*net.TCPConn).SetReadBuffer(256)
+	sw.conns[0].(*net.TCPConn).SetReadBuffer(256)
 
 	lconn, err := listener.Accept()
 	require.NoError(t, err)
 	lconn.(*net.TCPConn).SetWriteBuffer(256)
 	lconn.Close()
-	sw.Conn = nil
+	sw.conns[0] = nil
 
 	wg := sync.WaitGroup{}
 	wg.Add(1)
@@ -195,3 +195,33 @@ func TestSocketWriter_Write_reconnect(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, string(mbsout), string(buf[:n]))
 }
+
+func TestSocketWriter_addresses(t *testing.T) {
+	sw := newSocketWriter()
+	sw.BalanceStrategy = "round_robin"
+
+	var listeners []*net.UDPConn
+	for i := 0; i < 2; i++ {
+		listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		require.NoError(t, err)
+		defer listener.Close()
+		listeners = append(listeners, listener)
+		sw.Addresses = append(sw.Addresses, "udp://"+listener.LocalAddr().String())
+	}
+
+	require.NoError(t, sw.Connect())
+	defer sw.Close()
+
+	metrics := []telegraf.Metric{testutil.TestMetric(1, "test")}
+	mbsout, _ := sw.Serialize(metrics[0])
+
+	// Each address receives every other write.
+	for _, listener := range listeners {
+		require.NoError(t, sw.Write(metrics))
+
+		buf := make([]byte, 256)
+		n, err := listener.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, string(mbsout), string(buf[:n]))
+	}
+}
@@ -0,0 +1,146 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func getMetric() telegraf.Metric {
+	return testutil.MustMetric(
+		"metric one",
+		map[string]string{
+			"tag_one": "tag1",
+		},
+		map[string]interface{}{
+			"int64_one":  int64(42),
+			"uint64_one": uint64(43),
+			"double_one": 1.5,
+			"string_one": "string1",
+			"bool_one":   true,
+		},
+		time.Unix(1577836800, 0),
+	)
+}
+
+func newTestSQL(driver string) *SQL {
+	p := newSQL()
+	p.Driver = driver
+	p.Log = testutil.Logger{}
+	return p
+}
+
+func TestInitUnsupportedDriver(t *testing.T) {
+	p := newTestSQL("oracle")
+	require.Error(t, p.Init())
+}
+
+func TestGenerateStatements(t *testing.T) {
+	tests := []struct {
+		driver      string
+		createTable string
+		tableExists string
+		addColumn   string
+		insert      string
+	}{
+		{
+			driver:      "pgx",
+			createTable: `CREATE TABLE "metric one"("timestamp" TIMESTAMP,"tag_one" TEXT,"bool_one" BOOLEAN,"double_one" DOUBLE PRECISION,"int64_one" BIGINT,"string_one" TEXT,"uint64_one" NUMERIC(20))`,
+			tableExists: `SELECT * FROM "metric one" LIMIT 1`,
+			addColumn:   `ALTER TABLE "metric one" ADD COLUMN "tag_one" TEXT`,
+			insert:      `INSERT INTO "metric one"("timestamp","tag_one","bool_one","double_one","int64_one","string_one","uint64_one") VALUES($1,$2,$3,$4,$5,$6,$7)`,
+		},
+		{
+			driver:      "mysql",
+			createTable: "CREATE TABLE `metric one`(`timestamp` TIMESTAMP(6),`tag_one` TEXT,`bool_one` BOOL,`double_one` DOUBLE,`int64_one` BIGINT,`string_one` TEXT,`uint64_one` BIGINT UNSIGNED)",
+			tableExists: "SELECT * FROM `metric one` LIMIT 1",
+			addColumn:   "ALTER TABLE `metric one` ADD COLUMN `tag_one` TEXT",
+			insert:      "INSERT INTO `metric one`(`timestamp`,`tag_one`,`bool_one`,`double_one`,`int64_one`,`string_one`,`uint64_one`) VALUES(?,?,?,?,?,?,?)",
+		},
+		{
+			driver:      "sqlserver",
+			createTable: "CREATE TABLE [metric one]([timestamp] DATETIME2,[tag_one] NVARCHAR(MAX),[bool_one] BIT,[double_one] FLOAT,[int64_one] BIGINT,[string_one] NVARCHAR(MAX),[uint64_one] DECIMAL(20))",
+			tableExists: "SELECT TOP 1 * FROM [metric one]",
+			addColumn:   "ALTER TABLE [metric one] ADD [tag_one] NVARCHAR(MAX)",
+			insert:      "INSERT INTO [metric one]([timestamp],[tag_one],[bool_one],[double_one],[int64_one],[string_one],[uint64_one]) VALUES(@p1,@p2,@p3,@p4,@p5,@p6,@p7)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			p := newTestSQL(tt.driver)
+			require.NoError(t, p.Init())
+
+			columns := p.columns(getMetric())
+			require.Equal(t, tt.createTable, p.generateCreateTable("metric one", columns))
+			require.Equal(t, tt.tableExists, p.generateTableExists("metric one"))
+			require.Equal(t, tt.addColumn, p.generateAddColumn("metric one", columns[1]))
+			require.Equal(t, tt.insert, p.generateInsert("metric one", columns))
+		})
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	p := newTestSQL("pgx")
+	p.TableTemplate = "CREATE TABLE IF NOT EXISTS {TABLE}({COLUMNS}); COMMENT ON TABLE {TABLE} IS {TABLELITERAL}"
+	p.Convert.Real = "REAL"
+	require.NoError(t, p.Init())
+
+	m := testutil.MustMetric("it's", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+	require.Equal(t,
+		`CREATE TABLE IF NOT EXISTS "it's"("timestamp" TIMESTAMP,"value" REAL); COMMENT ON TABLE "it's" IS 'it''s'`,
+		p.generateCreateTable(m.Name(), p.columns(m)))
+}
+
+func TestColumnNameConflicts(t *testing.T) {
+	p := newTestSQL("pgx")
+	require.NoError(t, p.Init())
+
+	m := testutil.MustMetric("m",
+		map[string]string{"timestamp": "a", "host": "b"},
+		map[string]interface{}{"host": "c", "host_field": "d"},
+		time.Unix(0, 0))
+	var names []string
+	for _, c := range p.columns(m) {
+		names = append(names, c.name)
+	}
+	require.Equal(t, []string{"timestamp", "host", "timestamp_tag", "host_field", "host_field_field"}, names)
+}
+
+func TestPostgresIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	dsn := fmt.Sprintf("host=%s user=postgres sslmode=disable", testutil.GetLocalHost())
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`DROP TABLE IF EXISTS "metric one"`)
+	require.NoError(t, err)
+
+	p := newTestSQL("pgx")
+	p.DataSourceName = dsn
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	require.NoError(t, p.Write([]telegraf.Metric{getMetric()}))
+
+	// A new field adds a column to the existing table.
+	m := getMetric()
+	m.AddField("int64_two", int64(44))
+	require.NoError(t, p.Write([]telegraf.Metric{m}))
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM "metric one"`).Scan(&count))
+	require.Equal(t, 2, count)
+
+	var value sql.NullInt64
+	require.NoError(t, db.QueryRow(`SELECT "int64_two" FROM "metric one" WHERE "int64_two" IS NOT NULL`).Scan(&value))
+	require.Equal(t, int64(44), value.Int64)
+}
@@ -0,0 +1,39 @@
+// +build cgo
+
+package sql
+
+import (
+	"database/sql"
+
+	// The SQLite driver is written in C, it is only available in the builds
+	// with cgo.
+	"github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	dialects["sqlite3"] = &dialect{
+		convert: ConvertStruct{
+			Integer:   "INTEGER",
+			Real:      "REAL",
+			Text:      "TEXT",
+			Timestamp: "TIMESTAMP",
+			Unsigned:  "UNSIGNED BIG INT",
+			Bool:      "BOOLEAN",
+		},
+		tableExistsTemplate: "SELECT * FROM {TABLE} LIMIT 1",
+		tableUpdateTemplate: "ALTER TABLE {TABLE} ADD COLUMN {COLUMN}",
+		quote:               quoteWith(`"`),
+		placeholder:         questionMark,
+		tableMissing: func(db *sql.DB, table string, err error) bool {
+			// SQLite reports most errors of the statements with the same
+			// code, the schema tells if the table is missing.
+			e, ok := err.(sqlite3.Error)
+			if !ok || e.Code != sqlite3.ErrError {
+				return false
+			}
+			var n int
+			err = db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n)
+			return err == nil && n == 0
+		},
+	}
+}
@@ -0,0 +1,148 @@
+// +build cgo
+
+package sql
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteGenerateStatements(t *testing.T) {
+	p := newTestSQL("sqlite3")
+	require.NoError(t, p.Init())
+
+	columns := p.columns(getMetric())
+	require.Equal(t,
+		`CREATE TABLE "metric one"("timestamp" TIMESTAMP,"tag_one" TEXT,"bool_one" BOOLEAN,"double_one" REAL,"int64_one" INTEGER,"string_one" TEXT,"uint64_one" UNSIGNED BIG INT)`,
+		p.generateCreateTable("metric one", columns))
+	require.Equal(t, `SELECT * FROM "metric one" LIMIT 1`, p.generateTableExists("metric one"))
+	require.Equal(t, `ALTER TABLE "metric one" ADD COLUMN "tag_one" TEXT`, p.generateAddColumn("metric one", columns[1]))
+	require.Equal(t,
+		`INSERT INTO "metric one"("timestamp","tag_one","bool_one","double_one","int64_one","string_one","uint64_one") VALUES(?,?,?,?,?,?,?)`,
+		p.generateInsert("metric one", columns))
+}
+
+// newSQLite returns the plugin connected to a SQLite database in a temporary
+// directory, the database opened separately and a function removing them.
+func newSQLite(t *testing.T) (*SQL, *sql.DB, func()) {
+	dir, err := ioutil.TempDir("", "sql")
+	require.NoError(t, err)
+	dsn := filepath.Join(dir, "test.db")
+
+	p := newTestSQL("sqlite3")
+	p.DataSourceName = dsn
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+
+	db, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+
+	return p, db, func() {
+		db.Close()
+		p.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSQLiteWrite(t *testing.T) {
+	p, db, cleanup := newSQLite(t)
+	defer cleanup()
+
+	require.NoError(t, p.Write([]telegraf.Metric{getMetric()}))
+
+	var (
+		ts       time.Time
+		tag      string
+		boolOne  bool
+		double   float64
+		int64One int64
+		str      string
+		uint64v  int64
+	)
+	require.NoError(t, db.QueryRow(`SELECT * FROM "metric one"`).Scan(
+		&ts, &tag, &boolOne, &double, &int64One, &str, &uint64v))
+	require.Equal(t, time.Unix(1577836800, 0).UTC(), ts.UTC())
+	require.Equal(t, "tag1", tag)
+	require.True(t, boolOne)
+	require.Equal(t, 1.5, double)
+	require.Equal(t, int64(42), int64One)
+	require.Equal(t, "string1", str)
+	require.Equal(t, int64(43), uint64v)
+}
+
+func TestSQLiteAddColumn(t *testing.T) {
+	p, db, cleanup := newSQLite(t)
+	defer cleanup()
+
+	require.NoError(t, p.Write([]telegraf.Metric{getMetric()}))
+
+	// A new tag and field add columns to the existing table.
+	m := getMetric()
+	m.AddTag("tag_two", "tag2")
+	m.AddField("int64_two", int64(44))
+	require.NoError(t, p.Write([]telegraf.Metric{m}))
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM "metric one"`).Scan(&count))
+	require.Equal(t, 2, count)
+
+	var tag sql.NullString
+	var value sql.NullInt64
+	require.NoError(t, db.QueryRow(`SELECT "tag_two", "int64_two" FROM "metric one" WHERE "int64_two" IS NOT NULL`).Scan(&tag, &value))
+	require.Equal(t, "tag2", tag.String)
+	require.Equal(t, int64(44), value.Int64)
+
+	// The columns of a table created before are read from the database.
+	p2 := newTestSQL("sqlite3")
+	p2.DataSourceName = p.DataSourceName
+	require.NoError(t, p2.Init())
+	require.NoError(t, p2.Connect())
+	defer p2.Close()
+	require.NoError(t, p2.Write([]telegraf.Metric{m}))
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM "metric one"`).Scan(&count))
+	require.Equal(t, 3, count)
+}
+
+func TestSQLiteTransaction(t *testing.T) {
+	p, db, cleanup := newSQLite(t)
+	defer cleanup()
+	p.TableTemplate = `CREATE TABLE {TABLE}({COLUMNS}, CHECK("value" >= 0))`
+
+	ok := testutil.MustMetric("m", map[string]string{}, map[string]interface{}{"value": int64(1)}, time.Unix(0, 0))
+	bad := testutil.MustMetric("m", map[string]string{}, map[string]interface{}{"value": int64(-1)}, time.Unix(0, 0))
+
+	// The write fails as a whole, nothing is inserted.
+	require.Error(t, p.Write([]telegraf.Metric{ok, ok, bad}))
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM "m"`).Scan(&count))
+	require.Equal(t, 0, count)
+
+	require.NoError(t, p.Write([]telegraf.Metric{ok, ok}))
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM "m"`).Scan(&count))
+	require.Equal(t, 2, count)
+}
+
+func TestSQLiteTableCheckError(t *testing.T) {
+	p, db, cleanup := newSQLite(t)
+	defer cleanup()
+	_, err := db.Exec(`CREATE TABLE "metric one"("timestamp" TIMESTAMP)`)
+	require.NoError(t, err)
+
+	// Only a missing table is created, other errors fail the write.
+	p.TableExistsTemplate = "SELECT missing FROM {TABLE} LIMIT 1"
+	err = p.Write([]telegraf.Metric{getMetric()})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no such column")
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'`).Scan(&count))
+	require.Equal(t, 1, count)
+}
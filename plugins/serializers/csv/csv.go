@@ -0,0 +1,234 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	columnTimestamp   = "timestamp"
+	columnMeasurement = "measurement"
+	prefixTag         = "tag."
+	prefixField       = "field."
+)
+
+type serializer struct {
+	Header          bool
+	Separator       rune
+	TimestampFormat string
+	Columns         []string
+
+	mu sync.Mutex
+	// header is the layout of the last header written by Serialize.
+	header []string
+}
+
+func NewSerializer(header bool, separator string, timestampFormat string, columns []string) (*serializer, error) {
+	s := &serializer{
+		Header:          header,
+		Separator:       ',',
+		TimestampFormat: timestampFormat,
+		Columns:         columns,
+	}
+
+	if separator != "" {
+		r, size := utf8.DecodeRuneInString(separator)
+		if size != len(separator) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+			return nil, fmt.Errorf("invalid csv separator %q", separator)
+		}
+		s.Separator = r
+	}
+
+	if s.TimestampFormat == "" {
+		s.TimestampFormat = "unix"
+	}
+
+	for _, column := range columns {
+		switch {
+		case column == columnTimestamp, column == columnMeasurement:
+		case strings.HasPrefix(column, prefixTag) && len(column) > len(prefixTag):
+		case strings.HasPrefix(column, prefixField) && len(column) > len(prefixField):
+		default:
+			return nil, fmt.Errorf("invalid csv column %q: must be %q, %q or prefixed with %q or %q",
+				column, columnTimestamp, columnMeasurement, prefixTag, prefixField)
+		}
+	}
+
+	return s, nil
+}
+
+// Serialize writes a single row.  When the header is enabled it is emitted
+// before the first row written by this serializer and again whenever the
+// column layout changes.
+func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	columns := s.Columns
+	if len(columns) == 0 {
+		columns = defaultColumns([]telegraf.Metric{metric})
+	}
+
+	s.mu.Lock()
+	header := s.Header && !equalColumns(s.header, columns)
+	if header {
+		s.header = columns
+	}
+	s.mu.Unlock()
+
+	return s.write(columns, header, []telegraf.Metric{metric})
+}
+
+// SerializeBatch writes all metrics using a single column layout, with the
+// header, if enabled, at the start of the batch.  Unless explicit columns are
+// configured the layout is the union of all tags and fields in the batch.
+func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	columns := s.Columns
+	if len(columns) == 0 {
+		columns = defaultColumns(metrics)
+	}
+
+	return s.write(columns, s.Header, metrics)
+}
+
+func (s *serializer) write(columns []string, header bool, metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = s.Separator
+
+	if header {
+		names := make([]string, 0, len(columns))
+		for _, column := range columns {
+			names = append(names, columnName(column))
+		}
+		if err := w.Write(names); err != nil {
+			return nil, err
+		}
+	}
+
+	record := make([]string, len(columns))
+	for _, metric := range metrics {
+		for i, column := range columns {
+			record[i] = s.value(metric, column)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *serializer) value(metric telegraf.Metric, column string) string {
+	switch {
+	case column == columnTimestamp:
+		return formatTimestamp(metric.Time(), s.TimestampFormat)
+	case column == columnMeasurement:
+		return metric.Name()
+	case strings.HasPrefix(column, prefixTag):
+		v, _ := metric.GetTag(column[len(prefixTag):])
+		return v
+	case strings.HasPrefix(column, prefixField):
+		v, ok := metric.GetField(column[len(prefixField):])
+		if !ok {
+			return ""
+		}
+		return formatValue(v)
+	}
+	return ""
+}
+
+// defaultColumns returns the timestamp and measurement followed by the
+// sorted tag keys and the sorted field keys of all metrics.
+func defaultColumns(metrics []telegraf.Metric) []string {
+	tags := make(map[string]bool)
+	fields := make(map[string]bool)
+	for _, metric := range metrics {
+		for _, tag := range metric.TagList() {
+			tags[tag.Key] = true
+		}
+		for _, field := range metric.FieldList() {
+			fields[field.Key] = true
+		}
+	}
+
+	tagColumns := make([]string, 0, len(tags))
+	for k := range tags {
+		tagColumns = append(tagColumns, prefixTag+k)
+	}
+	sort.Strings(tagColumns)
+
+	fieldColumns := make([]string, 0, len(fields))
+	for k := range fields {
+		fieldColumns = append(fieldColumns, prefixField+k)
+	}
+	sort.Strings(fieldColumns)
+
+	columns := make([]string, 0, 2+len(tagColumns)+len(fieldColumns))
+	columns = append(columns, columnTimestamp, columnMeasurement)
+	columns = append(columns, tagColumns...)
+	columns = append(columns, fieldColumns...)
+	return columns
+}
+
+func equalColumns(a, b []string) bool {
+	if a == nil || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func columnName(column string) string {
+	switch {
+	case strings.HasPrefix(column, prefixTag):
+		return column[len(prefixTag):]
+	case strings.HasPrefix(column, prefixField):
+		return column[len(prefixField):]
+	}
+	return column
+}
+
+func formatTimestamp(t time.Time, format string) string {
+	switch format {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unix_ms":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case "unix_us":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Microsecond), 10)
+	case "unix_ns":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+	return t.UTC().Format(format)
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,229 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name            string
+		header          bool
+		separator       string
+		timestampFormat string
+		columns         []string
+		metric          telegraf.Metric
+		expected        string
+	}{
+		{
+			name: "default layout",
+			metric: testutil.MustMetric(
+				"cpu",
+				map[string]string{
+					"host": "localhost",
+					"cpu":  "cpu0",
+				},
+				map[string]interface{}{
+					"usage_idle": 91.5,
+					"running":    true,
+				},
+				time.Unix(1574000000, 0),
+			),
+			expected: "1574000000,cpu,cpu0,localhost,true,91.5\n",
+		},
+		{
+			name:   "header",
+			header: true,
+			metric: testutil.MustMetric(
+				"cpu",
+				map[string]string{
+					"cpu": "cpu0",
+				},
+				map[string]interface{}{
+					"usage_idle": 91.5,
+				},
+				time.Unix(1574000000, 0),
+			),
+			expected: "timestamp,measurement,cpu,usage_idle\n1574000000,cpu,cpu0,91.5\n",
+		},
+		{
+			name:            "separator and timestamp layout",
+			separator:       ";",
+			timestampFormat: time.RFC3339,
+			metric: testutil.MustMetric(
+				"cpu",
+				map[string]string{},
+				map[string]interface{}{
+					"value": int64(42),
+				},
+				time.Unix(1574000000, 0),
+			),
+			expected: "2019-11-17T14:13:20Z;cpu;42\n",
+		},
+		{
+			name:            "unix_ms",
+			timestampFormat: "unix_ms",
+			metric: testutil.MustMetric(
+				"cpu",
+				map[string]string{},
+				map[string]interface{}{
+					"value": uint64(42),
+				},
+				time.Unix(1574000000, 123456789),
+			),
+			expected: "1574000000123,cpu,42\n",
+		},
+		{
+			name:    "explicit columns",
+			header:  true,
+			columns: []string{"field.value", "tag.missing", "measurement", "tag.host"},
+			metric: testutil.MustMetric(
+				"cpu",
+				map[string]string{
+					"host": "localhost",
+				},
+				map[string]interface{}{
+					"value":  42.0,
+					"ignore": 1.0,
+				},
+				time.Unix(1574000000, 0),
+			),
+			expected: "value,missing,measurement,host\n42,,cpu,localhost\n",
+		},
+		{
+			name: "quoting",
+			metric: testutil.MustMetric(
+				"log",
+				map[string]string{},
+				map[string]interface{}{
+					"message": `a "quoted", message`,
+				},
+				time.Unix(1574000000, 0),
+			),
+			expected: "1574000000,log,\"a \"\"quoted\"\", message\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.header, tt.separator, tt.timestampFormat, tt.columns)
+			require.NoError(t, err)
+			actual, err := s.Serialize(tt.metric)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+}
+
+func TestSerializeHeaderOnce(t *testing.T) {
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(1574000000, 0),
+	)
+
+	s, err := NewSerializer(true, "", "", nil)
+	require.NoError(t, err)
+
+	actual, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "timestamp,measurement,value\n1574000000,cpu,42\n", string(actual))
+
+	actual, err = s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "1574000000,cpu,42\n", string(actual))
+}
+
+func TestSerializeHeaderLayoutChange(t *testing.T) {
+	cpu := testutil.MustMetric(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"usage_idle": 91.5,
+		},
+		time.Unix(1574000000, 0),
+	)
+	mem := testutil.MustMetric(
+		"mem",
+		map[string]string{},
+		map[string]interface{}{
+			"free":  int64(1024),
+			"total": int64(4096),
+		},
+		time.Unix(1574000000, 0),
+	)
+
+	s, err := NewSerializer(true, "", "", nil)
+	require.NoError(t, err)
+
+	actual, err := s.Serialize(cpu)
+	require.NoError(t, err)
+	require.Equal(t, "timestamp,measurement,usage_idle\n1574000000,cpu,91.5\n", string(actual))
+
+	// The columns of the second measurement differ, a new header is written.
+	actual, err = s.Serialize(mem)
+	require.NoError(t, err)
+	require.Equal(t, "timestamp,measurement,free,total\n1574000000,mem,1024,4096\n", string(actual))
+
+	actual, err = s.Serialize(mem)
+	require.NoError(t, err)
+	require.Equal(t, "1574000000,mem,1024,4096\n", string(actual))
+
+	actual, err = s.Serialize(cpu)
+	require.NoError(t, err)
+	require.Equal(t, "timestamp,measurement,usage_idle\n1574000000,cpu,91.5\n", string(actual))
+}
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{
+				"host": "a",
+			},
+			map[string]interface{}{
+				"usage_idle": 90.0,
+			},
+			time.Unix(1574000000, 0),
+		),
+		testutil.MustMetric(
+			"mem",
+			map[string]string{
+				"host":   "b",
+				"region": "us-east",
+			},
+			map[string]interface{}{
+				"free": int64(1024),
+			},
+			time.Unix(1574000010, 0),
+		),
+	}
+
+	s, err := NewSerializer(true, "", "", nil)
+	require.NoError(t, err)
+
+	expected := "timestamp,measurement,host,region,free,usage_idle\n" +
+		"1574000000,cpu,a,,,90\n" +
+		"1574000010,mem,b,us-east,1024,\n"
+
+	// Every batch starts with its own header.
+	for i := 0; i < 2; i++ {
+		actual, err := s.SerializeBatch(metrics)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(actual))
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := NewSerializer(false, "ab", "", nil)
+	require.Error(t, err)
+
+	_, err = NewSerializer(false, "", "", []string{"host"})
+	require.Error(t, err)
+}
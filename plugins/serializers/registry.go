@@ -6,12 +6,14 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/serializers/carbon2"
+	"github.com/influxdata/telegraf/plugins/serializers/csv"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
 	"github.com/influxdata/telegraf/plugins/serializers/nowmetric"
 	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
 	"github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
+	"github.com/influxdata/telegraf/plugins/serializers/template"
 	"github.com/influxdata/telegraf/plugins/serializers/wavefront"
 )
 
@@ -65,9 +67,26 @@ type Config struct {
 	Prefix string `toml:"prefix"`
 
 	// Template for converting telegraf metrics into Graphite
-	// only supports Graphite
+	// only supports Graphite, or Go template used for each metric in the
+	// template format
 	Template string `toml:"template"`
 
+	// Go template used for a batch of metrics; template format only
+	BatchTemplate string `toml:"batch_template"`
+
+	// Write a header row; csv format only
+	CSVHeader bool `toml:"csv_header"`
+
+	// Field separator; csv format only
+	CSVSeparator string `toml:"csv_separator"`
+
+	// Timestamp format, one of "unix", "unix_ms", "unix_us", "unix_ns" or a
+	// Go reference time layout; csv format only
+	CSVTimestampFormat string `toml:"csv_timestamp_format"`
+
+	// Ordered list of columns; csv format only
+	CSVColumns []string `toml:"csv_columns"`
+
 	// Timestamp units to use for JSON formatted output
 	TimestampUnits time.Duration `toml:"timestamp_units"`
 
@@ -117,6 +136,10 @@ func NewSerializer(config *Config) (Serializer, error) {
 		serializer, err = NewWavefrontSerializer(config.Prefix, config.WavefrontUseStrict, config.WavefrontSourceOverride)
 	case "prometheus":
 		serializer, err = NewPrometheusSerializer(config)
+	case "csv":
+		serializer, err = NewCSVSerializer(config.CSVHeader, config.CSVSeparator, config.CSVTimestampFormat, config.CSVColumns)
+	case "template":
+		serializer, err = NewTemplateSerializer(config.Template, config.BatchTemplate)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -162,6 +185,14 @@ func NewSplunkmetricSerializer(splunkmetric_hec_routing bool, splunkmetric_multi
 	return splunkmetric.NewSerializer(splunkmetric_hec_routing, splunkmetric_multimetric)
 }
 
+func NewCSVSerializer(header bool, separator string, timestampFormat string, columns []string) (Serializer, error) {
+	return csv.NewSerializer(header, separator, timestampFormat, columns)
+}
+
+func NewTemplateSerializer(metricTemplate string, batchTemplate string) (Serializer, error) {
+	return template.NewSerializer(metricTemplate, batchTemplate)
+}
+
 func NewNowSerializer() (Serializer, error) {
 	return nowmetric.NewSerializer()
 }
@@ -0,0 +1,112 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// TemplateMetric wraps a telegraf.Metric to provide single value accessors
+// that can be used from within a template.
+type TemplateMetric struct {
+	metric telegraf.Metric
+}
+
+func (m TemplateMetric) Name() string {
+	return m.metric.Name()
+}
+
+func (m TemplateMetric) Tag(key string) string {
+	v, _ := m.metric.GetTag(key)
+	return v
+}
+
+func (m TemplateMetric) Field(key string) interface{} {
+	v, _ := m.metric.GetField(key)
+	return v
+}
+
+func (m TemplateMetric) Tags() map[string]string {
+	return m.metric.Tags()
+}
+
+func (m TemplateMetric) Fields() map[string]interface{} {
+	return m.metric.Fields()
+}
+
+func (m TemplateMetric) Time() time.Time {
+	return m.metric.Time()
+}
+
+func (m TemplateMetric) String() string {
+	return fmt.Sprint(m.metric)
+}
+
+type serializer struct {
+	template      *template.Template
+	batchTemplate *template.Template
+}
+
+func NewSerializer(metricTemplate, batchTemplate string) (*serializer, error) {
+	if metricTemplate == "" && batchTemplate == "" {
+		return nil, fmt.Errorf("template or batch_template must be set")
+	}
+
+	s := &serializer{}
+	if metricTemplate != "" {
+		t, err := template.New("template").Parse(metricTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing template: %v", err)
+		}
+		s.template = t
+	}
+	if batchTemplate != "" {
+		t, err := template.New("batch_template").Parse(batchTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing batch_template: %v", err)
+		}
+		s.batchTemplate = t
+	}
+	return s, nil
+}
+
+func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	if s.template == nil {
+		return s.SerializeBatch([]telegraf.Metric{metric})
+	}
+
+	var buf bytes.Buffer
+	err := s.template.Execute(&buf, TemplateMetric{metric})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SerializeBatch renders the batch template with the list of metrics, or if
+// no batch template is set, renders the metric template for each metric.
+func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	if s.batchTemplate == nil {
+		for _, metric := range metrics {
+			err := s.template.Execute(&buf, TemplateMetric{metric})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf.Bytes(), nil
+	}
+
+	batch := make([]TemplateMetric, 0, len(metrics))
+	for _, metric := range metrics {
+		batch = append(batch, TemplateMetric{metric})
+	}
+	err := s.batchTemplate.Execute(&buf, batch)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,79 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var testMetrics = []telegraf.Metric{
+	testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"host": "localhost",
+		},
+		map[string]interface{}{
+			"usage_idle": 91.5,
+		},
+		time.Unix(1574000000, 0),
+	),
+	testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"host": "example.org",
+		},
+		map[string]interface{}{
+			"usage_idle": 42.0,
+		},
+		time.Unix(1574000010, 0),
+	),
+}
+
+func TestSerialize(t *testing.T) {
+	s, err := NewSerializer(`{{.Name}} {{.Tag "host"}} {{.Field "usage_idle"}} {{.Time.Unix}}`+"\n", "")
+	require.NoError(t, err)
+
+	actual, err := s.Serialize(testMetrics[0])
+	require.NoError(t, err)
+	require.Equal(t, "cpu localhost 91.5 1574000000\n", string(actual))
+}
+
+func TestSerializeBatchPerMetric(t *testing.T) {
+	s, err := NewSerializer(`{{.Tag "host"}}={{.Field "usage_idle"}};`, "")
+	require.NoError(t, err)
+
+	actual, err := s.SerializeBatch(testMetrics)
+	require.NoError(t, err)
+	require.Equal(t, "localhost=91.5;example.org=42;", string(actual))
+}
+
+func TestSerializeBatchTemplate(t *testing.T) {
+	s, err := NewSerializer("",
+		`[{{range $i, $m := .}}{{if $i}},{{end}}{"host":"{{$m.Tag "host"}}","value":{{$m.Field "usage_idle"}}}{{end}}]`)
+	require.NoError(t, err)
+
+	actual, err := s.SerializeBatch(testMetrics)
+	require.NoError(t, err)
+	require.Equal(t, `[{"host":"localhost","value":91.5},{"host":"example.org","value":42}]`, string(actual))
+
+	// Without a metric template single metrics use the batch template.
+	actual, err = s.Serialize(testMetrics[1])
+	require.NoError(t, err)
+	require.Equal(t, `[{"host":"example.org","value":42}]`, string(actual))
+}
+
+func TestTemplateErrors(t *testing.T) {
+	_, err := NewSerializer("", "")
+	require.Error(t, err)
+
+	_, err = NewSerializer("{{.Name", "")
+	require.Error(t, err)
+
+	s, err := NewSerializer("{{.Missing}}", "")
+	require.NoError(t, err)
+	_, err = s.Serialize(testMetrics[0])
+	require.Error(t, err)
+}